@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/wordbank"
+)
+
+// runCompile reads and filters a wordbank once and writes it in the compiled
+// format, which later runs memory-map instead of parsing
+func runCompile(args []string) error {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	cfg := &config.Config{}
+	config.RegisterWordBankFlags(flags, cfg)
+	config.RegisterWordFilterFlags(flags, cfg)
+	config.RegisterLogFlags(flags, cfg)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: essay_analyzer compile [options] <wordbank> <output>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a word bank and an output file")
+	}
+	if err := cfg.ValidateWordFilter(); err != nil {
+		return err
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// The compiled format is the sorted backend, so build that directly
+	cfg.WordBankFile = flags.Arg(0)
+	cfg.WordBankBackend = wordbank.BackendSorted
+	wordBank, err := loadWordBank(context.Background(), cfg, fetcher.New(0, logger), logger)
+	if err != nil {
+		return err
+	}
+	defer wordBank.Close()
+
+	// Replace the output in one step, since running analyses may have it mapped
+	output := flags.Arg(1)
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".wordbank-*")
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("creating output file: %w", err)
+	}
+
+	if err := wordBank.WriteCompiled(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	logger.Info("compiled wordbank", "words", wordBank.Size(), "output", output)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/firefly/essay-analyzer/internal/diff"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+)
+
+// runDiff compares two results produced by the analyzer
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "Report format: text or json")
+	limit := flags.Int("limit", 20, "Maximum words listed per section (-1 = all)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: essay_analyzer diff [options] <base-result> <target-result>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected two result files, got %d", flags.NArg())
+	}
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unsupported report format %q (supported: text, json)", *format)
+	}
+
+	base, err := loadCorpus(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	target, err := loadCorpus(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report := diff.Compare(base, target)
+
+	if *format == "json" {
+		return diff.WriteJSON(os.Stdout, report, *limit)
+	}
+	return diff.WriteText(os.Stdout, report, *limit)
+}
+
+// loadCorpus reads a result file or aggregator export into a diff corpus.
+// Exports carry every word count, so they give a complete comparison. A result
+// file lists only the top words, so its corpus is truncated unless it lists fewer
+// than its run asked for.
+func loadCorpus(path string) (diff.Corpus, error) {
+	export, err := outputio.ReadExport(path)
+	if err == nil {
+		return diff.Corpus{
+			Name:        path,
+			WordCounts:  export.Aggregator.WordCounts,
+			TotalWords:  export.Aggregator.TotalWordsProcessed,
+			TotalEssays: export.Aggregator.TotalEssaysProcessed,
+		}, nil
+	}
+	if !errors.Is(err, outputio.ErrNotExport) {
+		return diff.Corpus{}, err
+	}
+
+	result, err := outputio.ReadResult(path)
+	if err != nil {
+		return diff.Corpus{}, err
+	}
+
+	counts := make(map[string]int, len(result.TopWords))
+	for _, wc := range result.TopWords {
+		counts[wc.Word] = wc.Count
+	}
+
+	return diff.Corpus{
+		Name:        path,
+		WordCounts:  counts,
+		TotalWords:  result.TotalWordsProcessed,
+		TotalEssays: result.TotalEssaysProcessed,
+		Truncated:   result.Run == nil || len(result.TopWords) >= result.Run.TopWords,
+	}, nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/distributed"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/metrics"
+	"github.com/firefly/essay-analyzer/internal/parser"
+	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/tracing"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
+)
+
+// runCoordinator leases batches of URLs to remote workers and writes the merged result
+func runCoordinator(args []string) error {
+	startedAt := time.Now()
+
+	flags := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	cfg := &config.Config{}
+	flags.StringVar(&cfg.URLsFile, "urls-file", "", "Path to file containing URLs (required)")
+	flags.StringVar(&cfg.Format, "format", outputio.FormatJSON, "Output format: json, ndjson, csv, tsv, markdown or sqlite")
+	flags.StringVar(&cfg.Output, "output", "", "Output file (default stdout; required for sqlite)")
+	flags.StringVar(&cfg.Export, "export", "", "Write every word count and document frequency to this file for merging")
+	flags.StringVar(&cfg.ConcordanceFile, "concordance-file", "", "Write the concordance sampled by workers with --concordance to this file as JSON Lines")
+	config.RegisterDedupFlags(flags, cfg)
+	config.RegisterLogFlags(flags, cfg)
+	listen := flags.String("listen", ":8080", "Address to serve the worker API on")
+	batchSize := flags.Int("batch-size", 100, "URLs per leased batch")
+	leaseTimeout := flags.Duration("lease-timeout", 2*time.Minute, "Reassign a batch if its worker has not renewed the lease within this time")
+	linger := flags.Duration("linger", 5*time.Second, "Keep serving after completion so polling workers learn the run is done")
+	flags.Parse(args)
+
+	if cfg.URLsFile == "" {
+		return fmt.Errorf("--urls-file is required")
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	writer, err := outputio.NewWriter(cfg.Format, cfg.Output)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	urls, err := loadURLs(cfg.URLsFile)
+	if err != nil {
+		return err
+	}
+	var duplicates int64
+	if cfg.Dedup {
+		urls, duplicates = dedupURLList(urls, urlnorm.New(cfg.StripParamList()))
+	}
+
+	agg := aggregator.New(logger)
+	coordinator := distributed.NewCoordinator(urls, *batchSize, *leaseTimeout, agg)
+
+	server := &http.Server{Addr: *listen, Handler: coordinator.Handler()}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	logger.Info("coordinator listening", "addr", *listen, "urls", len(urls), "batch_size", *batchSize)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case <-coordinator.Done():
+	case err := <-serveErr:
+		return fmt.Errorf("serving worker API: %w", err)
+	case <-ctx.Done():
+		server.Close()
+		return fmt.Errorf("interrupted with %d of %d batches complete",
+			coordinator.Status().CompletedBatches, coordinator.Status().TotalBatches)
+	}
+
+	select {
+	case <-time.After(*linger):
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+
+	urlsDigest, err := outputio.DigestFile(cfg.URLsFile)
+	if err != nil {
+		return err
+	}
+
+	urlCounts := coordinator.URLCounts()
+	urlCounts.Duplicates = duplicates
+
+	topN := config.GetTopWordsCount()
+	result := outputio.NewResult(agg, topN)
+	result.Run = &outputio.RunManifest{
+		ToolVersion: version,
+		StartedAt:   startedAt,
+		FinishedAt:  time.Now(),
+		URLsFile:    urlsDigest,
+		Config:      cfg,
+		TopWords:    topN,
+		URLs:        urlCounts,
+	}
+
+	if cfg.Export != "" {
+		if err := outputio.WriteExport(cfg.Export, &outputio.Export{Aggregator: agg.Snapshot(), Run: result.Run}); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ConcordanceFile != "" {
+		if err := outputio.WriteConcordance(cfg.ConcordanceFile, result.Concordance); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Write(result); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// runWorker processes batches leased from a coordinator with the local pipeline
+func runWorker(args []string) error {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	cfg := &config.Config{}
+	flags.StringVar(&cfg.WordBankFile, "wordbank-file", "", "Path or http(s) URL of word bank file (default: count every word)")
+	config.RegisterWordBankFlags(flags, cfg)
+	config.RegisterReloadFlags(flags, cfg)
+	config.RegisterWordFilterFlags(flags, cfg)
+	config.RegisterLanguageFlags(flags, cfg)
+	config.RegisterAnalysisFlags(flags, cfg)
+	flags.Float64Var(&cfg.RateLimit, "rate-limit", 0, "Requests per second (0 = no limit unless robots.txt specifies)")
+	config.RegisterLogFlags(flags, cfg)
+	flags.StringVar(&cfg.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	config.RegisterWorkerFlags(flags, cfg)
+	config.RegisterFetchFlags(flags, cfg)
+	config.RegisterDedupFlags(flags, cfg)
+	config.RegisterNearDupFlags(flags, cfg)
+	flags.StringVar(&cfg.TraceFile, "trace-file", "", "Write a trace of every URL through the pipeline to this file as OTLP/JSON lines")
+	coordinatorURL := flags.String("coordinator", "", "Coordinator base URL, e.g. http://host:8080 (required)")
+	id := flags.String("id", defaultWorkerID(), "Worker ID reported to the coordinator")
+	flags.Parse(args)
+
+	if *coordinatorURL == "" {
+		return fmt.Errorf("--coordinator is required")
+	}
+	if err := cfg.ValidateWordFilter(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateLanguages(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateAnalysis(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateWorkers(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateReload(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateFetch(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateNearDup(); err != nil {
+		return err
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fetch := fetcher.NewWithOptions(cfg.RateLimit, fetcherOptions(cfg), logger)
+
+	wordBank, err := loadWordBank(ctx, mainConfig(cfg), fetch, logger)
+	if err != nil {
+		return err
+	}
+	defer wordBank.Close()
+	if err := fetch.LoadRobotsTxt(ctx, "https://www.engadget.com"); err != nil {
+		logger.Warn("failed to load robots.txt", "error", err)
+	}
+
+	htmlParser := parser.New(logger)
+	textProcessor := processor.New(wordBank, logger)
+	if cfg.CountPhraseWords {
+		textProcessor.CountPhraseWords()
+	}
+	if cfg.Statistics {
+		textProcessor.CollectStatistics()
+	}
+	if cfg.Concordance > 0 {
+		textProcessor.KeepContexts(cfg.Concordance, cfg.ConcordanceWidth, cfg.ConcordanceWordList())
+	}
+	languageBanks, err := detectLanguages(ctx, cfg, fetch, textProcessor, logger)
+	if err != nil {
+		return err
+	}
+	defer languageBanks.Close()
+
+	var reg *metrics.Registry
+	if cfg.MetricsAddr != "" {
+		reg = metrics.NewRegistry()
+		metricsServer, err := serveMetrics(cfg.MetricsAddr, reg, logger)
+		if err != nil {
+			return err
+		}
+		defer metricsServer.Close()
+		fetch.Instrument(reg)
+		htmlParser.Instrument(reg)
+		textProcessor.Instrument(reg)
+	}
+
+	var tracer *tracing.Tracer
+	if cfg.TraceFile != "" {
+		if tracer, err = tracing.Create(cfg.TraceFile, traceService); err != nil {
+			return err
+		}
+	}
+
+	// Seen articles span every batch this worker runs, so duplicates are found within each worker
+	var articles *articleDedup
+	if cfg.Dedup {
+		articles = newArticleDedup(urlnorm.New(cfg.StripParamList()))
+	}
+	nearDups := simhash.NewIndex(cfg.NearDupThreshold)
+
+	// The wordbank is reloaded between and during batches; each batch is recounted on its own
+	reloader := newWordBankReloader(mainConfig(cfg), wordBank, fetch, textProcessor, logger)
+	defer reloader.Close()
+	reloader.Start(ctx)
+
+	batch := pipelineBatch(cfg, logger, fetch, htmlParser, textProcessor, reloader, articles, nearDups, configureWorkers(cfg), newPipelineMetrics(reg), tracer)
+
+	worker := distributed.NewWorker(*id, *coordinatorURL, batch, logger)
+	if err := worker.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		tracer.Close()
+		return err
+	}
+	return tracer.Close()
+}
+
+// pipelineBatch runs each leased batch through the local pipeline into a fresh aggregator
+func pipelineBatch(
+	cfg *config.Config,
+	logger *slog.Logger,
+	fetch *fetcher.Fetcher,
+	htmlParser *parser.Parser,
+	textProcessor *processor.Processor,
+	reloader *wordBankReloader,
+	articles *articleDedup,
+	nearDups *simhash.Index,
+	workerCfg WorkerConfig,
+	pm *pipelineMetrics,
+	tracer *tracing.Tracer,
+) distributed.BatchFunc {
+	return func(ctx context.Context, urls []string) (*aggregator.Snapshot, outputio.URLCounts, error) {
+		agg := newAggregator(cfg, logger)
+		reloader.track(agg)
+		stats := &PipelineStats{}
+
+		err := runPipeline(ctx, logger, sliceURLSource(urls), fetch, htmlParser, textProcessor, reloader, agg, articles, nearDups, workerCfg, stats, pm, tracer)
+		if err == nil {
+			// The pipeline stops quietly on cancellation; a partial batch must not be reported
+			err = ctx.Err()
+		}
+		if err != nil {
+			return nil, stats.Counts(), err
+		}
+
+		reloader.recount(agg)
+		return agg.Snapshot(), stats.Counts(), nil
+	}
+}
+
+// defaultWorkerID identifies a worker by host name and process ID
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/parser"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
+)
+
+// batchResult is what one call of a distributed.BatchFunc returned
+type batchResult struct {
+	snapshot *aggregator.Snapshot
+	counts   outputio.URLCounts
+	err      error
+}
+
+// newArticleServer serves the given article bodies at /article/<key>, each
+// declaring itself as its canonical URL. Before serving a key in hold it signals
+// reached and waits for release.
+func newArticleServer(bodies map[string]string, hold string, reached chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var key string
+		if _, err := fmt.Sscanf(r.URL.Path, "/article/%s", &key); err != nil || bodies[key] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if key == hold {
+			reached <- struct{}{}
+			<-release
+		}
+		fmt.Fprintf(w, `<html><head><link rel="canonical" href="/article/%s"></head><body><article><div data-article-body="true">%s</div></article></body></html>`, key, bodies[key])
+	}))
+}
+
+func TestPipelineBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	writeWordBankFile(t, path, "robot")
+	textProcessor, reloader := testReloader(t, path, true)
+
+	reached, release := make(chan struct{}), make(chan struct{})
+	content := newArticleServer(map[string]string{
+		"1": "A robot built another robot",
+		"2": "The robot sold a laptop",
+		"3": "A robot fixed the laptop",
+		"4": "Every laptop needs a charger",
+	}, "4", reached, release)
+	defer content.Close()
+	article := func(key string) string { return content.URL + "/article/" + key }
+
+	cfg := &config.Config{}
+	logger := logging.Discard()
+	batch := pipelineBatch(cfg, logger, fetcher.New(0, logger), parser.New(logger), textProcessor, reloader,
+		newArticleDedup(urlnorm.New(nil)), nil, WorkerConfig{Fetchers: 1, Parsers: 1, Processors: 1}, newPipelineMetrics(nil), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	run := func(urls ...string) <-chan batchResult {
+		done := make(chan batchResult, 1)
+		go func() {
+			snapshot, counts, err := batch(ctx, urls)
+			done <- batchResult{snapshot, counts, err}
+		}()
+		return done
+	}
+
+	first := <-run(article("1"), article("2"))
+	if first.err != nil {
+		t.Fatalf("Expected the first batch to succeed, got %v", first.err)
+	}
+	if want := map[string]int{"robot": 3}; !reflect.DeepEqual(first.snapshot.WordCounts, want) {
+		t.Errorf("Expected first batch counts %v, got %v", want, first.snapshot.WordCounts)
+	}
+	if first.counts.Succeeded != 2 {
+		t.Errorf("Expected 2 articles counted in the first batch, got %d", first.counts.Succeeded)
+	}
+
+	// Article 3 is counted with the first wordbank, then the wordbank is reloaded
+	// before article 4 is fetched
+	done := run(article("1")+"?copy=1", article("3"), article("4"))
+	select {
+	case <-reached:
+	case <-ctx.Done():
+		t.Fatal("Expected the second batch to fetch article 4")
+	}
+	for !retained(reloader, article("3")) {
+		select {
+		case <-ctx.Done():
+			t.Fatal("Expected article 3 to be counted before the reload")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	reloadWordBank(t, reloader, path, "robot", "laptop")
+	close(release)
+
+	second := <-done
+	if second.err != nil {
+		t.Fatalf("Expected the second batch to succeed, got %v", second.err)
+	}
+	// The copy of article 1 was already counted by the first batch, and the batch is
+	// recounted with the reloaded wordbank before it is reported
+	if want := map[string]int{"robot": 1, "laptop": 2}; !reflect.DeepEqual(second.snapshot.WordCounts, want) {
+		t.Errorf("Expected second batch counts %v, got %v", want, second.snapshot.WordCounts)
+	}
+	if second.counts.Succeeded != 2 || second.counts.CanonicalDuplicates != 1 {
+		t.Errorf("Expected 2 articles counted and 1 duplicate in the second batch, got %+v", second.counts)
+	}
+}
+
+// retained reports whether reloader holds the text of the article at url
+func retained(reloader *wordBankReloader, url string) bool {
+	reloader.textsMu.Lock()
+	defer reloader.textsMu.Unlock()
+	_, ok := reloader.texts[url]
+	return ok
+}
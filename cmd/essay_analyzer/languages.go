@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/langid"
+	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/wordbank"
+)
+
+// mainConfig returns the configuration the wordbank file is loaded with, which is
+// that of the main language when detecting languages
+func mainConfig(cfg *config.Config) *config.Config {
+	languages := cfg.LanguageList()
+	if len(languages) == 0 {
+		return cfg
+	}
+	return cfg.ForLanguage(languages[0])
+}
+
+// tokenizer returns the regex splitting text in a language into words. English
+// keeps the ASCII tokenizer the processor always used; other languages need
+// accented letters.
+func tokenizer(code string) *regexp.Regexp {
+	if code == "en" {
+		return regexp.MustCompile(`[a-zA-Z]+`)
+	}
+	return regexp.MustCompile(`\p{L}+`)
+}
+
+// languageWordBanks holds the wordbanks of the languages other than the main one
+type languageWordBanks []*wordbank.WordBank
+
+// Close closes every wordbank
+func (banks languageWordBanks) Close() {
+	for _, wordBank := range banks {
+		wordBank.Close()
+	}
+}
+
+// detectLanguages makes textProcessor identify the language of each article when
+// cfg lists languages, loading a wordbank for each language after the main one.
+// Articles in other languages are skipped. The wordbanks are returned to be closed
+// once processing is done.
+func detectLanguages(ctx context.Context, cfg *config.Config, fetch *fetcher.Fetcher, textProcessor *processor.Processor, logger *slog.Logger) (languageWordBanks, error) {
+	languages := cfg.LanguageList()
+	if len(languages) == 0 {
+		return nil, nil
+	}
+
+	identifier, err := langid.New()
+	if err != nil {
+		return nil, err
+	}
+	textProcessor.DetectLanguages(identifier, languages[0], tokenizer(languages[0]))
+
+	var banks languageWordBanks
+	for _, code := range languages[1:] {
+		wordBank, err := loadWordBank(ctx, cfg.ForLanguage(code), fetch, logger.With("language", code))
+		if err != nil {
+			banks.Close()
+			return nil, fmt.Errorf("language %s: %w", code, err)
+		}
+		banks = append(banks, wordBank)
+		textProcessor.AddLanguage(code, wordBank, tokenizer(code))
+	}
+	logger.Info("detecting languages", "main", languages[0], "languages", languages)
+	return banks, nil
+}
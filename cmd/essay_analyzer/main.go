@@ -2,37 +2,58 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/firefly/essay-analyzer/internal/aggregator"
 	"github.com/firefly/essay-analyzer/internal/config"
 	"github.com/firefly/essay-analyzer/internal/fetcher"
 	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/metrics"
 	"github.com/firefly/essay-analyzer/internal/parser"
 	"github.com/firefly/essay-analyzer/internal/processor"
-	"github.com/firefly/essay-analyzer/internal/wordbank"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/tracing"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
 )
 
-// Pipeline data structures for passing data between stages
+// traceService is the service name recorded in exported traces
+const traceService = "essay-analyzer"
+
+// Pipeline data structures for passing data between stages. Each carries the
+// URL's root trace span (nil when tracing is off) so every stage can add to it.
 type URLJob struct {
-	URL string
+	URL  string
+	Span *tracing.Span
 }
 
 type HTMLResult struct {
-	URL     string
-	Content io.Reader
-	Error   error
+	URL      string
+	FinalURL string        // Where the page was served from after redirects
+	Content  io.ReadCloser // Closed by the parser stage once read
+	Error    error
+	Span     *tracing.Span
 }
 
 type TextResult struct {
 	URL   string
 	Text  string
 	Error error
+	Span  *tracing.Span
+}
+
+type CountResult struct {
+	aggregator.ProcessingResult
+	Fingerprint   uint64 // SimHash of the article text, set when Fingerprinted
+	Fingerprinted bool
+	Skipped       string // Why the article was not counted, such as its language being unsupported, or ""
+	Text          string // The article text, kept only when the wordbank reloader recounts
+	Span          *tracing.Span
 }
 
 // WorkerConfig holds configuration for worker pool sizes
@@ -40,9 +61,52 @@ type WorkerConfig struct {
 	Fetchers   int
 	Parsers    int
 	Processors int
+	Auto       bool // Move workers between stages towards the bottleneck during the run
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				log.Fatalf("diff: %v", err)
+			}
+			return
+		case "merge":
+			if err := runMerge(os.Args[2:]); err != nil {
+				log.Fatalf("merge: %v", err)
+			}
+			return
+		case "coordinator":
+			if err := runCoordinator(os.Args[2:]); err != nil {
+				log.Fatalf("coordinator: %v", err)
+			}
+			return
+		case "worker":
+			if err := runWorker(os.Args[2:]); err != nil {
+				log.Fatalf("worker: %v", err)
+			}
+			return
+		case "compile":
+			if err := runCompile(os.Args[2:]); err != nil {
+				log.Fatalf("compile: %v", err)
+			}
+			return
+		case "serve":
+			if err := runServe(os.Args[2:]); err != nil {
+				log.Fatalf("serve: %v", err)
+			}
+			return
+		}
+	}
+
+	runAnalyze()
+}
+
+// runAnalyze runs the default command: fetch, parse and count words for every URL
+func runAnalyze() {
+	startedAt := time.Now()
+
 	// Parse command line flags
 	cfg, err := config.ParseFlags()
 	if err != nil {
@@ -54,57 +118,111 @@ func main() {
 		log.Fatalf("File validation error: %v", err)
 	}
 
-	if cfg.Verbose {
-		fmt.Println("🚀 Starting Essay Analyzer...")
-		fmt.Printf("  URLs file: %s\n", cfg.URLsFile)
-		fmt.Printf("  Wordbank file: %s\n", cfg.WordBankFile)
-		fmt.Printf("  Workers: %d\n", cfg.Workers)
-		fmt.Printf("  Rate limit: %.1f req/sec\n", cfg.RateLimit)
+	// All diagnostics go to stderr so results written to stdout stay intact
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
 	}
 
-	// Initialize components
-	wordBank, err := wordbank.New(cfg.WordBankFile)
+	logger.Info("starting essay analyzer",
+		"urls_file", cfg.URLsFile,
+		"wordbank_file", cfg.WordBankFile,
+		"workers", cfg.Workers,
+		"rate_limit", cfg.RateLimit)
+
+	// Open the output early so a bad --format or --output fails before any fetching
+	writer, err := outputio.NewWriter(cfg.Format, cfg.Output)
 	if err != nil {
-		log.Fatalf("Failed to load wordbank: %v", err)
+		log.Fatalf("Output error: %v", err)
 	}
 
-	if cfg.Verbose {
-		fmt.Printf("  Loaded wordbank: %d words\n", wordBank.Size())
+	// Failing from here on discards the output rather than leaving it partly written
+	fatalf := func(format string, args ...any) {
+		writer.Close()
+		log.Fatalf(format, args...)
 	}
 
 	// Initialize fetcher
-	fetch := fetcher.New(cfg.RateLimit, cfg.Verbose)
+	fetch := fetcher.NewWithOptions(cfg.RateLimit, fetcherOptions(cfg), logger)
+
+	// Initialize components; a remote wordbank is fetched with the same fetcher
+	wordBank, err := loadWordBank(context.Background(), mainConfig(cfg), fetch, logger)
+	if err != nil {
+		fatalf("Failed to load wordbank: %v", err)
+	}
+	defer wordBank.Close()
 
 	// Initialize parser and processor
-	htmlParser := parser.New(cfg.Verbose)
-	textProcessor := processor.New(wordBank, cfg.Verbose)
+	htmlParser := parser.New(logger)
+	textProcessor := processor.New(wordBank, logger)
+	if cfg.CountPhraseWords {
+		textProcessor.CountPhraseWords()
+	}
+	if cfg.Statistics {
+		textProcessor.CollectStatistics()
+	}
+	if cfg.Concordance > 0 {
+		textProcessor.KeepContexts(cfg.Concordance, cfg.ConcordanceWidth, cfg.ConcordanceWordList())
+	}
+	languageBanks, err := detectLanguages(context.Background(), cfg, fetch, textProcessor, logger)
+	if err != nil {
+		fatalf("Failed to load language wordbanks: %v", err)
+	}
+	defer languageBanks.Close()
 
 	// Initialize aggregator
-	agg := aggregator.New(cfg.Verbose)
+	agg := newAggregator(cfg, logger)
+	if cfg.Format == outputio.FormatSQLite {
+		// SQLite output stores per-article counts alongside the totals
+		agg.RetainArticles()
+	}
 
-	// Calculate worker distribution
-	workerCfg := calculateWorkerDistribution(cfg.Workers)
+	// Swap in the wordbank when it changes; articles counted with an earlier one are
+	// recounted at the end or reported per wordbank version
+	reloader := newWordBankReloader(mainConfig(cfg), wordBank, fetch, textProcessor, logger)
+	defer reloader.Close()
+	reloader.track(agg)
+
+	// Expose metrics while the run is in progress
+	var reg *metrics.Registry
+	if cfg.MetricsAddr != "" {
+		reg = metrics.NewRegistry()
+		metricsServer, err := serveMetrics(cfg.MetricsAddr, reg, logger)
+		if err != nil {
+			fatalf("Metrics error: %v", err)
+		}
+		defer metricsServer.Close()
+		fetch.Instrument(reg)
+		htmlParser.Instrument(reg)
+		textProcessor.Instrument(reg)
+	}
 
-	if cfg.Verbose {
-		fmt.Printf("  Worker distribution: %d fetchers, %d parsers, %d processors\n",
-			workerCfg.Fetchers, workerCfg.Parsers, workerCfg.Processors)
+	// Record a trace per URL when requested
+	var tracer *tracing.Tracer
+	if cfg.TraceFile != "" {
+		tracer, err = tracing.Create(cfg.TraceFile, traceService)
+		if err != nil {
+			fatalf("Tracing error: %v", err)
+		}
 	}
 
+	// Calculate worker distribution
+	workerCfg := configureWorkers(cfg)
+
+	logger.Info("worker distribution",
+		"fetchers", workerCfg.Fetchers,
+		"parsers", workerCfg.Parsers,
+		"processors", workerCfg.Processors,
+		"auto_tune", workerCfg.Auto)
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// For single domain optimization, we can pre-load robots.txt
 	// This assumes all URLs are from the same domain (Engadget)
-	if cfg.Verbose {
-		fmt.Println("  Loading robots.txt...")
-	}
-
-	// Load robots.txt for engadget.com (assuming all URLs are from same domain)
 	if err := fetch.LoadRobotsTxt(ctx, "https://www.engadget.com"); err != nil {
-		if cfg.Verbose {
-			fmt.Printf("  Warning: Failed to load robots.txt: %v\n", err)
-		}
+		logger.Warn("failed to load robots.txt", "error", err)
 	}
 
 	// Handle interrupt signals
@@ -112,26 +230,79 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\n⚠️  Received interrupt signal, shutting down gracefully...")
+		logger.Warn("received interrupt signal, shutting down gracefully")
 		cancel()
 	}()
 
 	// Run the pipeline
-	if err := runPipeline(ctx, cfg, fetch, htmlParser, textProcessor, agg, workerCfg); err != nil {
-		log.Fatalf("Pipeline error: %v", err)
+	stats := &PipelineStats{}
+
+	// Debug logs already report every URL, so only show progress without them
+	var progress *progressReporter
+	if cfg.Progress && !logger.Enabled(ctx, slog.LevelDebug) {
+		total, err := countURLs(cfg.URLsFile)
+		if err != nil {
+			fatalf("Counting URLs: %v", err)
+		}
+		progress = startProgress(total, stats)
+	}
+
+	source := fileURLSource(cfg.URLsFile, logger)
+	if cfg.Dedup {
+		source = dedupURLs(source, urlnorm.New(cfg.StripParamList()), urlnorm.NewSet(), stats, logger)
 	}
 
-	// Output final results
-	if cfg.Verbose {
-		agg.PrintFinalStats()
+	var articles *articleDedup
+	if cfg.Dedup {
+		articles = newArticleDedup(urlnorm.New(cfg.StripParamList()))
 	}
+	nearDups := simhash.NewIndex(cfg.NearDupThreshold)
+
+	reloader.Start(ctx)
+	err = runPipeline(ctx, logger, source, fetch, htmlParser, textProcessor, reloader, agg, articles, nearDups, workerCfg, stats, newPipelineMetrics(reg), tracer)
+	if progress != nil {
+		progress.Stop()
+	}
+	if err != nil {
+		fatalf("Pipeline error: %v", err)
+	}
+	if err := tracer.Close(); err != nil {
+		fatalf("Tracing error: %v", err)
+	}
+	reloader.Stop()
+	reloader.recount(agg)
+	wordBank = reloader.WordBank(wordBank)
+
+	// Log final statistics
+	htmlParser.LogStats()
+	agg.LogFinalStats()
 
 	topN := config.GetTopWordsCount()
-	if err := outputio.OutputResult(agg, topN); err != nil {
-		log.Fatalf("Output error: %v", err)
+	result := outputio.NewResult(agg, topN)
+	result.Run, err = buildRunManifest(cfg, startedAt, fetch, wordBank, htmlParser, workerCfg, stats, topN)
+	if err != nil {
+		fatalf("Building run manifest: %v", err)
 	}
 
-	if cfg.Verbose {
-		fmt.Println("✅ Analysis complete!")
+	if cfg.Export != "" {
+		export := &outputio.Export{Aggregator: agg.Snapshot(), Run: result.Run}
+		if err := outputio.WriteExport(cfg.Export, export); err != nil {
+			fatalf("Export error: %v", err)
+		}
 	}
+
+	if cfg.ConcordanceFile != "" {
+		if err := outputio.WriteConcordance(cfg.ConcordanceFile, result.Concordance); err != nil {
+			fatalf("Concordance error: %v", err)
+		}
+	}
+
+	if err := writer.Write(result); err != nil {
+		fatalf("Output error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		log.Fatalf("Output error: %v", err)
+	}
+
+	logger.Info("analysis complete")
 }
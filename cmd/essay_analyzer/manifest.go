@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/parser"
+	"github.com/firefly/essay-analyzer/internal/wordbank"
+)
+
+// version is the tool version, overridden at build time with
+// -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// buildRunManifest describes how the current run was configured and what happened to its URLs
+func buildRunManifest(
+	cfg *config.Config,
+	startedAt time.Time,
+	fetch *fetcher.Fetcher,
+	wordBank *wordbank.WordBank,
+	htmlParser *parser.Parser,
+	workerCfg WorkerConfig,
+	stats *PipelineStats,
+	topN int,
+) (*outputio.RunManifest, error) {
+	urlsDigest, err := outputio.DigestFile(cfg.URLsFile)
+	if err != nil {
+		return nil, fmt.Errorf("hashing URLs file: %w", err)
+	}
+
+	workers := outputio.WorkerDistribution{
+		Fetchers:   workerCfg.Fetchers,
+		Parsers:    workerCfg.Parsers,
+		Processors: workerCfg.Processors,
+		AutoTuned:  workerCfg.Auto,
+	}
+	if workerCfg.Auto {
+		final := stats.Workers()
+		workers.Final = &outputio.WorkerDistribution{
+			Fetchers:   final.Fetchers,
+			Parsers:    final.Parsers,
+			Processors: final.Processors,
+		}
+	}
+
+	return &outputio.RunManifest{
+		ToolVersion:        version,
+		StartedAt:          startedAt,
+		FinishedAt:         time.Now(),
+		URLsFile:           urlsDigest,
+		WordBank:           outputio.FileDigest{Path: wordBank.Source(), SHA256: wordBank.SHA256()},
+		Config:             cfg,
+		WorkerDistribution: workers,
+		EffectiveRateLimit: fetch.EffectiveRateLimit(),
+		TopWords:           topN,
+		Robots:             fetch.RobotsPolicies(),
+		Extractor:          htmlParser.Profile(),
+		URLs:               stats.Counts(),
+	}, nil
+}
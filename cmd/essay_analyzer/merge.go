@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+)
+
+// runMerge combines the exports of sharded runs into one result
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	format := flags.String("format", outputio.FormatJSON, "Output format: json, ndjson, csv, tsv, markdown or sqlite")
+	output := flags.String("output", "", "Output file (default stdout; required for sqlite)")
+	exportPath := flags.String("export", "", "Also write the merged export to this file")
+	concordancePath := flags.String("concordance-file", "", "Write the merged concordance to this file as JSON Lines")
+	cfg := &config.Config{}
+	config.RegisterLogFlags(flags, cfg)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: essay_analyzer merge [options] <export> <export>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one export file")
+	}
+
+	logger, err := cfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	writer, err := outputio.NewWriter(*format, *output)
+	if err != nil {
+		return err
+	}
+
+	agg := aggregator.New(nil)
+	if *format == outputio.FormatSQLite {
+		// Exports of SQLite runs carry per-article counts for its article tables
+		agg.RetainArticles()
+	}
+	run := &outputio.RunManifest{ToolVersion: version}
+	var deduped []string
+
+	for _, path := range flags.Args() {
+		export, err := outputio.ReadExport(path)
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		if err := agg.Merge(export.Aggregator); err != nil {
+			writer.Close()
+			return fmt.Errorf("merging %s: %w", path, err)
+		}
+
+		digest, err := outputio.DigestFile(path)
+		if err != nil {
+			writer.Close()
+			return err
+		}
+		run.MergedFrom = append(run.MergedFrom, digest)
+
+		if shard := export.Run; shard != nil {
+			mergeRunManifest(run, shard)
+			if shardDeduped(shard) {
+				deduped = append(deduped, path)
+			}
+		}
+	}
+
+	if len(deduped) > 0 {
+		// Each shard only knew its own URLs and fingerprints, so an article
+		// fetched by two shards is counted once per shard
+		logger.Warn("shards deduplicated articles on their own, so duplicates across shards are counted more than once", "exports", deduped)
+	}
+
+	topN := config.GetTopWordsCount()
+	run.TopWords = topN
+	if run.FinishedAt.IsZero() {
+		run.FinishedAt = time.Now()
+	}
+
+	if *exportPath != "" {
+		if err := outputio.WriteExport(*exportPath, &outputio.Export{Aggregator: agg.Snapshot(), Run: run}); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	result := outputio.NewResult(agg, topN)
+	result.Run = run
+
+	if *concordancePath != "" {
+		if err := outputio.WriteConcordance(*concordancePath, result.Concordance); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	if err := writer.Write(result); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// shardDeduped reports whether a shard dropped duplicate or near-duplicate
+// articles, which merge cannot repeat across shards
+func shardDeduped(shard *outputio.RunManifest) bool {
+	return shard.Config != nil && (shard.Config.Dedup || shard.Config.NearDupThreshold >= 0)
+}
+
+// mergeRunManifest folds a shard's manifest into the merged one: URL counts
+// add up and the run spans the earliest start to the latest finish
+func mergeRunManifest(run, shard *outputio.RunManifest) {
+	run.URLs.Queued += shard.URLs.Queued
+	run.URLs.Succeeded += shard.URLs.Succeeded
+	run.URLs.Failed += shard.URLs.Failed
+	run.URLs.Skipped += shard.URLs.Skipped
+	run.URLs.Duplicates += shard.URLs.Duplicates
+	run.URLs.NearDuplicates += shard.URLs.NearDuplicates
+	run.URLs.CanonicalDuplicates += shard.URLs.CanonicalDuplicates
+	run.URLs.LanguageSkipped += shard.URLs.LanguageSkipped
+
+	if run.StartedAt.IsZero() || (!shard.StartedAt.IsZero() && shard.StartedAt.Before(run.StartedAt)) {
+		run.StartedAt = shard.StartedAt
+	}
+	if shard.FinishedAt.After(run.FinishedAt) {
+		run.FinishedAt = shard.FinishedAt
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/firefly/essay-analyzer/internal/metrics"
+)
+
+// pipelineMetrics reports how full each pipeline channel is, which shows the bottleneck stage
+type pipelineMetrics struct {
+	queueDepth    *metrics.GaugeVec
+	queueCapacity *metrics.GaugeVec
+	workers       *metrics.GaugeVec
+}
+
+// newPipelineMetrics registers the pipeline's metrics with reg (nil for none)
+func newPipelineMetrics(reg *metrics.Registry) *pipelineMetrics {
+	return &pipelineMetrics{
+		queueDepth: reg.NewGaugeVec("essay_pipeline_queue_depth",
+			"Items waiting in a pipeline channel.", "channel"),
+		queueCapacity: reg.NewGaugeVec("essay_pipeline_queue_capacity",
+			"Buffer size of a pipeline channel.", "channel"),
+		workers: reg.NewGaugeVec("essay_pipeline_workers",
+			"Workers running in a pipeline stage.", "stage"),
+	}
+}
+
+// watch reports the depth of a channel until unwatch is called
+func (m *pipelineMetrics) watch(channel string, depth func() int, capacity int) {
+	if m == nil {
+		return
+	}
+	m.queueDepth.SetFunc(func() float64 { return float64(depth()) }, channel)
+	m.queueCapacity.Set(float64(capacity), channel)
+}
+
+// unwatch stops reporting a channel once its pipeline has finished
+func (m *pipelineMetrics) unwatch(channel string) {
+	if m == nil {
+		return
+	}
+	m.queueDepth.Delete(channel)
+	m.queueCapacity.Delete(channel)
+}
+
+// watchWorkers reports the worker count of a stage; the last pipeline's count stays visible
+func (m *pipelineMetrics) watchWorkers(stage string, size func() int) {
+	if m == nil {
+		return
+	}
+	m.workers.SetFunc(func() float64 { return float64(size()) }, stage)
+}
+
+// serveMetrics exposes reg at /metrics on addr. It returns once the listener is
+// open so a bad address fails before any work starts.
+func serveMetrics(addr string, reg *metrics.Registry, logger *slog.Logger) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	logger.Info("serving metrics", "url", fmt.Sprintf("http://%s/metrics", listener.Addr()))
+
+	return server, nil
+}
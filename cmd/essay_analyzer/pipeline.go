@@ -2,42 +2,287 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"sync"
+	"sync/atomic"
 
 	"github.com/firefly/essay-analyzer/internal/aggregator"
 	"github.com/firefly/essay-analyzer/internal/config"
 	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
 	"github.com/firefly/essay-analyzer/internal/parser"
 	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/tracing"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
+	"github.com/firefly/essay-analyzer/internal/wordbank"
+	"github.com/firefly/essay-analyzer/internal/workerpool"
+	"golang.org/x/time/rate"
 )
 
+// PipelineStats counts what happened to the URLs moving through the pipeline
+type PipelineStats struct {
+	Queued    atomic.Int64 // URLs read from the input
+	Succeeded atomic.Int64 // URLs whose word counts reached the aggregator
+	Failed    atomic.Int64 // URLs that failed to fetch or parse
+	Skipped   atomic.Int64 // URLs disallowed by robots.txt
+
+	Duplicates     atomic.Int64 // URLs dropped before queueing as duplicates of one already queued
+	NearDuplicates atomic.Int64 // URLs fetched but not counted because their text nearly matched an earlier article
+
+	CanonicalDuplicates atomic.Int64 // URLs fetched but not counted because they resolved to an article already seen
+
+	LanguageSkipped atomic.Int64 // URLs fetched but not counted because of their language
+
+	queues  atomic.Pointer[func() QueueDepths]  // Set while a pipeline is running
+	workers atomic.Pointer[func() WorkerConfig] // Set once a pipeline has started
+}
+
+// Queues returns the current channel depths of the running pipeline (zero when idle)
+func (s *PipelineStats) Queues() QueueDepths {
+	if depths := s.queues.Load(); depths != nil {
+		return (*depths)()
+	}
+	return QueueDepths{}
+}
+
+// Workers returns the number of workers in each stage of the running or most
+// recent pipeline (zero before one has started)
+func (s *PipelineStats) Workers() WorkerConfig {
+	if sizes := s.workers.Load(); sizes != nil {
+		return (*sizes)()
+	}
+	return WorkerConfig{}
+}
+
+// QueueDepths is the number of items waiting in each pipeline channel
+type QueueDepths struct {
+	URL     int
+	HTML    int
+	Text    int
+	Results int
+}
+
+// Counts returns a point-in-time copy of the counters
+func (s *PipelineStats) Counts() outputio.URLCounts {
+	return outputio.URLCounts{
+		Queued:    s.Queued.Load(),
+		Succeeded: s.Succeeded.Load(),
+		Failed:    s.Failed.Load(),
+		Skipped:   s.Skipped.Load(),
+
+		Duplicates:     s.Duplicates.Load(),
+		NearDuplicates: s.NearDuplicates.Load(),
+
+		CanonicalDuplicates: s.CanonicalDuplicates.Load(),
+
+		LanguageSkipped: s.LanguageSkipped.Load(),
+	}
+}
+
+// Pipeline stages, used to tag log records and the stage at which a URL dropped out
+const (
+	StageRobots    = "robots"
+	StageFetch     = "fetch"
+	StageParse     = "parse"
+	StageProcess   = "process"
+	StageAggregate = "aggregate"
+)
+
+// errDisallowed is the cause recorded for URLs that robots.txt disallows
+var errDisallowed = errors.New("disallowed by robots.txt")
+
+// URLError records why a URL dropped out of the pipeline and at which stage
+type URLError struct {
+	URL   string
+	Stage string
+	Err   error
+}
+
+func (e *URLError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Stage, e.URL, e.Err)
+}
+
+func (e *URLError) Unwrap() error {
+	return e.Err
+}
+
+// URLSource feeds URLs into the pipeline by calling enqueue for each one.
+// enqueue blocks while the pipeline is full and fails once it is stopping.
+type URLSource func(ctx context.Context, enqueue func(url string) error) error
+
+// fileURLSource reads URLs from a file, one per line
+func fileURLSource(filename string, logger *slog.Logger) URLSource {
+	return func(ctx context.Context, enqueue func(url string) error) error {
+		return readURLs(ctx, filename, enqueue, logger)
+	}
+}
+
+// dedupURLs normalizes the URLs from source and drops any already seen, counting them
+// in stats. Malformed URLs pass through unchanged so the fetcher reports them.
+func dedupURLs(source URLSource, normalizer *urlnorm.Normalizer, seen *urlnorm.Set, stats *PipelineStats, logger *slog.Logger) URLSource {
+	return func(ctx context.Context, enqueue func(url string) error) error {
+		return source(ctx, func(url string) error {
+			normalized, err := normalizer.Normalize(url)
+			if err != nil {
+				return enqueue(url)
+			}
+			if !seen.Add(urlnorm.Key(normalized)) {
+				stats.Duplicates.Add(1)
+				logger.Debug("dropped duplicate URL", "url", url, "normalized", normalized)
+				return nil
+			}
+			return enqueue(normalized)
+		})
+	}
+}
+
+// rateLimitURLs passes on the URLs from source at most requestsPerSecond at a time
+func rateLimitURLs(source URLSource, requestsPerSecond float64) URLSource {
+	return func(ctx context.Context, enqueue func(url string) error) error {
+		limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+		return source(ctx, func(url string) error {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			return enqueue(url)
+		})
+	}
+}
+
+// dedupURLList normalizes urls and drops any already seen, returning the rest and the number dropped
+func dedupURLList(urls []string, normalizer *urlnorm.Normalizer) ([]string, int64) {
+	seen := urlnorm.NewSet()
+	unique := make([]string, 0, len(urls))
+	var duplicates int64
+	for _, url := range urls {
+		normalized, err := normalizer.Normalize(url)
+		if err != nil {
+			unique = append(unique, url)
+			continue
+		}
+		if !seen.Add(urlnorm.Key(normalized)) {
+			duplicates++
+			continue
+		}
+		unique = append(unique, normalized)
+	}
+	return unique, duplicates
+}
+
+// articleDedup identifies fetched pages by their canonical link, or by their final URL
+// after redirects when they declare none, so input URLs resolving to one article count once
+type articleDedup struct {
+	normalizer *urlnorm.Normalizer
+	seen       *urlnorm.Set
+}
+
+// newArticleDedup creates an articleDedup that normalizes URLs with normalizer
+func newArticleDedup(normalizer *urlnorm.Normalizer) *articleDedup {
+	return &articleDedup{normalizer: normalizer, seen: urlnorm.NewSet()}
+}
+
+// add records the article served from finalURL with the given canonical link ("" for none)
+// and reports the URL that identifies it and whether it is new. A nil articleDedup reports
+// every article as new.
+func (d *articleDedup) add(finalURL, canonical string) (string, bool) {
+	if d == nil {
+		return finalURL, true
+	}
+
+	id, err := d.normalizer.Normalize(canonical)
+	if err != nil || isFrontPage(id) {
+		// Templates that point every page's canonical link at the front page would merge them all
+		id, err = d.normalizer.Normalize(finalURL)
+		if err != nil {
+			return finalURL, true
+		}
+	}
+	return id, d.seen.Add(urlnorm.Key(id))
+}
+
+// isFrontPage reports whether a normalized URL is a site's front page
+func isFrontPage(normalized string) bool {
+	u, err := url.Parse(normalized)
+	return err == nil && u.Path == "/" && u.RawQuery == ""
+}
+
+// sliceURLSource feeds a fixed list of URLs, such as a batch leased from a coordinator
+func sliceURLSource(urls []string) URLSource {
+	return func(ctx context.Context, enqueue func(url string) error) error {
+		for _, url := range urls {
+			if err := enqueue(url); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // runPipeline orchestrates the concurrent processing pipeline
 func runPipeline(
 	ctx context.Context,
-	cfg *config.Config,
+	logger *slog.Logger,
+	source URLSource,
 	fetch *fetcher.Fetcher,
 	htmlParser *parser.Parser,
 	textProcessor *processor.Processor,
+	reloader *wordBankReloader,
 	agg *aggregator.Aggregator,
+	articles *articleDedup,
+	nearDups *simhash.Index,
 	workerCfg WorkerConfig,
+	stats *PipelineStats,
+	pm *pipelineMetrics,
+	tracer *tracing.Tracer,
 ) error {
 	// Create channels with appropriate buffer sizes
 	urlCh := make(chan URLJob, 100)
 	htmlCh := make(chan HTMLResult, 50)
 	textCh := make(chan TextResult, 50)
-	resultsCh := make(chan aggregator.ProcessingResult, 100)
+	resultsCh := make(chan CountResult, 100)
 	errorCh := make(chan error, 100)
 
+	// Channel depths show which stage is the bottleneck
+	depths := func() QueueDepths {
+		return QueueDepths{URL: len(urlCh), HTML: len(htmlCh), Text: len(textCh), Results: len(resultsCh)}
+	}
+	stats.queues.Store(&depths)
+	defer stats.queues.Store(nil)
+	pm.watch("url", func() int { return len(urlCh) }, cap(urlCh))
+	pm.watch("html", func() int { return len(htmlCh) }, cap(htmlCh))
+	pm.watch("text", func() int { return len(textCh) }, cap(textCh))
+	pm.watch("results", func() int { return len(resultsCh) }, cap(resultsCh))
+	defer func() {
+		for _, channel := range []string{"url", "html", "text", "results"} {
+			pm.unwatch(channel)
+		}
+	}()
+
 	// Wait group for coordinating shutdown
 	var wg sync.WaitGroup
 
+	// Each URL's trace starts when it is queued, so time spent waiting for a fetcher shows
+	enqueue := func(url string) error {
+		_, span := tracer.Start(ctx, "url", tracing.String("url.full", url))
+		select {
+		case urlCh <- URLJob{URL: url, Span: span}:
+			stats.Queued.Add(1)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
 	// Start URL reader
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		defer close(urlCh)
-		if err := readURLs(ctx, cfg.URLsFile, urlCh, cfg.Verbose); err != nil {
+		if err := source(ctx, enqueue); err != nil {
 			select {
 			case errorCh <- fmt.Errorf("reading URLs: %w", err):
 			case <-ctx.Done():
@@ -45,65 +290,77 @@ func runPipeline(
 		}
 	}()
 
-	// Create separate wait groups for each stage to enable cascading channel closes
-	fetcherWg := &sync.WaitGroup{}
-	parserWg := &sync.WaitGroup{}
-	processorWg := &sync.WaitGroup{}
-
-	// Start fetcher workers
-	for i := 0; i < workerCfg.Fetchers; i++ {
-		wg.Add(1)
-		fetcherWg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			defer fetcherWg.Done()
-			fetcherWorker(ctx, id, fetch, urlCh, htmlCh, errorCh, cfg.Verbose)
-		}(i)
-	}
-
-	// Start parser workers
-	for i := 0; i < workerCfg.Parsers; i++ {
-		wg.Add(1)
-		parserWg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			defer parserWg.Done()
-			parserWorker(ctx, id, htmlParser, htmlCh, textCh, errorCh, cfg.Verbose)
-		}(i)
-	}
-
-	// Start processor workers
-	for i := 0; i < workerCfg.Processors; i++ {
-		wg.Add(1)
-		processorWg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			defer processorWg.Done()
-			processorWorker(ctx, id, textProcessor, textCh, resultsCh, errorCh, cfg.Verbose)
-		}(i)
+	// Each stage runs in a resizable pool so the tuner can move workers between them
+	fetchers := workerpool.New(urlCh, func(id int) func(context.Context, URLJob) {
+		return fetcherWorker(fetch, htmlCh, errorCh, stats, logger.With("stage", StageFetch, "worker", id))
+	})
+	parsers := workerpool.New(htmlCh, func(id int) func(context.Context, HTMLResult) {
+		return parserWorker(htmlParser, articles, textCh, stats, logger.With("stage", StageParse, "worker", id))
+	})
+	processors := workerpool.New(textCh, func(id int) func(context.Context, TextResult) {
+		return processorWorker(textProcessor, reloader, nearDups != nil, resultsCh, errorCh, stats, logger.With("stage", StageProcess, "worker", id))
+	})
+	fetchers.Start(ctx, workerCfg.Fetchers)
+	parsers.Start(ctx, workerCfg.Parsers)
+	processors.Start(ctx, workerCfg.Processors)
+
+	// Pool sizes stay readable after the run so the final distribution can be reported
+	sizes := func() WorkerConfig {
+		return WorkerConfig{
+			Fetchers:   fetchers.Size(),
+			Parsers:    parsers.Size(),
+			Processors: processors.Size(),
+			Auto:       workerCfg.Auto,
+		}
 	}
+	stats.workers.Store(&sizes)
+	pm.watchWorkers(StageFetch, fetchers.Size)
+	pm.watchWorkers(StageParse, parsers.Size)
+	pm.watchWorkers(StageProcess, processors.Size)
 
 	// Close channels in cascade as each stage completes
+	wg.Add(3)
 	go func() {
-		fetcherWg.Wait()
+		defer wg.Done()
+		fetchers.Wait()
 		close(htmlCh)
 	}()
 
 	go func() {
-		parserWg.Wait()
+		defer wg.Done()
+		parsers.Wait()
+		// Close bodies left unread when the pipeline is canceled, freeing their connections
+		for result := range htmlCh {
+			if result.Content != nil {
+				result.Content.Close()
+			}
+		}
 		close(textCh)
 	}()
 
 	go func() {
-		processorWg.Wait()
+		defer wg.Done()
+		processors.Wait()
 		close(resultsCh)
 	}()
 
+	// Move workers towards the bottleneck stage while the pipeline runs
+	if workerCfg.Auto {
+		tunerCtx, stopTuner := context.WithCancel(ctx)
+		defer stopTuner()
+		tuner := workerpool.NewTuner(logger,
+			workerpool.Stage{Name: StageFetch, Pool: fetchers, Input: occupancy(urlCh), Output: occupancy(htmlCh)},
+			workerpool.Stage{Name: StageParse, Pool: parsers, Input: occupancy(htmlCh), Output: occupancy(textCh)},
+			workerpool.Stage{Name: StageProcess, Pool: processors, Input: occupancy(textCh), Output: occupancy(resultsCh)},
+		)
+		go tuner.Run(tunerCtx)
+	}
+
 	// Start aggregator
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		aggregatorWorker(ctx, agg, resultsCh, cfg.Verbose)
+		aggregatorWorker(ctx, agg, nearDups, reloader, resultsCh, stats, logger.With("stage", StageAggregate))
 	}()
 
 	// Start error collector
@@ -114,8 +371,11 @@ func runPipeline(
 		defer errorWg.Done()
 		for err := range errorCh {
 			errorCount++
-			if cfg.Verbose {
-				fmt.Printf("❌ Error #%d: %v\n", errorCount, err)
+			var urlErr *URLError
+			if errors.As(err, &urlErr) {
+				logger.Info("URL failed", "url", urlErr.URL, "stage", urlErr.Stage, "error", urlErr.Err)
+			} else {
+				logger.Error("pipeline error", "error", err)
 			}
 		}
 	}()
@@ -127,13 +387,101 @@ func runPipeline(
 	close(errorCh)
 	errorWg.Wait()
 
-	if cfg.Verbose && errorCount > 0 {
-		fmt.Printf("⚠️  Total errors encountered: %d\n", errorCount)
+	if errorCount > 0 {
+		logger.Info("pipeline finished with errors", "errors", errorCount)
 	}
 
 	return nil
 }
 
+// fetcherOptions returns the HTTP client settings given on the command line
+func fetcherOptions(cfg *config.Config) fetcher.Options {
+	return fetcher.Options{
+		Timeout:               cfg.RequestTimeout,
+		DialTimeout:           cfg.DialTimeout,
+		TLSHandshakeTimeout:   cfg.TLSTimeout,
+		ResponseHeaderTimeout: cfg.HeaderTimeout,
+		IdleConnTimeout:       cfg.IdleTimeout,
+		MaxBodyBytes:          cfg.MaxBodyBytes,
+		MaxRedirects:          cfg.MaxRedirects,
+	}
+}
+
+// loadWordBank loads the wordbank described by cfg, fetching a remote one with fetch.
+// Without a wordbank file every word passing the filter that is not a stopword counts.
+func loadWordBank(ctx context.Context, cfg *config.Config, fetch *fetcher.Fetcher, logger *slog.Logger) (*wordbank.WordBank, error) {
+	filter, err := cfg.WordFilter()
+	if err != nil {
+		return nil, err
+	}
+	stop, err := cfg.LoadStopwords()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := wordbank.Options{
+		Format:    cfg.WordBankFormat,
+		Backend:   cfg.WordBankBackend,
+		Fetcher:   fetch,
+		MaxBytes:  cfg.WordBankMaxBytes,
+		CacheDir:  cfg.WordBankCache,
+		CacheTTL:  cfg.WordBankCacheTTL,
+		Logger:    logger,
+		Filter:    filter,
+		Stopwords: stop,
+
+		FuzzyDistance: cfg.FuzzyDistance,
+	}
+	if cfg.WordBankFile == "" {
+		logger.Info("no wordbank given, counting every word", "stopwords", stop.Size())
+		return wordbank.All(opts), nil
+	}
+
+	wordBank, err := wordbank.NewWithOptions(ctx, cfg.WordBankFile, opts)
+	if err != nil {
+		return nil, fmt.Errorf("loading wordbank: %w", err)
+	}
+	logger.Info("loaded wordbank", "words", wordBank.Size(), "stopwords", stop.Size())
+	if removed := wordBank.StopwordsRemoved(); removed > 0 {
+		logger.Warn("left out wordbank entries that are stopwords", "entries", removed, "stopwords", cfg.StopwordList(), "stopwords_file", cfg.StopwordsFile)
+	}
+	if filtered := wordBank.FilteredOut(); filtered > 0 {
+		logger.Warn("left out wordbank entries that don't match the word filter", "entries", filtered, "word_pattern", cfg.WordPattern, "min_word_length", cfg.MinWordLength)
+	}
+	return wordBank, nil
+}
+
+// newAggregator returns an aggregator keeping what cfg's analyses need
+func newAggregator(cfg *config.Config, logger *slog.Logger) *aggregator.Aggregator {
+	agg := aggregator.New(logger)
+	if cfg.Concordance > 0 {
+		agg.KeepContexts(cfg.Concordance, cfg.ConcordanceWordList(), config.GetTopWordsCount())
+	}
+	return agg
+}
+
+// occupancy reports how full a channel is, for the tuner
+func occupancy[T any](ch chan T) func() (int, int) {
+	return func() (int, int) { return len(ch), cap(ch) }
+}
+
+// configureWorkers returns the starting worker distribution: the 60/20/20 split of
+// --workers, with any stage given explicitly by --fetchers, --parsers or --processors
+func configureWorkers(cfg *config.Config) WorkerConfig {
+	workerCfg := calculateWorkerDistribution(cfg.Workers)
+	if cfg.Fetchers > 0 {
+		workerCfg.Fetchers = cfg.Fetchers
+	}
+	if cfg.Parsers > 0 {
+		workerCfg.Parsers = cfg.Parsers
+	}
+	if cfg.Processors > 0 {
+		workerCfg.Processors = cfg.Processors
+	}
+	workerCfg.Auto = cfg.AutoTune
+	return workerCfg
+}
+
 // calculateWorkerDistribution distributes workers across pipeline stages
 func calculateWorkerDistribution(totalWorkers int) WorkerConfig {
 	// Distribution strategy:
@@ -151,11 +499,3 @@ func calculateWorkerDistribution(totalWorkers int) WorkerConfig {
 		Processors: processors,
 	}
 }
-
-// max returns the maximum of two integers
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
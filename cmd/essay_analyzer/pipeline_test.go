@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRateLimitURLs(t *testing.T) {
+	urls := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"}
+	source := rateLimitURLs(sliceURLSource(urls), 20)
+
+	var got []string
+	start := time.Now()
+	err := source(context.Background(), func(url string) error {
+		got = append(got, url)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the source to finish, got %v", err)
+	}
+	if !reflect.DeepEqual(got, urls) {
+		t.Errorf("Expected %v, got %v", urls, got)
+	}
+	// The first URL passes at once and each later one waits 50ms
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected 3 URLs at 20/s to take at least 100ms, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := source(ctx, func(string) error { return nil }); err == nil {
+		t.Error("Expected a canceled source to fail")
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// progressRefresh is how often the progress line is redrawn on a terminal
+	progressRefresh = 250 * time.Millisecond
+
+	// progressLogInterval is how often a progress line is logged when not on a terminal
+	progressLogInterval = 10 * time.Second
+
+	// throughputWindow is the period over which current throughput is measured
+	throughputWindow = 10 * time.Second
+)
+
+// progressSample records how many URLs had finished at a point in time
+type progressSample struct {
+	at   time.Time
+	done int64
+}
+
+// progressReporter shows live pipeline progress on stderr. On a terminal it
+// redraws a single line in place; otherwise it writes a line periodically so
+// logs stay readable. Stdout is never touched, so results written there stay intact.
+type progressReporter struct {
+	out      io.Writer
+	tty      bool
+	interval time.Duration
+	total    int64
+	stats    *PipelineStats
+	start    time.Time
+
+	samples []progressSample // Recent samples for current throughput, oldest first
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// startProgress begins reporting progress for a run of total URLs
+func startProgress(total int64, stats *PipelineStats) *progressReporter {
+	p := &progressReporter{
+		out:      os.Stderr,
+		tty:      isTerminal(os.Stderr),
+		interval: progressLogInterval,
+		total:    total,
+		stats:    stats,
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+	}
+	if p.tty {
+		p.interval = progressRefresh
+	}
+
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// Stop prints the final progress and stops reporting
+func (p *progressReporter) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+func (p *progressReporter) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.render(time.Now(), false)
+		case <-p.stop:
+			p.render(time.Now(), true)
+			return
+		}
+	}
+}
+
+// render writes one progress update
+func (p *progressReporter) render(now time.Time, final bool) {
+	line := p.line(now)
+	if p.tty {
+		// Return to the start of the line and clear it before redrawing
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+		if final {
+			fmt.Fprintln(p.out)
+		}
+		return
+	}
+	fmt.Fprintf(p.out, "%s progress: %s\n", now.Format("2006/01/02 15:04:05"), line)
+}
+
+// line formats the current progress
+func (p *progressReporter) line(now time.Time) string {
+	counts := p.stats.Counts()
+	queues := p.stats.Queues()
+	// The total counts every line, so duplicates dropped before queueing are done too
+	finished := counts.Succeeded + counts.Failed + counts.Skipped + counts.NearDuplicates + counts.CanonicalDuplicates + counts.LanguageSkipped
+	done := finished + counts.Duplicates
+	inFlight := max(counts.Queued-finished, 0)
+
+	throughput := p.throughput(now, done)
+
+	var b strings.Builder
+	if p.total > 0 {
+		fmt.Fprintf(&b, "%d/%d URLs (%.1f%%)", done, p.total, float64(done)/float64(p.total)*100)
+	} else {
+		fmt.Fprintf(&b, "%d URLs", done)
+	}
+	fmt.Fprintf(&b, " | %d ok, %d failed, %d skipped, %d duplicates, %d near duplicates, %d in flight",
+		counts.Succeeded, counts.Failed, counts.Skipped+counts.LanguageSkipped, counts.Duplicates+counts.CanonicalDuplicates, counts.NearDuplicates, inFlight)
+	fmt.Fprintf(&b, " | %.1f URLs/s", throughput)
+	if eta, ok := p.eta(done, throughput); ok {
+		fmt.Fprintf(&b, " | ETA %s", eta)
+	}
+	fmt.Fprintf(&b, " | queues url %d, html %d, text %d, results %d", queues.URL, queues.HTML, queues.Text, queues.Results)
+	if workers := p.stats.Workers(); workers.Auto {
+		fmt.Fprintf(&b, " | workers %d/%d/%d", workers.Fetchers, workers.Parsers, workers.Processors)
+	}
+
+	return b.String()
+}
+
+// throughput returns the URLs finished per second over the recent window
+func (p *progressReporter) throughput(now time.Time, done int64) float64 {
+	p.samples = append(p.samples, progressSample{at: now, done: done})
+
+	// Keep one sample at or before the window start so the rate spans the full window
+	for len(p.samples) > 2 && now.Sub(p.samples[1].at) >= throughputWindow {
+		p.samples = p.samples[1:]
+	}
+
+	oldest := progressSample{at: p.start}
+	if len(p.samples) > 1 {
+		oldest = p.samples[0]
+	}
+
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(done-oldest.done) / elapsed
+}
+
+// eta estimates the time left at the current throughput
+func (p *progressReporter) eta(done int64, throughput float64) (time.Duration, bool) {
+	if p.total <= 0 || throughput <= 0 || done >= p.total {
+		return 0, false
+	}
+	remaining := float64(p.total-done) / throughput
+	return time.Duration(remaining * float64(time.Second)).Round(time.Second), true
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
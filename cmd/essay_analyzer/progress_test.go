@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// testProgress returns a reporter for total URLs writing to out, started at start
+func testProgress(out *bytes.Buffer, tty bool, total int64, start time.Time) (*progressReporter, *PipelineStats) {
+	stats := &PipelineStats{}
+	return &progressReporter{out: out, tty: tty, total: total, stats: stats, start: start}, stats
+}
+
+func TestProgressReporter_Line(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	var out bytes.Buffer
+	p, stats := testProgress(&out, false, 100, start)
+	stats.Queued.Store(30)
+	stats.Succeeded.Store(18)
+	stats.Failed.Store(1)
+	stats.Skipped.Store(1)
+	stats.Duplicates.Store(5)
+
+	// 25 URLs done in the first 10 seconds leaves 75 at 2.5 a second
+	p.render(start.Add(10*time.Second), false)
+	want := "2026/01/02 15:04:10 progress: 25/100 URLs (25.0%) | 18 ok, 1 failed, 1 skipped, 5 duplicates, 0 near duplicates, 10 in flight | 2.5 URLs/s | ETA 30s | queues url 0, html 0, text 0, results 0\n"
+	if got := out.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestProgressReporter_Terminal(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	var out bytes.Buffer
+	p, _ := testProgress(&out, true, 0, start)
+
+	p.render(start.Add(time.Second), false)
+	p.render(start.Add(2*time.Second), true)
+	line := "0 URLs | 0 ok, 0 failed, 0 skipped, 0 duplicates, 0 near duplicates, 0 in flight | 0.0 URLs/s | queues url 0, html 0, text 0, results 0"
+	want := "\r\033[K" + line + "\r\033[K" + line + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestProgressReporter_Throughput(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	p, _ := testProgress(&bytes.Buffer{}, false, 0, start)
+
+	if got := p.throughput(start.Add(5*time.Second), 10); got != 2 {
+		t.Errorf("Expected 2 URLs/s since the start, got %v", got)
+	}
+	if got := p.throughput(start.Add(10*time.Second), 20); got != 2 {
+		t.Errorf("Expected 2 URLs/s over the first samples, got %v", got)
+	}
+	// Only the last 10 seconds count once the window has passed
+	if got := p.throughput(start.Add(20*time.Second), 30); got != 1 {
+		t.Errorf("Expected 1 URL/s over the window, got %v", got)
+	}
+	if len(p.samples) != 2 {
+		t.Errorf("Expected samples before the window to be dropped, got %d", len(p.samples))
+	}
+}
+
+func TestProgressReporter_ETA(t *testing.T) {
+	p, _ := testProgress(&bytes.Buffer{}, false, 10, time.Time{})
+
+	// 2.5 seconds left rounds to 3 rather than truncating to 2
+	if eta, ok := p.eta(9, 0.4); !ok || eta != 3*time.Second {
+		t.Errorf("Expected an ETA of 3s, got %v, %v", eta, ok)
+	}
+	if _, ok := p.eta(9, 0); ok {
+		t.Error("Expected no ETA without throughput")
+	}
+	if _, ok := p.eta(10, 1); ok {
+		t.Error("Expected no ETA once every URL is done")
+	}
+	p.total = 0
+	if _, ok := p.eta(5, 1); ok {
+		t.Error("Expected no ETA without a total")
+	}
+}
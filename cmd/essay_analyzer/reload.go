@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/wordbank"
+)
+
+// wordBankReloader swaps the processor's wordbank for a fresh copy when the process
+// receives SIGHUP or, when polling, whenever the wordbank file changes. Articles
+// already being counted finish with the wordbank they started with, and each result
+// names the version it was counted with.
+type wordBankReloader struct {
+	cfg       *config.Config
+	fetch     *fetcher.Fetcher
+	processor *processor.Processor
+	logger    *slog.Logger
+	language  string // Of the articles counted with the wordbank, "" unless detecting languages
+
+	mu      sync.Mutex
+	current *wordbank.WordBank
+	loaded  []*wordbank.WordBank // Every wordbank this reloader loaded, closed by Close
+	modTime time.Time            // Of the local wordbank file when last checked
+	size    int64
+
+	// Article texts by URL, kept for the recount when cfg.WordBankRecount is set
+	textsMu sync.Mutex
+	texts   map[string]string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newWordBankReloader returns a reloader for the wordbank the processor was created
+// with, or nil when cfg does not reload it. Without a wordbank file there is nothing
+// to reload.
+func newWordBankReloader(cfg *config.Config, wordBank *wordbank.WordBank, fetch *fetcher.Fetcher, textProcessor *processor.Processor, logger *slog.Logger) *wordBankReloader {
+	if !cfg.Reloads() {
+		return nil
+	}
+	if cfg.WordBankFile == "" {
+		logger.Warn("no wordbank file to reload")
+		return nil
+	}
+
+	r := &wordBankReloader{
+		cfg:       cfg,
+		fetch:     fetch,
+		processor: textProcessor,
+		logger:    logger,
+		current:   wordBank,
+	}
+	if languages := cfg.LanguageList(); len(languages) > 0 {
+		r.language = languages[0]
+	}
+	r.modTime, r.size = r.stat()
+	if cfg.WordBankRecount {
+		r.texts = make(map[string]string)
+	}
+	return r
+}
+
+// Start watches for changes until Stop is called
+func (r *wordBankReloader) Start(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.stop = make(chan struct{})
+
+	hup := make(chan os.Signal, 1)
+	if r.cfg.WordBankReload {
+		signal.Notify(hup, syscall.SIGHUP)
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer signal.Stop(hup)
+
+		// A nil channel never fires, so without polling only SIGHUP reloads
+		var poll <-chan time.Time
+		if r.cfg.WordBankPoll > 0 {
+			ticker := time.NewTicker(r.cfg.WordBankPoll)
+			defer ticker.Stop()
+			poll = ticker.C
+		}
+
+		for {
+			select {
+			case <-hup:
+				r.logger.Info("received SIGHUP, reloading wordbank")
+				r.reload(ctx)
+			case <-poll:
+				if r.changed() {
+					r.reload(ctx)
+				}
+			case <-r.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops watching for changes
+func (r *wordBankReloader) Stop() {
+	if r == nil || r.stop == nil {
+		return
+	}
+	close(r.stop)
+	r.wg.Wait()
+	r.stop = nil
+}
+
+// changed reports whether the wordbank may have changed since last checked. A
+// remote wordbank is always checked again; its cache decides when it is fetched.
+func (r *wordBankReloader) changed() bool {
+	if r.cfg.IsRemoteWordBank() {
+		return true
+	}
+
+	modTime, size := r.stat()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if modTime.Equal(r.modTime) && size == r.size {
+		return false
+	}
+	r.modTime, r.size = modTime, size
+	return true
+}
+
+// stat returns the modification time and size of a local wordbank file, zero
+// when it cannot be read
+func (r *wordBankReloader) stat() (time.Time, int64) {
+	if r.cfg.IsRemoteWordBank() {
+		return time.Time{}, 0
+	}
+	info, err := os.Stat(r.cfg.WordBankFile)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+// reload loads the wordbank again and gives it to the processor when its words
+// changed. A wordbank that fails to load is logged and the current one kept, so a
+// file caught halfway through being saved does no harm.
+func (r *wordBankReloader) reload(ctx context.Context) {
+	wordBank, err := loadWordBank(ctx, r.cfg, r.fetch, r.logger)
+	if err != nil {
+		r.logger.Warn("failed to reload wordbank, keeping the current one", "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wordBank.Version() == r.current.Version() {
+		wordBank.Close()
+		r.logger.Debug("wordbank unchanged", "version", wordBank.Version())
+		return
+	}
+
+	// The previous wordbank stays open, since articles may still be counted with it
+	r.logger.Info("reloaded wordbank", "previous", r.current.Version(), "version", wordBank.Version(), "words", wordBank.Size())
+	r.current = wordBank
+	r.loaded = append(r.loaded, wordBank)
+	r.processor.SetWordBank(wordBank)
+}
+
+// WordBank returns the current wordbank, for the run manifest, or loaded, the
+// wordbank the run started with, on a nil reloader
+func (r *wordBankReloader) WordBank(loaded *wordbank.WordBank) *wordbank.WordBank {
+	if r == nil {
+		return loaded
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// track makes agg keep what the reloader needs: the articles to recount, or else
+// the counts of each wordbank version. It does nothing on a nil reloader.
+func (r *wordBankReloader) track(agg *aggregator.Aggregator) {
+	if r == nil {
+		return
+	}
+	if r.cfg.WordBankRecount {
+		agg.RetainArticles()
+	} else {
+		agg.KeepVersions()
+	}
+}
+
+// keepsTexts reports whether article texts should reach retain, which they only
+// need to for a recount
+func (r *wordBankReloader) keepsTexts() bool {
+	return r != nil && r.texts != nil
+}
+
+// retain keeps the text of an article the aggregator counted, for the recount. It
+// does nothing on a nil reloader or without a recount.
+func (r *wordBankReloader) retain(url, text string) {
+	if !r.keepsTexts() {
+		return
+	}
+	r.textsMu.Lock()
+	defer r.textsMu.Unlock()
+	r.texts[url] = text
+}
+
+// recount counts the retained articles that were counted with an earlier wordbank
+// again with the current one, replacing their counts in agg, and returns how many
+// it recounted. The retained texts are released afterwards.
+func (r *wordBankReloader) recount(agg *aggregator.Aggregator) int {
+	if r == nil || r.texts == nil {
+		return 0
+	}
+	r.textsMu.Lock()
+	defer r.textsMu.Unlock()
+
+	version := r.processor.WordBankVersion()
+	recounted := 0
+	for _, article := range agg.GetArticles() {
+		if article.WordBank == version || article.Language != r.language {
+			continue
+		}
+		text, ok := r.texts[article.URL]
+		if !ok {
+			continue
+		}
+		delete(r.texts, article.URL) // Replace recounts every article with the URL at once
+		counts := r.processor.Process(text)
+		agg.Replace(processingResult(article.URL, counts))
+		recounted++
+	}
+	clear(r.texts)
+
+	if recounted > 0 {
+		r.logger.Info("recounted articles counted with an earlier wordbank", "articles", recounted, "version", version)
+	}
+	return recounted
+}
+
+// Close closes the wordbanks loaded by the reloader, once nothing counts with them
+func (r *wordBankReloader) Close() {
+	if r == nil {
+		return
+	}
+	r.Stop()
+	for _, wordBank := range r.loaded {
+		wordBank.Close()
+	}
+}
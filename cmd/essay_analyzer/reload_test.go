@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+)
+
+// writeWordBankFile writes words, one per line, to path
+func writeWordBankFile(t *testing.T, path string, words ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(words, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testReloader loads the wordbank at path and returns a processor counting with
+// it and a reloader polling it
+func testReloader(t *testing.T, path string, recount bool) (*processor.Processor, *wordBankReloader) {
+	t.Helper()
+	cfg := &config.Config{WordBankFile: path, WordBankPoll: time.Hour, WordBankRecount: recount}
+	logger := logging.Discard()
+
+	wordBank, err := loadWordBank(context.Background(), cfg, nil, logger)
+	if err != nil {
+		t.Fatalf("Expected the wordbank to load, got %v", err)
+	}
+	t.Cleanup(func() { wordBank.Close() })
+
+	textProcessor := processor.New(wordBank, logger)
+	reloader := newWordBankReloader(cfg, wordBank, nil, textProcessor, logger)
+	t.Cleanup(reloader.Close)
+	return textProcessor, reloader
+}
+
+// countArticles passes articles through the processor and aggregator workers
+func countArticles(textProcessor *processor.Processor, reloader *wordBankReloader, agg *aggregator.Aggregator, nearDups *simhash.Index, articles ...TextResult) *PipelineStats {
+	ctx := context.Background()
+	stats := &PipelineStats{}
+	resultsCh := make(chan CountResult, len(articles))
+	errorCh := make(chan error, len(articles))
+
+	process := processorWorker(textProcessor, reloader, nearDups != nil, resultsCh, errorCh, stats, logging.Discard())
+	for _, article := range articles {
+		process(ctx, article)
+	}
+	close(resultsCh)
+	aggregatorWorker(ctx, agg, nearDups, reloader, resultsCh, stats, logging.Discard())
+	return stats
+}
+
+// reloadWordBank rewrites the wordbank file and reloads it as polling would
+func reloadWordBank(t *testing.T, reloader *wordBankReloader, path string, words ...string) {
+	t.Helper()
+	writeWordBankFile(t, path, words...)
+	if !reloader.changed() {
+		t.Fatal("Expected the rewritten wordbank to be seen as changed")
+	}
+	reloader.reload(context.Background())
+	if reloader.changed() {
+		t.Error("Expected the wordbank to be unchanged once reloaded")
+	}
+}
+
+func TestWordBankReloader_Versions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	writeWordBankFile(t, path, "robot")
+	textProcessor, reloader := testReloader(t, path, false)
+	first := textProcessor.WordBankVersion()
+
+	agg := aggregator.New(nil)
+	reloader.track(agg)
+	if reloader.changed() {
+		t.Error("Expected an untouched wordbank to be unchanged")
+	}
+
+	countArticles(textProcessor, reloader, agg, nil, TextResult{URL: "https://example.com/1", Text: "A robot and a laptop and a robot"})
+	reloadWordBank(t, reloader, path, "robot", "laptop")
+	second := textProcessor.WordBankVersion()
+	if second == first || reloader.WordBank(nil).Version() != second {
+		t.Fatalf("Expected the processor and manifest to use the new wordbank, got %q and %q", second, reloader.WordBank(nil).Version())
+	}
+	countArticles(textProcessor, reloader, agg, nil, TextResult{URL: "https://example.com/2", Text: "A robot and a laptop"})
+
+	want := map[string]aggregator.VersionCount{
+		first:  {Version: first, Articles: 1, TotalWords: 2, TopWords: []aggregator.WordCount{{Word: "robot", Count: 2}}},
+		second: {Version: second, Articles: 1, TotalWords: 2, TopWords: []aggregator.WordCount{{Word: "laptop", Count: 1}, {Word: "robot", Count: 1}}},
+	}
+	versions := agg.GetVersions(10)
+	if len(versions) != 2 {
+		t.Fatalf("Expected counts for 2 versions, got %+v", versions)
+	}
+	for _, got := range versions {
+		if !reflect.DeepEqual(got, want[got.Version]) {
+			t.Errorf("Expected %+v, got %+v", want[got.Version], got)
+		}
+	}
+
+	// Without a recount no text is kept
+	if reloader.keepsTexts() || reloader.recount(agg) != 0 {
+		t.Error("Expected no texts kept or recounted without a recount")
+	}
+}
+
+func TestWordBankReloader_Recount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	writeWordBankFile(t, path, "robot")
+	textProcessor, reloader := testReloader(t, path, true)
+
+	agg := aggregator.New(nil)
+	reloader.track(agg)
+	text := "The robot sat next to the laptop while another robot watched the laptop screen"
+	stats := countArticles(textProcessor, reloader, agg, simhash.NewIndex(0),
+		TextResult{URL: "https://example.com/1", Text: text},
+		TextResult{URL: "https://example.com/copy", Text: text},
+	)
+	if stats.NearDuplicates.Load() != 1 {
+		t.Fatalf("Expected the copy to be a near duplicate, got %d", stats.NearDuplicates.Load())
+	}
+	if clusters := agg.GetNearDuplicates(); len(clusters) != 1 || clusters[0].URL != "https://example.com/1" {
+		t.Errorf("Expected the copy recorded against the first article, got %+v", clusters)
+	}
+
+	reloadWordBank(t, reloader, path, "robot", "laptop")
+	countArticles(textProcessor, reloader, agg, nil, TextResult{URL: "https://example.com/3", Text: "A robot bought a laptop"})
+
+	// Only articles the aggregator counted are kept, so the near duplicate is not
+	if _, ok := reloader.texts["https://example.com/copy"]; ok || len(reloader.texts) != 2 {
+		t.Errorf("Expected the texts of the 2 counted articles, got %d", len(reloader.texts))
+	}
+
+	if recounted := reloader.recount(agg); recounted != 1 {
+		t.Errorf("Expected the article counted with the first wordbank to be recounted, got %d", recounted)
+	}
+	want := []aggregator.WordCount{{Word: "laptop", Count: 3}, {Word: "robot", Count: 3}}
+	if got := agg.GetTopWords(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected top words %+v, got %+v", want, got)
+	}
+	for _, article := range agg.GetArticles() {
+		if article.WordBank != textProcessor.WordBankVersion() {
+			t.Errorf("Expected %s to be counted with the current wordbank, got %q", article.URL, article.WordBank)
+		}
+	}
+	if len(reloader.texts) != 0 {
+		t.Errorf("Expected the texts to be released after the recount, got %d", len(reloader.texts))
+	}
+}
+
+func TestWordBankReloader_Nil(t *testing.T) {
+	var reloader *wordBankReloader
+	if reloader.WordBank(nil) != nil || reloader.keepsTexts() {
+		t.Error("Expected a nil reloader to keep nothing")
+	}
+	reloader.retain("https://example.com/1", "text")
+	if reloader.recount(aggregator.New(nil)) != 0 {
+		t.Error("Expected a nil reloader to recount nothing")
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/jobs"
+	"github.com/firefly/essay-analyzer/internal/parser"
+	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
+)
+
+// serveOptions holds the server-wide defaults for submitted jobs
+type serveOptions struct {
+	wordBankFile string
+	wordBankDir  string
+	workers      int
+	rateLimit    float64
+
+	// Shared by every job, so together they keep to the rate limit and crawl-delay
+	fetch    *fetcher.Fetcher
+	fetchCfg *config.Config // HTTP client limits the fetcher was created with
+
+	// Wordbank reloading for running jobs, as set by the reload flags
+	wordBankReload  bool
+	wordBankPoll    time.Duration
+	wordBankRecount bool
+}
+
+// runServe runs analysis jobs submitted over an HTTP API
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	opts := &serveOptions{}
+	listen := flags.String("listen", ":8080", "Address to serve the job API on")
+	dataDir := flags.String("data-dir", "essay-jobs", "Directory for job metadata and results")
+	maxJobs := flags.Int("max-jobs", 2, "Number of jobs to run at once; later jobs wait in a queue")
+	flags.StringVar(&opts.wordBankFile, "wordbank-file", "", "Wordbank used by jobs that do not name one")
+	flags.StringVar(&opts.wordBankDir, "wordbank-dir", "", "Directory of wordbanks that jobs may select by file name")
+	flags.IntVar(&opts.workers, "workers", 50, "Default number of concurrent workers per job")
+	flags.Float64Var(&opts.rateLimit, "rate-limit", 0, "Requests per second across all jobs (0 = no limit unless robots.txt specifies)")
+	flagCfg := &config.Config{} // Settings shared with the CLI
+	config.RegisterLogFlags(flags, flagCfg)
+	config.RegisterReloadFlags(flags, flagCfg)
+	config.RegisterFetchFlags(flags, flagCfg)
+	flags.Parse(args)
+
+	if opts.wordBankFile == "" && opts.wordBankDir == "" {
+		return fmt.Errorf("--wordbank-file or --wordbank-dir is required")
+	}
+	if opts.workers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if err := flagCfg.ValidateReload(); err != nil {
+		return err
+	}
+	if err := flagCfg.ValidateFetch(); err != nil {
+		return err
+	}
+	opts.wordBankReload = flagCfg.WordBankReload
+	opts.wordBankPoll = flagCfg.WordBankPoll
+	opts.wordBankRecount = flagCfg.WordBankRecount
+
+	logger, err := flagCfg.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	opts.fetchCfg = flagCfg
+	opts.fetch = fetcher.NewWithOptions(opts.rateLimit, fetcherOptions(flagCfg), logger)
+	if err := opts.fetch.LoadRobotsTxt(context.Background(), "https://www.engadget.com"); err != nil {
+		logger.Warn("failed to load robots.txt", "error", err)
+	}
+
+	// Running jobs reload their wordbanks on SIGHUP. Listening for it here too keeps
+	// it from stopping the server while no job is running.
+	if opts.wordBankReload {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for range hup {
+				logger.Info("received SIGHUP, reloading the wordbanks of running jobs")
+			}
+		}()
+	}
+
+	manager, err := jobs.NewManager(*dataDir, *maxJobs, opts.runJob, logger)
+	if err != nil {
+		return err
+	}
+	manager.Validate = func(spec *jobs.Spec) error {
+		_, err := opts.resolveWordbank(spec.Wordbank)
+		return err
+	}
+
+	server := &http.Server{Addr: *listen, Handler: manager.Handler()}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	logger.Info("serving job API", "addr", *listen, "data_dir", *dataDir, "max_jobs", *maxJobs)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serving job API: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Warn("received interrupt signal, shutting down gracefully")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+	return manager.Shutdown(shutdownCtx)
+}
+
+// resolveWordbank maps a job's wordbank name to a file, falling back to the default
+func (o *serveOptions) resolveWordbank(name string) (string, error) {
+	if name == "" {
+		if o.wordBankFile == "" {
+			return "", fmt.Errorf("no wordbank given and the server has no default")
+		}
+		return o.wordBankFile, nil
+	}
+
+	if o.wordBankDir == "" {
+		return "", fmt.Errorf("the server does not offer named wordbanks")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid wordbank name %q", name)
+	}
+
+	path := filepath.Join(o.wordBankDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("unknown wordbank %q", name)
+	}
+	return path, nil
+}
+
+// runJob analyzes one submitted job through the same pipeline as the CLI
+func (o *serveOptions) runJob(ctx context.Context, urlsFile string, spec jobs.Spec, agg *aggregator.Aggregator, logger *slog.Logger) (*outputio.RunManifest, error) {
+	startedAt := time.Now()
+
+	wordBankFile, err := o.resolveWordbank(spec.Wordbank)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config.Config{
+		URLsFile:     urlsFile,
+		WordBankFile: wordBankFile,
+		Workers:      o.workers,
+		RateLimit:    o.rateLimit,
+		Format:       outputio.FormatJSON,
+
+		// Pages are fetched with the server's shared fetcher and its limits
+		MaxBodyBytes:   o.fetchCfg.MaxBodyBytes,
+		RequestTimeout: o.fetchCfg.RequestTimeout,
+		DialTimeout:    o.fetchCfg.DialTimeout,
+		TLSTimeout:     o.fetchCfg.TLSTimeout,
+		HeaderTimeout:  o.fetchCfg.HeaderTimeout,
+		IdleTimeout:    o.fetchCfg.IdleTimeout,
+		MaxRedirects:   o.fetchCfg.MaxRedirects,
+
+		// Remote wordbanks are cached like the CLI's, and words are filtered with the
+		// defaults, which keep every stopword the wordbank lists
+		WordBankCache:    config.DefaultWordBankCacheDir(),
+		WordBankCacheTTL: config.DefaultWordBankCacheTTL,
+		WordPattern:      config.DefaultWordPattern,
+		MinWordLength:    config.DefaultMinWordLength,
+
+		// Jobs always drop duplicate URLs with the defaults and, like the CLI, count near duplicates
+		Dedup:            true,
+		StripParams:      strings.Join(urlnorm.DefaultStripParams, ","),
+		NearDupThreshold: -1,
+
+		WordBankReload:  o.wordBankReload,
+		WordBankPoll:    o.wordBankPoll,
+		WordBankRecount: o.wordBankRecount,
+	}
+	if spec.Workers > 0 {
+		cfg.Workers = spec.Workers
+	}
+	if spec.RateLimit > 0 {
+		cfg.RateLimit = spec.RateLimit
+	}
+
+	fetch := o.fetch
+
+	wordBank, err := loadWordBank(ctx, cfg, fetch, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer wordBank.Close()
+
+	htmlParser := parser.New(logger)
+	textProcessor := processor.New(wordBank, logger)
+	workerCfg := calculateWorkerDistribution(cfg.Workers)
+	stats := &PipelineStats{}
+
+	source := dedupURLs(fileURLSource(urlsFile, logger), urlnorm.New(cfg.StripParamList()), urlnorm.NewSet(), stats, logger)
+	if spec.RateLimit > 0 {
+		// A job's own limit only slows it further below the shared one
+		source = rateLimitURLs(source, spec.RateLimit)
+	}
+	articles := newArticleDedup(urlnorm.New(cfg.StripParamList()))
+	nearDups := simhash.NewIndex(cfg.NearDupThreshold)
+
+	reloader := newWordBankReloader(cfg, wordBank, fetch, textProcessor, logger)
+	defer reloader.Close()
+	reloader.track(agg)
+	reloader.Start(ctx)
+
+	if err := runPipeline(ctx, logger, source, fetch, htmlParser, textProcessor, reloader, agg, articles, nearDups, workerCfg, stats, nil, nil); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	reloader.Stop()
+	reloader.recount(agg)
+	wordBank = reloader.WordBank(wordBank)
+
+	run, err := buildRunManifest(cfg, startedAt, fetch, wordBank, htmlParser, workerCfg, stats, config.GetTopWordsCount())
+	if err != nil {
+		return nil, err
+	}
+	if limit := run.EffectiveRateLimit; spec.RateLimit > 0 && (limit == 0 || spec.RateLimit < limit) {
+		run.EffectiveRateLimit = spec.RateLimit
+	}
+	return run, nil
+}
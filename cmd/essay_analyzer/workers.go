@@ -3,7 +3,9 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -11,10 +13,12 @@ import (
 	"github.com/firefly/essay-analyzer/internal/fetcher"
 	"github.com/firefly/essay-analyzer/internal/parser"
 	"github.com/firefly/essay-analyzer/internal/processor"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/tracing"
 )
 
-// readURLs reads URLs from file and sends them to the URL channel
-func readURLs(ctx context.Context, filename string, urlCh chan<- URLJob, verbose bool) error {
+// readURLs reads URLs from file and queues them with enqueue
+func readURLs(ctx context.Context, filename string, enqueue func(url string) error, logger *slog.Logger) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("opening URLs file: %w", err)
@@ -31,19 +35,17 @@ func readURLs(ctx context.Context, filename string, urlCh chan<- URLJob, verbose
 		default:
 		}
 
-		url := strings.TrimSpace(scanner.Text())
-		if url == "" || strings.HasPrefix(url, "#") {
-			continue // Skip empty lines and comments
+		url, ok := parseURLLine(scanner.Text())
+		if !ok {
+			continue
 		}
 
-		select {
-		case urlCh <- URLJob{URL: url}:
-			urlCount++
-			if verbose && urlCount%1000 == 0 {
-				fmt.Printf("📖 Queued %d URLs...\n", urlCount)
-			}
-		case <-ctx.Done():
-			return ctx.Err()
+		if err := enqueue(url); err != nil {
+			return err
+		}
+		urlCount++
+		if urlCount%1000 == 0 {
+			logger.Debug("queued URLs", "count", urlCount)
 		}
 	}
 
@@ -51,155 +53,264 @@ func readURLs(ctx context.Context, filename string, urlCh chan<- URLJob, verbose
 		return fmt.Errorf("reading URLs file: %w", err)
 	}
 
-	if verbose {
-		fmt.Printf("📖 Finished reading %d URLs\n", urlCount)
-	}
+	logger.Info("finished reading URLs", "count", urlCount)
 
 	return nil
 }
 
-// fetcherWorker fetches HTML content for URLs
+// countURLs counts the URLs in a file without queueing them, so progress can show a total
+func countURLs(filename string) (int64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("opening URLs file: %w", err)
+	}
+	defer file.Close()
+
+	var count int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if _, ok := parseURLLine(scanner.Text()); ok {
+			count++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("reading URLs file: %w", err)
+	}
+
+	return count, nil
+}
+
+// loadURLs reads every URL in a file into memory
+func loadURLs(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening URLs file: %w", err)
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if url, ok := parseURLLine(scanner.Text()); ok {
+			urls = append(urls, url)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading URLs file: %w", err)
+	}
+
+	return urls, nil
+}
+
+// parseURLLine returns the URL on a line of a URLs file, skipping empty lines and comments
+func parseURLLine(line string) (string, bool) {
+	url := strings.TrimSpace(line)
+	if url == "" || strings.HasPrefix(url, "#") {
+		return "", false
+	}
+	return url, true
+}
+
+// fetcherWorker returns a handler that fetches the HTML content for each URL
 func fetcherWorker(
-	ctx context.Context,
-	id int,
 	fetch *fetcher.Fetcher,
-	urlCh <-chan URLJob,
 	htmlCh chan<- HTMLResult,
 	errorCh chan<- error,
-	verbose bool,
-) {
-	for {
-		select {
-		case job, ok := <-urlCh:
-			if !ok {
-				return // Channel closed
-			}
+	stats *PipelineStats,
+	logger *slog.Logger,
+) func(ctx context.Context, job URLJob) {
+	return func(ctx context.Context, job URLJob) {
+		traceCtx := tracing.ContextWithSpan(ctx, job.Span)
 
-			// Check robots.txt compliance
-			allowed := fetch.IsAllowed(job.URL)
-			if !allowed {
-				select {
-				case errorCh <- fmt.Errorf("robots.txt disallows %s", job.URL):
-				case <-ctx.Done():
-					return
-				}
-				continue
+		// Check robots.txt compliance
+		_, robotsSpan := tracing.Start(traceCtx, "robots.check")
+		allowed := fetch.IsAllowed(job.URL)
+		robotsSpan.SetAttributes(tracing.Bool("robots.allowed", allowed))
+		robotsSpan.End()
+		if !allowed {
+			stats.Skipped.Add(1)
+			job.Span.RecordError(errDisallowed)
+			job.Span.End()
+			select {
+			case errorCh <- &URLError{URL: job.URL, Stage: StageRobots, Err: errDisallowed}:
+			case <-ctx.Done():
 			}
+			return
+		}
 
-			// Fetch content
-			content, err := fetch.FetchURL(ctx, job.URL)
+		// Fetch content
+		logger.Debug("fetching", "url", job.URL)
+		fetchCtx, fetchSpan := tracing.Start(traceCtx, "fetch")
+		page, err := fetch.FetchURL(fetchCtx, job.URL)
+		fetchSpan.RecordError(err)
+		fetchSpan.End()
 
+		if errors.Is(err, fetcher.ErrRedirectDisallowed) {
+			// Redirected somewhere robots.txt rules out, so skipped like a disallowed URL
+			stats.Skipped.Add(1)
+			job.Span.RecordError(err)
+			job.Span.End()
 			select {
-			case htmlCh <- HTMLResult{
-				URL:     job.URL,
-				Content: content,
-				Error:   err,
-			}:
+			case errorCh <- &URLError{URL: job.URL, Stage: StageRobots, Err: err}:
 			case <-ctx.Done():
-				return
 			}
+			return
+		}
 
+		result := HTMLResult{URL: job.URL, Error: err, Span: job.Span}
+		if page != nil {
+			result.FinalURL, result.Content = page.URL, page.Body
+		}
+
+		select {
+		case htmlCh <- result:
 		case <-ctx.Done():
-			return
+			if result.Content != nil {
+				result.Content.Close()
+			}
 		}
 	}
 }
 
-// parserWorker parses HTML content to extract text
+// parserWorker returns a handler that parses each page's HTML to extract its text,
+// dropping pages that articles has already seen under their canonical or final URL
 func parserWorker(
-	ctx context.Context,
-	id int,
 	htmlParser *parser.Parser,
-	htmlCh <-chan HTMLResult,
+	articles *articleDedup,
 	textCh chan<- TextResult,
-	errorCh chan<- error,
-	verbose bool,
-) {
-	for {
-		select {
-		case result, ok := <-htmlCh:
-			if !ok {
-				return // Channel closed
-			}
-
-			var text string
-			var err error
+	stats *PipelineStats,
+	logger *slog.Logger,
+) func(ctx context.Context, result HTMLResult) {
+	return func(ctx context.Context, result HTMLResult) {
+		var text string
+		var err error
 
-			if result.Error != nil {
-				err = fmt.Errorf("fetch failed: %w", result.Error)
-			} else {
-				text, err = htmlParser.ExtractText(result.Content)
-				if err != nil {
-					err = fmt.Errorf("parsing failed: %w", err)
-				}
+		if result.Error != nil {
+			err = &URLError{URL: result.URL, Stage: StageFetch, Err: result.Error}
+		} else {
+			logger.Debug("parsing", "url", result.URL)
+			_, parseSpan := tracing.Start(tracing.ContextWithSpan(ctx, result.Span), "html.parse")
+			var article *parser.Article
+			article, err = htmlParser.Extract(result.Content, result.FinalURL)
+			result.Content.Close()
+			if article != nil {
+				text = article.Text
+				parseSpan.SetAttributes(tracing.String("url.canonical", article.Canonical))
 			}
-
-			select {
-			case textCh <- TextResult{
-				URL:   result.URL,
-				Text:  text,
-				Error: err,
-			}:
-			case <-ctx.Done():
-				return
+			parseSpan.SetAttributes(tracing.Int("text.chars", len(text)))
+			parseSpan.RecordError(err)
+			parseSpan.End()
+			switch {
+			case errors.Is(err, fetcher.ErrBodyTooLarge):
+				// The body is streamed into the parser, so an oversized page surfaces here
+				err = &URLError{URL: result.URL, Stage: StageFetch, Err: err}
+			case err != nil:
+				err = &URLError{URL: result.URL, Stage: StageParse, Err: err}
+			default:
+				if id, ok := articles.add(result.FinalURL, article.Canonical); !ok {
+					stats.CanonicalDuplicates.Add(1)
+					result.Span.SetAttributes(tracing.String("url.article", id))
+					result.Span.End()
+					logger.Debug("dropped duplicate article", "url", result.URL, "article", id)
+					return
+				}
 			}
+		}
 
+		select {
+		case textCh <- TextResult{
+			URL:   result.URL,
+			Text:  text,
+			Error: err,
+			Span:  result.Span,
+		}:
 		case <-ctx.Done():
-			return
 		}
 	}
 }
 
-// processorWorker processes text to extract word counts
+// processorWorker returns a handler that counts the wordbank words in each article,
+// fingerprinting its text too when near duplicates are being detected and passing it
+// on when reloader recounts
 func processorWorker(
-	ctx context.Context,
-	id int,
 	textProcessor *processor.Processor,
-	textCh <-chan TextResult,
-	resultsCh chan<- aggregator.ProcessingResult,
+	reloader *wordBankReloader,
+	fingerprint bool,
+	resultsCh chan<- CountResult,
 	errorCh chan<- error,
-	verbose bool,
-) {
-	for {
-		select {
-		case result, ok := <-textCh:
-			if !ok {
-				return // Channel closed
-			}
-
-			if result.Error != nil {
-				select {
-				case errorCh <- fmt.Errorf("processing %s: %w", result.URL, result.Error):
-				case <-ctx.Done():
-					return
-				}
-				continue
-			}
-
-			// Process text to get word counts
-			wordCounts := textProcessor.ProcessText(result.Text)
-
+	stats *PipelineStats,
+	logger *slog.Logger,
+) func(ctx context.Context, result TextResult) {
+	return func(ctx context.Context, result TextResult) {
+		if result.Error != nil {
+			stats.Failed.Add(1)
+			result.Span.RecordError(result.Error)
+			result.Span.End()
 			select {
-			case resultsCh <- aggregator.ProcessingResult{
-				URL:        result.URL,
-				WordCounts: wordCounts,
-			}:
+			case errorCh <- result.Error:
 			case <-ctx.Done():
-				return
 			}
+			return
+		}
+
+		// Process text to get word counts
+		_, tokenizeSpan := tracing.Start(tracing.ContextWithSpan(ctx, result.Span), "tokenize")
+		counts := textProcessor.Process(result.Text)
+		wordCounts := counts.Words
+		tokenizeSpan.SetAttributes(tracing.Int("words.unique", len(wordCounts)))
+		if counts.Language != "" {
+			tokenizeSpan.SetAttributes(tracing.String("language", counts.Language))
+		}
+		tokenizeSpan.End()
+		logger.Debug("processed", "url", result.URL, "words", len(wordCounts), "language", counts.Language)
 
+		count := CountResult{
+			ProcessingResult: processingResult(result.URL, counts),
+			Skipped:          counts.Skipped,
+			Span:             result.Span,
+		}
+		if counts.Skipped == "" && reloader.keepsTexts() {
+			count.Text = result.Text
+		}
+		if fingerprint && counts.Skipped == "" {
+			count.Fingerprint, count.Fingerprinted = simhash.Fingerprint(result.Text)
+		}
+
+		select {
+		case resultsCh <- count:
 		case <-ctx.Done():
-			return
 		}
 	}
 }
 
-// aggregatorWorker collects and aggregates results
+// processingResult is what the aggregator keeps of an article's counts
+func processingResult(url string, counts processor.Counts) aggregator.ProcessingResult {
+	return aggregator.ProcessingResult{
+		URL:        url,
+		WordCounts: counts.Words,
+		Categories: counts.Categories,
+		Variants:   counts.Variants,
+		WordBank:   counts.WordBank,
+		Language:   counts.Language,
+		Statistics: counts.Statistics,
+		Contexts:   counts.Contexts,
+	}
+}
+
+// aggregatorWorker collects and aggregates results, skipping articles that nearDups
+// finds to be near duplicates of one already aggregated and recording those the
+// processor skipped. The text of each article aggregated is retained for reloader's
+// recount.
 func aggregatorWorker(
 	ctx context.Context,
 	agg *aggregator.Aggregator,
-	resultsCh <-chan aggregator.ProcessingResult,
-	verbose bool,
+	nearDups *simhash.Index,
+	reloader *wordBankReloader,
+	resultsCh <-chan CountResult,
+	stats *PipelineStats,
+	logger *slog.Logger,
 ) {
 	for {
 		select {
@@ -208,7 +319,32 @@ func aggregatorWorker(
 				return // Channel closed
 			}
 
-			agg.AddResult(result)
+			_, aggregateSpan := tracing.Start(tracing.ContextWithSpan(ctx, result.Span), "aggregate")
+			if result.Skipped != "" {
+				agg.AddSkipped(result.Skipped, result.Language)
+				aggregateSpan.SetAttributes(tracing.String("skipped", result.Skipped))
+				aggregateSpan.End()
+				result.Span.End()
+				stats.LanguageSkipped.Add(1)
+				logger.Debug("skipped article", "url", result.URL, "reason", result.Skipped, "language", result.Language)
+				continue
+			}
+			if result.Fingerprinted {
+				if original, distance, ok := nearDups.Add(result.URL, result.Fingerprint); ok {
+					agg.AddNearDuplicate(original, simhash.Duplicate{URL: result.URL, Distance: distance})
+					aggregateSpan.SetAttributes(tracing.String("near_duplicate.of", original))
+					aggregateSpan.End()
+					result.Span.End()
+					stats.NearDuplicates.Add(1)
+					logger.Debug("skipped near duplicate", "url", result.URL, "original", original)
+					continue
+				}
+			}
+			agg.AddResult(result.ProcessingResult)
+			reloader.retain(result.URL, result.Text)
+			aggregateSpan.End()
+			result.Span.End()
+			stats.Succeeded.Add(1)
 
 		case <-ctx.Done():
 			return
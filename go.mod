@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/PuerkitoBio/goquery v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.22
 	golang.org/x/time v0.3.0
 )
 
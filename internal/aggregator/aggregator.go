@@ -1,9 +1,16 @@
 package aggregator
 
 import (
+	"fmt"
+	"log/slog"
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/textstats"
 )
 
 // WordCount represents a word and its frequency
@@ -12,28 +19,139 @@ type WordCount struct {
 	Count int    `json:"count"`
 }
 
+// VariantCount is how often a form other than the word itself, such as an alias
+// or misspelling, was credited to a word
+type VariantCount struct {
+	Form  string `json:"form"`
+	Count int    `json:"count"`
+}
+
+// CategoryCount reports the words counted in one wordbank category
+type CategoryCount struct {
+	Category string      `json:"category"`
+	Count    int         `json:"count"`
+	Share    float64     `json:"share"` // Fraction of all counted words
+	TopWords []WordCount `json:"top_words"`
+}
+
+// VersionCount reports the articles counted with one version of the wordbank
+type VersionCount struct {
+	Version    string      `json:"version"`
+	Articles   int         `json:"articles"`
+	TotalWords int         `json:"total_words"`
+	TopWords   []WordCount `json:"top_words"`
+}
+
+// LanguageCount reports the articles counted in one language
+type LanguageCount struct {
+	Language   string      `json:"language"`
+	Articles   int         `json:"articles"`
+	TotalWords int         `json:"total_words"`
+	TopWords   []WordCount `json:"top_words"`
+}
+
+// SkipCount is how many articles were skipped for one reason, such as their
+// language being unsupported
+type SkipCount struct {
+	Reason   string `json:"reason"`
+	Language string `json:"language,omitempty"` // The language identified, if any
+	Articles int    `json:"articles"`
+}
+
 // ProcessingResult represents the result from processing a single article
 type ProcessingResult struct {
-	URL        string
-	WordCounts map[string]int
+	URL        string                           `json:"url"`
+	WordCounts map[string]int                   `json:"word_counts"`
+	Categories map[string]string                `json:"categories,omitempty"` // Category of each word in WordCounts that has one
+	Variants   map[string]map[string]int        `json:"variants,omitempty"`   // Variant forms counted for each word in WordCounts, with their counts
+	WordBank   string                           `json:"wordbank,omitempty"`   // Version of the wordbank the article was counted with
+	Language   string                           `json:"language,omitempty"`   // Language the article was identified as, if detected
+	Statistics *textstats.Article               `json:"statistics,omitempty"` // Vocabulary and readability, if measured
+	Contexts   map[string]concordance.Reservoir `json:"contexts,omitempty"`   // Sample contexts of counted words, if kept
+}
+
+// SnapshotVersion is the format version written in exported snapshots
+const SnapshotVersion = 1
+
+// Snapshot is the complete, mergeable state of an Aggregator
+type Snapshot struct {
+	Version               int                              `json:"version"`
+	WordCounts            map[string]int                   `json:"word_counts"`
+	DocumentFrequencies   map[string]int                   `json:"document_frequencies"`
+	WordCategories        map[string]string                `json:"word_categories,omitempty"`
+	Variants              map[string]map[string]int        `json:"variants,omitempty"`
+	Versions              map[string]*Tally                `json:"wordbank_versions,omitempty"`
+	Languages             map[string]*Tally                `json:"languages,omitempty"`
+	Skipped               map[string]map[string]int        `json:"skipped,omitempty"` // Articles skipped by reason and language
+	NearDuplicates        []simhash.Cluster                `json:"near_duplicates,omitempty"`
+	Articles              []ProcessingResult               `json:"articles,omitempty"` // Per-article counts, when retained
+	Statistics            *textstats.Totals                `json:"statistics,omitempty"`
+	Contexts              map[string]concordance.Reservoir `json:"contexts,omitempty"`
+	ContextsPerWord       int                              `json:"contexts_per_word,omitempty"`
+	ContextWords          []string                         `json:"context_words,omitempty"`      // Words contexts were kept for, nil for the top words
+	ContextCandidates     int                              `json:"context_candidates,omitempty"` // Most words contexts were kept for without ContextWords
+	TotalWordsProcessed   int                              `json:"total_words_processed"`
+	TotalEssaysProcessed  int                              `json:"total_essays_processed"`
+	ProcessingTimeSeconds float64                          `json:"processing_time_seconds"`
 }
 
 // Aggregator collects and aggregates word frequency results
 type Aggregator struct {
 	mu                   sync.RWMutex
 	globalWordCounts     map[string]int
+	documentFrequencies  map[string]int            // Number of articles each word appeared in
+	wordCategories       map[string]string         // Category of each counted word that has one
+	variants             map[string]map[string]int // Variant forms credited to each word
 	totalWordsProcessed  int
 	totalEssaysProcessed int
 	startTime            time.Time
-	verbose              bool
+	mergedElapsed        float64 // Longest processing time among merged snapshots
+	logger               *slog.Logger
+
+	// Per-article word counts, only kept when RetainArticles has been called
+	retainArticles bool
+	articles       []ProcessingResult
+	articleIndex   map[string][]int // Indexes in articles of each URL
+
+	// Counts per wordbank version, only kept when KeepVersions has been called
+	keepVersions bool
+	versions     tallies
+
+	// Counts per language of the articles whose language was detected
+	languages tallies
+
+	// Articles skipped by reason and language
+	skipped map[string]map[string]int
+
+	// Near duplicates left out of the counts, by the URL of the article they matched
+	nearDuplicates map[string][]simhash.Duplicate
+
+	// Statistics of the articles that were measured, nil when none were
+	statistics *textstats.Totals
+
+	// Sample contexts of each word, only kept when KeepContexts has been called
+	contextsPerWord   int
+	contextWords      []string
+	contextCandidates int // Most words contexts are kept for without contextWords (0 = unlimited)
+	contexts          map[string]concordance.Reservoir
 }
 
-// New creates a new Aggregator
-func New(verbose bool) *Aggregator {
+// ContextCandidatesPerWord is how many words contexts are kept for, per top word
+// reported, when no words are given to KeepContexts
+const ContextCandidatesPerWord = 4
+
+// New creates a new Aggregator. A nil logger discards all log output.
+func New(logger *slog.Logger) *Aggregator {
 	return &Aggregator{
-		globalWordCounts: make(map[string]int),
-		startTime:        time.Now(),
-		verbose:          verbose,
+		globalWordCounts:    make(map[string]int),
+		documentFrequencies: make(map[string]int),
+		wordCategories:      make(map[string]string),
+		variants:            make(map[string]map[string]int),
+		articleIndex:        make(map[string][]int),
+		skipped:             make(map[string]map[string]int),
+		nearDuplicates:      make(map[string][]simhash.Duplicate),
+		startTime:           time.Now(),
+		logger:              logging.OrDiscard(logger),
 	}
 }
 
@@ -42,22 +160,320 @@ func (a *Aggregator) AddResult(result ProcessingResult) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	a.count(result, 1)
+	a.totalEssaysProcessed++
+
+	if a.retainArticles {
+		result.Contexts = nil // Only needed for the totals
+		a.articleIndex[result.URL] = append(a.articleIndex[result.URL], len(a.articles))
+		a.articles = append(a.articles, result)
+	}
+}
+
+// Replace recounts an article added earlier, such as after the wordbank changed,
+// swapping its counts for those in result. Every article added with result's URL
+// is replaced. It reports false when there is none, so it needs RetainArticles.
+func (a *Aggregator) Replace(result ProcessingResult) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	indexes := a.articleIndex[result.URL]
+	for _, i := range indexes {
+		a.count(a.articles[i], -1)
+		a.count(result, 1)
+		a.articles[i] = result
+	}
+	return len(indexes) > 0
+}
+
+// count adds an article's counts to the totals, or removes them when sign is -1,
+// dropping words whose count falls to zero. Callers must hold a.mu.
+func (a *Aggregator) count(result ProcessingResult, sign int) {
 	// Aggregate word counts
 	articleWordCount := 0
 	for word, count := range result.WordCounts {
-		a.globalWordCounts[word] += count
 		articleWordCount += count
+		addCount(a.globalWordCounts, word, sign*count)
+		if count > 0 {
+			addCount(a.documentFrequencies, word, sign)
+		}
 	}
+	for word, category := range result.Categories {
+		if sign > 0 {
+			a.wordCategories[word] = category
+		} else if a.globalWordCounts[word] == 0 {
+			delete(a.wordCategories, word)
+		}
+	}
+	for word, forms := range result.Variants {
+		if a.variants[word] == nil {
+			a.variants[word] = make(map[string]int)
+		}
+		for form, count := range forms {
+			addCount(a.variants[word], form, sign*count)
+		}
+		if len(a.variants[word]) == 0 {
+			delete(a.variants, word)
+		}
+	}
+	a.totalWordsProcessed += sign * articleWordCount
 
-	a.totalWordsProcessed += articleWordCount
-	a.totalEssaysProcessed++
+	if a.keepVersions {
+		a.versions.count(result.WordBank, result.WordCounts, sign)
+	}
+	if result.Language != "" {
+		a.languages.count(result.Language, result.WordCounts, sign)
+	}
+	if result.Statistics != nil {
+		if a.statistics == nil {
+			a.statistics = &textstats.Totals{}
+		}
+		a.statistics.Add(*result.Statistics, sign)
+	}
+	// A sample cannot be taken back out, but a recounted article offers the same
+	// samples again and they are only kept once
+	if sign > 0 && a.contextsPerWord > 0 {
+		for word, samples := range result.Contexts {
+			if !a.admitContexts(word) {
+				continue
+			}
+			r := a.contexts[word]
+			for _, sample := range samples {
+				sample.URL = result.URL
+				r.Offer(sample, a.contextsPerWord)
+			}
+			a.contexts[word] = r
+		}
+	}
+}
+
+// addCount adds n to counts[key], deleting the key when it reaches zero
+func addCount(counts map[string]int, key string, n int) {
+	if counts[key] += n; counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
+
+// KeepVersions makes the aggregator also count the articles of each wordbank
+// version separately. Call it before adding any results.
+func (a *Aggregator) KeepVersions() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.keepVersions = true
+}
+
+// GetVersions returns the counts of each wordbank version in the order first
+// seen, each with its top n words, or nil unless KeepVersions was called
+func (a *Aggregator) GetVersions(n int) []VersionCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var versions []VersionCount
+	a.versions.each(n, func(name string, tally *Tally, top []WordCount) {
+		versions = append(versions, VersionCount{Version: name, Articles: tally.Articles, TotalWords: tally.TotalWords, TopWords: top})
+	})
+	return versions
+}
+
+// GetLanguages returns the counts of each detected language, most articles first,
+// each with its top n words, or nil when no article's language was detected
+func (a *Aggregator) GetLanguages(n int) []LanguageCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var languages []LanguageCount
+	a.languages.each(n, func(name string, tally *Tally, top []WordCount) {
+		languages = append(languages, LanguageCount{Language: name, Articles: tally.Articles, TotalWords: tally.TotalWords, TopWords: top})
+	})
+	sort.SliceStable(languages, func(i, j int) bool {
+		if languages[i].Articles != languages[j].Articles {
+			return languages[i].Articles > languages[j].Articles
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages
+}
+
+// AddSkipped records an article that was not counted, with the reason and the
+// language it was identified as ("" when none)
+func (a *Aggregator) AddSkipped(reason, language string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.skipped[reason] == nil {
+		a.skipped[reason] = make(map[string]int)
+	}
+	a.skipped[reason][language]++
+}
+
+// AddNearDuplicate records an article that was not counted because its text
+// nearly matched the counted article at original
+func (a *Aggregator) AddNearDuplicate(original string, duplicate simhash.Duplicate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nearDuplicates[original] = append(a.nearDuplicates[original], duplicate)
+}
+
+// GetNearDuplicates returns every counted article that has near duplicates,
+// sorted by URL with its duplicates sorted by URL
+func (a *Aggregator) GetNearDuplicates() []simhash.Cluster {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.nearDuplicateClusters()
+}
+
+// nearDuplicateClusters copies the near duplicates into sorted clusters. The
+// caller must hold a.mu.
+func (a *Aggregator) nearDuplicateClusters() []simhash.Cluster {
+	var clusters []simhash.Cluster
+	for url, duplicates := range a.nearDuplicates {
+		duplicates = append([]simhash.Duplicate(nil), duplicates...)
+		sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].URL < duplicates[j].URL })
+		clusters = append(clusters, simhash.Cluster{URL: url, Duplicates: duplicates})
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].URL < clusters[j].URL })
+	return clusters
+}
+
+// GetSkipped returns the articles skipped for each reason and language, most first
+func (a *Aggregator) GetSkipped() []SkipCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var skipped []SkipCount
+	for reason, languages := range a.skipped {
+		for language, articles := range languages {
+			skipped = append(skipped, SkipCount{Reason: reason, Language: language, Articles: articles})
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		if skipped[i].Articles != skipped[j].Articles {
+			return skipped[i].Articles > skipped[j].Articles
+		}
+		if skipped[i].Reason != skipped[j].Reason {
+			return skipped[i].Reason < skipped[j].Reason
+		}
+		return skipped[i].Language < skipped[j].Language
+	})
+	return skipped
+}
+
+// GetStatistics returns the vocabulary and readability of the articles measured,
+// or nil when none were
+func (a *Aggregator) GetStatistics() *textstats.Statistics {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.statistics == nil {
+		return nil
+	}
+	return a.statistics.Summarize()
+}
+
+// KeepContexts makes the aggregator keep a uniform sample of up to k of the
+// contexts offered for each word, for a concordance of the top n words or, with
+// words, of those words. Without words, contexts are only kept for the
+// ContextCandidatesPerWord*n most counted words so far, so memory stays bounded
+// however many words are counted; a word that becomes one of them late only has
+// contexts from then on. Call it before adding any results.
+func (a *Aggregator) KeepContexts(k int, words []string, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.contextsPerWord = k
+	a.contextWords = words
+	if words == nil {
+		a.contextCandidates = ContextCandidatesPerWord * n
+	}
+	a.contexts = make(map[string]concordance.Reservoir)
+}
+
+// admitContexts reports whether contexts of word are kept, making room for it by
+// dropping those of the least counted candidate when word is counted more.
+// Callers must hold a.mu.
+func (a *Aggregator) admitContexts(word string) bool {
+	if _, ok := a.contexts[word]; ok || a.contextCandidates <= 0 || len(a.contexts) < a.contextCandidates {
+		return true
+	}
+	least := a.leastContextWord()
+	if a.globalWordCounts[word] <= a.globalWordCounts[least] {
+		return false
+	}
+	delete(a.contexts, least)
+	return true
+}
+
+// trimContexts drops the contexts of the least counted words until no more than
+// the candidates are left. Callers must hold a.mu.
+func (a *Aggregator) trimContexts() {
+	for a.contextCandidates > 0 && len(a.contexts) > a.contextCandidates {
+		delete(a.contexts, a.leastContextWord())
+	}
+}
 
-	if a.verbose && a.totalEssaysProcessed%100 == 0 {
-		elapsed := time.Since(a.startTime).Seconds()
-		rate := float64(a.totalEssaysProcessed) / elapsed
-		println("✅ Processed", a.totalEssaysProcessed, "articles,", a.totalWordsProcessed, "words",
-			"(", int(rate), "articles/sec )")
+// leastContextWord returns the least counted word contexts are kept for, the last
+// alphabetically among ties. Callers must hold a.mu.
+func (a *Aggregator) leastContextWord() string {
+	least := ""
+	for word := range a.contexts {
+		count, leastCount := a.globalWordCounts[word], a.globalWordCounts[least]
+		if least == "" || count < leastCount || (count == leastCount && word > least) {
+			least = word
+		}
 	}
+	return least
+}
+
+// GetConcordance returns the contexts kept for each of the words given to
+// KeepContexts, or else each of the top n words, skipping words without any. It
+// returns nil unless KeepContexts was called.
+func (a *Aggregator) GetConcordance(n int) []concordance.Entry {
+	a.mu.RLock()
+	words := a.contextWords
+	keep := a.contextsPerWord > 0
+	a.mu.RUnlock()
+
+	if !keep {
+		return nil
+	}
+	if words == nil {
+		for _, wc := range a.GetTopWords(n) {
+			words = append(words, wc.Word)
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var entries []concordance.Entry
+	for _, word := range words {
+		if samples := a.contexts[word]; len(samples) > 0 {
+			entries = append(entries, concordance.Entry{Word: word, Contexts: samples.Contexts()})
+		}
+	}
+	return entries
+}
+
+// RetainArticles makes the aggregator keep each article's word counts so they
+// can be exported individually. Call it before adding any results.
+func (a *Aggregator) RetainArticles() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.retainArticles = true
+}
+
+// GetArticles returns the per-article results kept since RetainArticles was called
+func (a *Aggregator) GetArticles() []ProcessingResult {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	articles := make([]ProcessingResult, len(a.articles))
+	copy(articles, a.articles)
+	return articles
 }
 
 // GetTopWords returns the top N words by frequency
@@ -71,7 +487,82 @@ func (a *Aggregator) GetTopWords(n int) []WordCount {
 		words = append(words, WordCount{Word: word, Count: count})
 	}
 
-	// Sort by count (descending), then by word (ascending) for stable results
+	return topWords(words, n)
+}
+
+// GetVariants returns the variants credited to each of words that has any, most
+// counted first, or nil when none has
+func (a *Aggregator) GetVariants(words []WordCount) map[string][]VariantCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var result map[string][]VariantCount
+	for _, wc := range words {
+		forms := a.variants[wc.Word]
+		if len(forms) == 0 {
+			continue
+		}
+		variants := make([]VariantCount, 0, len(forms))
+		for form, count := range forms {
+			variants = append(variants, VariantCount{Form: form, Count: count})
+		}
+		sort.Slice(variants, func(i, j int) bool {
+			if variants[i].Count == variants[j].Count {
+				return variants[i].Form < variants[j].Form
+			}
+			return variants[i].Count > variants[j].Count
+		})
+		if result == nil {
+			result = make(map[string][]VariantCount)
+		}
+		result[wc.Word] = variants
+	}
+	return result
+}
+
+// GetCategories returns the total count of each category, largest first, with its
+// share of all counted words and its top n words. Words without a category are left out.
+func (a *Aggregator) GetCategories(n int) []CategoryCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	words := make(map[string][]WordCount)
+	for word, category := range a.wordCategories {
+		words[category] = append(words[category], WordCount{Word: word, Count: a.globalWordCounts[word]})
+	}
+
+	categories := make([]CategoryCount, 0, len(words))
+	for category, categoryWords := range words {
+		total := 0
+		for _, wc := range categoryWords {
+			total += wc.Count
+		}
+
+		var share float64
+		if a.totalWordsProcessed > 0 {
+			share = float64(total) / float64(a.totalWordsProcessed)
+		}
+
+		categories = append(categories, CategoryCount{
+			Category: category,
+			Count:    total,
+			Share:    share,
+			TopWords: topWords(categoryWords, n),
+		})
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count == categories[j].Count {
+			return categories[i].Category < categories[j].Category
+		}
+		return categories[i].Count > categories[j].Count
+	})
+	return categories
+}
+
+// topWords sorts words by count (descending), then by word (ascending) for stable
+// results, and returns the first n
+func topWords(words []WordCount, n int) []WordCount {
 	sort.Slice(words, func(i, j int) bool {
 		if words[i].Count == words[j].Count {
 			return words[i].Word < words[j].Word
@@ -92,21 +583,187 @@ func (a *Aggregator) GetStats() (processed int, totalWords int, uniqueWords int,
 	defer a.mu.RUnlock()
 
 	return a.totalEssaysProcessed, a.totalWordsProcessed,
-		len(a.globalWordCounts), time.Since(a.startTime).Seconds()
+		len(a.globalWordCounts), a.elapsed()
 }
 
-// PrintFinalStats prints final processing statistics
-func (a *Aggregator) PrintFinalStats() {
-	processed, totalWords, uniqueWords, elapsed := a.GetStats()
+// elapsed returns the processing time, which is never less than that of any
+// merged snapshot since shards run in parallel. Callers must hold a.mu.
+func (a *Aggregator) elapsed() float64 {
+	elapsed := time.Since(a.startTime).Seconds()
+	if a.mergedElapsed > elapsed {
+		return a.mergedElapsed
+	}
+	return elapsed
+}
 
-	println("\n📊 Final Statistics:")
-	println("  Articles processed:", processed)
-	println("  Total words processed:", totalWords)
-	println("  Unique words found:", uniqueWords)
-	println("  Processing time:", int(elapsed), "seconds")
+// GetDocumentFrequency returns the number of articles in which word appeared
+func (a *Aggregator) GetDocumentFrequency(word string) int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.documentFrequencies[word]
+}
 
-	if processed > 0 {
-		rate := float64(processed) / elapsed
-		println("  Processing rate:", int(rate), "articles/sec")
+// Snapshot returns a copy of the aggregator's full state
+func (a *Aggregator) Snapshot() *Snapshot {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Version:               SnapshotVersion,
+		WordCounts:            make(map[string]int, len(a.globalWordCounts)),
+		DocumentFrequencies:   make(map[string]int, len(a.documentFrequencies)),
+		TotalWordsProcessed:   a.totalWordsProcessed,
+		TotalEssaysProcessed:  a.totalEssaysProcessed,
+		ProcessingTimeSeconds: a.elapsed(),
+	}
+	for word, count := range a.globalWordCounts {
+		snapshot.WordCounts[word] = count
+	}
+	for word, df := range a.documentFrequencies {
+		snapshot.DocumentFrequencies[word] = df
+	}
+	if len(a.wordCategories) > 0 {
+		snapshot.WordCategories = make(map[string]string, len(a.wordCategories))
+		for word, category := range a.wordCategories {
+			snapshot.WordCategories[word] = category
+		}
+	}
+	if len(a.variants) > 0 {
+		snapshot.Variants = make(map[string]map[string]int, len(a.variants))
+		for word, forms := range a.variants {
+			snapshot.Variants[word] = make(map[string]int, len(forms))
+			for form, count := range forms {
+				snapshot.Variants[word][form] = count
+			}
+		}
+	}
+	if a.statistics != nil {
+		snapshot.Statistics = &textstats.Totals{}
+		snapshot.Statistics.Merge(a.statistics)
 	}
+	if a.contextsPerWord > 0 {
+		snapshot.ContextsPerWord = a.contextsPerWord
+		snapshot.ContextWords = a.contextWords
+		snapshot.ContextCandidates = a.contextCandidates
+		snapshot.Contexts = make(map[string]concordance.Reservoir, len(a.contexts))
+		for word, samples := range a.contexts {
+			snapshot.Contexts[word] = append(concordance.Reservoir(nil), samples...)
+		}
+	}
+	snapshot.Versions = a.versions.snapshot()
+	snapshot.Languages = a.languages.snapshot()
+	if len(a.skipped) > 0 {
+		snapshot.Skipped = make(map[string]map[string]int, len(a.skipped))
+		for reason, languages := range a.skipped {
+			snapshot.Skipped[reason] = make(map[string]int, len(languages))
+			for language, articles := range languages {
+				snapshot.Skipped[reason][language] = articles
+			}
+		}
+	}
+	snapshot.NearDuplicates = a.nearDuplicateClusters()
+	if a.retainArticles {
+		snapshot.Articles = append([]ProcessingResult(nil), a.articles...)
+	}
+
+	return snapshot
+}
+
+// Merge adds a snapshot's counts to the aggregator. Merging the snapshots of
+// disjoint shards yields the same counts as processing all articles at once.
+// The snapshot's per-article counts are kept if the aggregator retains articles.
+func (a *Aggregator) Merge(snapshot *Snapshot) error {
+	if snapshot.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (expected %d)", snapshot.Version, SnapshotVersion)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for word, count := range snapshot.WordCounts {
+		a.globalWordCounts[word] += count
+	}
+	for word, df := range snapshot.DocumentFrequencies {
+		a.documentFrequencies[word] += df
+	}
+	for word, category := range snapshot.WordCategories {
+		a.wordCategories[word] = category
+	}
+	for word, forms := range snapshot.Variants {
+		if a.variants[word] == nil {
+			a.variants[word] = make(map[string]int)
+		}
+		for form, count := range forms {
+			a.variants[word][form] += count
+		}
+	}
+
+	if len(snapshot.Versions) > 0 {
+		a.keepVersions = true
+		a.versions.merge(snapshot.Versions)
+	}
+	a.languages.merge(snapshot.Languages)
+	if snapshot.ContextsPerWord > 0 {
+		if a.contextsPerWord == 0 {
+			a.contextsPerWord = snapshot.ContextsPerWord
+			a.contextWords = snapshot.ContextWords
+			a.contextCandidates = snapshot.ContextCandidates
+			a.contexts = make(map[string]concordance.Reservoir)
+		}
+		for word, samples := range snapshot.Contexts {
+			r := a.contexts[word]
+			r.Merge(samples, a.contextsPerWord)
+			a.contexts[word] = r
+		}
+		a.trimContexts()
+	}
+	if snapshot.Statistics != nil {
+		if a.statistics == nil {
+			a.statistics = &textstats.Totals{}
+		}
+		a.statistics.Merge(snapshot.Statistics)
+	}
+	for reason, languages := range snapshot.Skipped {
+		if a.skipped[reason] == nil {
+			a.skipped[reason] = make(map[string]int)
+		}
+		for language, articles := range languages {
+			a.skipped[reason][language] += articles
+		}
+	}
+	for _, cluster := range snapshot.NearDuplicates {
+		a.nearDuplicates[cluster.URL] = append(a.nearDuplicates[cluster.URL], cluster.Duplicates...)
+	}
+	if a.retainArticles {
+		for _, article := range snapshot.Articles {
+			a.articleIndex[article.URL] = append(a.articleIndex[article.URL], len(a.articles))
+			a.articles = append(a.articles, article)
+		}
+	}
+	a.totalWordsProcessed += snapshot.TotalWordsProcessed
+	a.totalEssaysProcessed += snapshot.TotalEssaysProcessed
+
+	if snapshot.ProcessingTimeSeconds > a.mergedElapsed {
+		a.mergedElapsed = snapshot.ProcessingTimeSeconds
+	}
+
+	return nil
+}
+
+// LogFinalStats logs final processing statistics
+func (a *Aggregator) LogFinalStats() {
+	processed, totalWords, uniqueWords, elapsed := a.GetStats()
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(processed) / elapsed
+	}
+
+	a.logger.Info("final statistics",
+		"articles_processed", processed,
+		"total_words", totalWords,
+		"unique_words", uniqueWords,
+		"processing_seconds", elapsed,
+		"articles_per_second", rate)
 }
@@ -1,11 +1,16 @@
 package aggregator
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/textstats"
 )
 
 func TestAggregator_AddResult(t *testing.T) {
-	agg := New(false)
+	agg := New(nil)
 
 	// Test successful result
 	result1 := ProcessingResult{
@@ -32,7 +37,7 @@ func TestAggregator_AddResult(t *testing.T) {
 }
 
 func TestAggregator_GetTopWords(t *testing.T) {
-	agg := New(false)
+	agg := New(nil)
 
 	// Add multiple results
 	results := []ProcessingResult{
@@ -80,7 +85,7 @@ func TestAggregator_GetTopWords(t *testing.T) {
 }
 
 func TestAggregator_ConcurrentAccess(t *testing.T) {
-	agg := New(false)
+	agg := New(nil)
 
 	// Test concurrent access
 	done := make(chan bool, 10)
@@ -116,3 +121,503 @@ func TestAggregator_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected top word to be {word: 10}, got %+v", topWords[0])
 	}
 }
+
+func TestAggregator_RetainArticles(t *testing.T) {
+	agg := New(nil)
+
+	// Articles are not kept by default
+	agg.AddResult(ProcessingResult{URL: "https://example.com/0", WordCounts: map[string]int{"word": 1}})
+	if articles := agg.GetArticles(); len(articles) != 0 {
+		t.Fatalf("Expected no retained articles by default, got %d", len(articles))
+	}
+
+	agg.RetainArticles()
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"word": 2}})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"other": 3}})
+
+	articles := agg.GetArticles()
+	if len(articles) != 2 {
+		t.Fatalf("Expected 2 retained articles, got %d", len(articles))
+	}
+	if articles[0].URL != "https://example.com/1" || articles[0].WordCounts["word"] != 2 {
+		t.Errorf("Unexpected first article: %+v", articles[0])
+	}
+	if articles[1].URL != "https://example.com/2" || articles[1].WordCounts["other"] != 3 {
+		t.Errorf("Unexpected second article: %+v", articles[1])
+	}
+}
+
+func TestAggregator_DocumentFrequency(t *testing.T) {
+	agg := New(nil)
+
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 5, "science": 1}})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"technology": 2}})
+
+	if df := agg.GetDocumentFrequency("technology"); df != 2 {
+		t.Errorf("Expected technology in 2 documents, got %d", df)
+	}
+	if df := agg.GetDocumentFrequency("science"); df != 1 {
+		t.Errorf("Expected science in 1 document, got %d", df)
+	}
+	if df := agg.GetDocumentFrequency("missing"); df != 0 {
+		t.Errorf("Expected missing in 0 documents, got %d", df)
+	}
+}
+
+func TestAggregator_MergeMatchesSingleRun(t *testing.T) {
+	results := []ProcessingResult{
+		{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 10, "innovation": 5}},
+		{URL: "https://example.com/2", WordCounts: map[string]int{"technology": 8, "science": 7}},
+		{URL: "https://example.com/3", WordCounts: map[string]int{"science": 1, "computer": 3}},
+		{URL: "https://example.com/4", WordCounts: map[string]int{"innovation": 2}},
+	}
+
+	single := New(nil)
+	for _, result := range results {
+		single.AddResult(result)
+	}
+
+	// Split the same results across two shards and merge their snapshots
+	shardA, shardB := New(nil), New(nil)
+	for i, result := range results {
+		if i%2 == 0 {
+			shardA.AddResult(result)
+		} else {
+			shardB.AddResult(result)
+		}
+	}
+
+	merged := New(nil)
+	for _, shard := range []*Aggregator{shardA, shardB} {
+		if err := merged.Merge(shard.Snapshot()); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+
+	want, got := single.Snapshot(), merged.Snapshot()
+	if !reflect.DeepEqual(want.WordCounts, got.WordCounts) {
+		t.Errorf("Word counts differ: want %v, got %v", want.WordCounts, got.WordCounts)
+	}
+	if !reflect.DeepEqual(want.DocumentFrequencies, got.DocumentFrequencies) {
+		t.Errorf("Document frequencies differ: want %v, got %v", want.DocumentFrequencies, got.DocumentFrequencies)
+	}
+	if want.TotalWordsProcessed != got.TotalWordsProcessed || want.TotalEssaysProcessed != got.TotalEssaysProcessed {
+		t.Errorf("Totals differ: want %+v, got %+v", want, got)
+	}
+	if !reflect.DeepEqual(single.GetTopWords(10), merged.GetTopWords(10)) {
+		t.Errorf("Top words differ: want %v, got %v", single.GetTopWords(10), merged.GetTopWords(10))
+	}
+}
+
+func TestAggregator_MergeKeepsLongestElapsed(t *testing.T) {
+	agg := New(nil)
+
+	if err := agg.Merge(&Snapshot{Version: SnapshotVersion, ProcessingTimeSeconds: 120}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if err := agg.Merge(&Snapshot{Version: SnapshotVersion, ProcessingTimeSeconds: 90}); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if _, _, _, elapsed := agg.GetStats(); elapsed != 120 {
+		t.Errorf("Expected elapsed of the slowest shard (120s), got %v", elapsed)
+	}
+}
+
+func TestAggregator_MergeRejectsUnknownVersion(t *testing.T) {
+	agg := New(nil)
+
+	if err := agg.Merge(&Snapshot{Version: SnapshotVersion + 1}); err == nil {
+		t.Fatal("Expected error for unsupported snapshot version")
+	}
+}
+
+func TestAggregator_GetCategories(t *testing.T) {
+	agg := New(nil)
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"privacy": 4, "tracking": 2, "console": 3, "weather": 1},
+		Categories: map[string]string{"privacy": "privacy", "tracking": "privacy", "console": "gaming"},
+	})
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/2",
+		WordCounts: map[string]int{"console": 2, "tracking": 3},
+		Categories: map[string]string{"console": "gaming", "tracking": "privacy"},
+	})
+
+	want := []CategoryCount{
+		{Category: "privacy", Count: 9, Share: 0.6, TopWords: []WordCount{{"tracking", 5}, {"privacy", 4}}},
+		{Category: "gaming", Count: 5, Share: 5.0 / 15, TopWords: []WordCount{{"console", 5}}},
+	}
+	if got := agg.GetCategories(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected categories %+v, got %+v", want, got)
+	}
+
+	if got := agg.GetCategories(1); len(got[0].TopWords) != 1 || got[0].TopWords[0].Word != "tracking" {
+		t.Errorf("Expected only the top privacy word, got %+v", got[0].TopWords)
+	}
+
+	// Categories survive a snapshot round trip
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetCategories(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected merged categories %+v, got %+v", want, got)
+	}
+}
+
+func TestAggregator_GetCategories_None(t *testing.T) {
+	agg := New(nil)
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 1}})
+
+	if got := agg.GetCategories(10); len(got) != 0 {
+		t.Errorf("Expected no categories, got %+v", got)
+	}
+	if agg.Snapshot().WordCategories != nil {
+		t.Error("Expected no word categories in the snapshot")
+	}
+}
+
+func TestAggregator_GetVariants(t *testing.T) {
+	agg := New(nil)
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"ereader": 4, "color": 1},
+		Variants:   map[string]map[string]int{"ereader": {"e reader": 2, "ereeder": 1}},
+	})
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/2",
+		WordCounts: map[string]int{"ereader": 2},
+		Variants:   map[string]map[string]int{"ereader": {"ereeder": 1}},
+	})
+
+	want := map[string][]VariantCount{
+		"ereader": {{Form: "e reader", Count: 2}, {Form: "ereeder", Count: 2}},
+	}
+	if got := agg.GetVariants(agg.GetTopWords(10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected variants %+v, got %+v", want, got)
+	}
+	if got := agg.GetVariants([]WordCount{{"color", 1}}); got != nil {
+		t.Errorf("Expected no variants for color, got %+v", got)
+	}
+
+	// Variants survive a snapshot round trip
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetVariants(merged.GetTopWords(10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected merged variants %+v, got %+v", want, got)
+	}
+}
+
+func TestAggregator_KeepVersions(t *testing.T) {
+	agg := New(nil)
+	agg.KeepVersions()
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"robot": 3}, WordBank: "aaa"})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"robot": 1, "laptop": 2}, WordBank: "bbb"})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/3", WordCounts: map[string]int{"laptop": 1}, WordBank: "aaa"})
+
+	want := []VersionCount{
+		{Version: "aaa", Articles: 2, TotalWords: 4, TopWords: []WordCount{{"robot", 3}, {"laptop", 1}}},
+		{Version: "bbb", Articles: 1, TotalWords: 3, TopWords: []WordCount{{"laptop", 2}, {"robot", 1}}},
+	}
+	if got := agg.GetVersions(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected versions %+v, got %+v", want, got)
+	}
+	if _, total, _, _ := agg.GetStats(); total != 7 {
+		t.Errorf("Expected 7 words in total, got %d", total)
+	}
+
+	// Versions survive a snapshot round trip
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetVersions(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected merged versions %+v, got %+v", want, got)
+	}
+
+	if got := New(nil).GetVersions(10); got != nil {
+		t.Errorf("Expected no versions unless kept, got %+v", got)
+	}
+}
+
+func TestAggregator_Replace(t *testing.T) {
+	agg := New(nil)
+	agg.RetainArticles()
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"robot": 2, "tech": 1},
+		Variants:   map[string]map[string]int{"robot": {"robots": 1}},
+		WordBank:   "aaa",
+	})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"robot": 1}, WordBank: "aaa"})
+
+	// The new wordbank credits tech to technology and drops the variant
+	replaced := agg.Replace(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"robot": 2, "technology": 1},
+		WordBank:   "bbb",
+	})
+	if !replaced {
+		t.Fatal("Expected the article to be replaced")
+	}
+
+	want := []WordCount{{"robot", 3}, {"technology", 1}}
+	if got := agg.GetTopWords(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected top words %+v, got %+v", want, got)
+	}
+	if processed, total, unique, _ := agg.GetStats(); processed != 2 || total != 4 || unique != 2 {
+		t.Errorf("Expected 2 articles, 4 words and 2 unique words, got %d, %d and %d", processed, total, unique)
+	}
+	if df := agg.GetDocumentFrequency("tech"); df != 0 {
+		t.Errorf("Expected tech to leave the document frequencies, got %d", df)
+	}
+	if got := agg.GetVariants(agg.GetTopWords(10)); got != nil {
+		t.Errorf("Expected no variants after the recount, got %+v", got)
+	}
+	if got := agg.GetArticles()[0].WordBank; got != "bbb" {
+		t.Errorf("Expected the retained article to be replaced, got version %q", got)
+	}
+
+	if agg.Replace(ProcessingResult{URL: "https://example.com/3"}) {
+		t.Error("Expected no replacement for an article never added")
+	}
+}
+
+func TestAggregator_ReplaceCategories(t *testing.T) {
+	agg := New(nil)
+	agg.RetainArticles()
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"robot": 2, "tech": 1},
+		Categories: map[string]string{"robot": "hardware", "tech": "gadgets"},
+	})
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/2",
+		WordCounts: map[string]int{"robot": 1},
+		Categories: map[string]string{"robot": "hardware"},
+	})
+
+	// The new wordbank no longer has tech, so its category goes with its last count
+	if !agg.Replace(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"robot": 2, "technology": 1},
+		Categories: map[string]string{"robot": "hardware", "technology": "science"},
+	}) {
+		t.Fatal("Expected the article to be replaced")
+	}
+
+	want := []CategoryCount{
+		{Category: "hardware", Count: 3, Share: 0.75, TopWords: []WordCount{{"robot", 3}}},
+		{Category: "science", Count: 1, Share: 0.25, TopWords: []WordCount{{"technology", 1}}},
+	}
+	if got := agg.GetCategories(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected categories %+v, got %+v", want, got)
+	}
+}
+
+func TestAggregator_GetLanguages(t *testing.T) {
+	agg := New(nil)
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"robot": 2}, Language: "en"})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"robot": 1, "señal": 3}, Language: "es"})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/3", WordCounts: map[string]int{"señal": 1}, Language: "es"})
+	agg.AddSkipped("unsupported", "de")
+	agg.AddSkipped("unsupported", "de")
+	agg.AddSkipped("undetermined", "")
+
+	wantLanguages := []LanguageCount{
+		{Language: "es", Articles: 2, TotalWords: 5, TopWords: []WordCount{{"señal", 4}, {"robot", 1}}},
+		{Language: "en", Articles: 1, TotalWords: 2, TopWords: []WordCount{{"robot", 2}}},
+	}
+	wantSkipped := []SkipCount{
+		{Reason: "unsupported", Language: "de", Articles: 2},
+		{Reason: "undetermined", Articles: 1},
+	}
+	if got := agg.GetLanguages(10); !reflect.DeepEqual(got, wantLanguages) {
+		t.Errorf("Expected languages %+v, got %+v", wantLanguages, got)
+	}
+	if got := agg.GetSkipped(); !reflect.DeepEqual(got, wantSkipped) {
+		t.Errorf("Expected skipped %+v, got %+v", wantSkipped, got)
+	}
+	if processed, _, _, _ := agg.GetStats(); processed != 3 {
+		t.Errorf("Expected skipped articles not to count as processed, got %d", processed)
+	}
+
+	// Languages and skips survive a snapshot round trip
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetLanguages(10); !reflect.DeepEqual(got, wantLanguages) {
+		t.Errorf("Expected merged languages %+v, got %+v", wantLanguages, got)
+	}
+	if got := merged.GetSkipped(); !reflect.DeepEqual(got, wantSkipped) {
+		t.Errorf("Expected merged skipped %+v, got %+v", wantSkipped, got)
+	}
+
+	if got := New(nil).GetLanguages(10); got != nil {
+		t.Errorf("Expected no languages when none were detected, got %+v", got)
+	}
+}
+
+func TestAggregator_GetNearDuplicates(t *testing.T) {
+	// Two shards each skipped near duplicates of the same article
+	first, second := New(nil), New(nil)
+	first.AddNearDuplicate("https://example.com/b", simhash.Duplicate{URL: "https://example.com/b-copy", Distance: 2})
+	first.AddNearDuplicate("https://example.com/a", simhash.Duplicate{URL: "https://example.com/a-copy", Distance: 1})
+	second.AddNearDuplicate("https://example.com/a", simhash.Duplicate{URL: "https://example.com/a-amp", Distance: 3})
+
+	merged := New(nil)
+	for _, shard := range []*Aggregator{first, second} {
+		if err := merged.Merge(shard.Snapshot()); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+
+	want := []simhash.Cluster{
+		{URL: "https://example.com/a", Duplicates: []simhash.Duplicate{{URL: "https://example.com/a-amp", Distance: 3}, {URL: "https://example.com/a-copy", Distance: 1}}},
+		{URL: "https://example.com/b", Duplicates: []simhash.Duplicate{{URL: "https://example.com/b-copy", Distance: 2}}},
+	}
+	if got := merged.GetNearDuplicates(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected merged clusters %+v, got %+v", want, got)
+	}
+	if got := New(nil).GetNearDuplicates(); got != nil {
+		t.Errorf("Expected no clusters without near duplicates, got %+v", got)
+	}
+}
+
+func TestAggregator_GetStatistics(t *testing.T) {
+	agg := New(nil)
+	agg.AddResult(ProcessingResult{URL: "https://example.com/0", WordCounts: map[string]int{"robot": 1}})
+	if got := agg.GetStatistics(); got != nil {
+		t.Errorf("Expected no statistics when no article was measured, got %+v", got)
+	}
+
+	first := textstats.NewArticle("Robots build robots. Laptops too.", map[string]int{"robots": 2, "build": 1, "laptops": 1, "too": 1})
+	second := textstats.NewArticle("Laptops are light.", map[string]int{"laptops": 1, "are": 1, "light": 1})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"robot": 2, "laptop": 1}, Statistics: &first})
+	agg.AddResult(ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"laptop": 1, "light": 1}, Statistics: &second})
+
+	stats := agg.GetStatistics()
+	if stats == nil {
+		t.Fatal("Expected statistics")
+	}
+	if stats.ArticleMeans.Articles != 2 || stats.Corpus.Sentences != 3 || stats.Corpus.Words != 8 {
+		t.Errorf("Expected 2 articles of 3 sentences and 8 words, got %+v", stats)
+	}
+	// The vocabulary spans every token of the measured articles, whatever the wordbank counted
+	if stats.Corpus.Tokens != 8 || stats.Corpus.Types != 6 || stats.Corpus.HapaxLegomena != 4 {
+		t.Errorf("Expected 8 tokens, 6 types and 4 hapax, got %+v", stats.Corpus)
+	}
+
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetStatistics(); !reflect.DeepEqual(got, stats) {
+		t.Errorf("Expected merged statistics %+v, got %+v", stats, got)
+	}
+}
+
+func TestAggregator_GetConcordance(t *testing.T) {
+	if got := New(nil).GetConcordance(10); got != nil {
+		t.Errorf("Expected no concordance unless contexts are kept, got %+v", got)
+	}
+
+	sample := func(key uint64, match string) concordance.Sample {
+		return concordance.Sample{Key: key, Context: concordance.Context{Left: "the", Match: match, Right: "works"}}
+	}
+	agg := New(nil)
+	agg.KeepContexts(2, nil, 10)
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/1",
+		WordCounts: map[string]int{"robot": 2, "laptop": 1},
+		Contexts: map[string]concordance.Reservoir{
+			"robot":  {sample(30, "robot"), sample(50, "Robot")},
+			"laptop": {sample(20, "laptop")},
+		},
+	})
+	agg.AddResult(ProcessingResult{
+		URL:        "https://example.com/2",
+		WordCounts: map[string]int{"robot": 1},
+		Contexts:   map[string]concordance.Reservoir{"robot": {sample(10, "robots")}},
+	})
+
+	want := []concordance.Entry{
+		{Word: "robot", Contexts: []concordance.Context{
+			{Left: "the", Match: "robots", Right: "works", URL: "https://example.com/2"},
+			{Left: "the", Match: "robot", Right: "works", URL: "https://example.com/1"},
+		}},
+		{Word: "laptop", Contexts: []concordance.Context{
+			{Left: "the", Match: "laptop", Right: "works", URL: "https://example.com/1"},
+		}},
+	}
+	if got := agg.GetConcordance(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected concordance %+v, got %+v", want, got)
+	}
+	if got := agg.GetConcordance(1); len(got) != 1 || got[0].Word != "robot" {
+		t.Errorf("Expected the concordance of the top word, got %+v", got)
+	}
+
+	merged := New(nil)
+	if err := merged.Merge(agg.Snapshot()); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if got := merged.GetConcordance(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected merged concordance %+v, got %+v", want, got)
+	}
+
+	// Only the words asked for are reported
+	query := New(nil)
+	query.KeepContexts(2, []string{"laptop", "tablet"}, 10)
+	query.AddResult(ProcessingResult{URL: "https://example.com/3", WordCounts: map[string]int{"laptop": 1},
+		Contexts: map[string]concordance.Reservoir{"laptop": {sample(5, "laptop")}}})
+	if got := query.GetConcordance(10); len(got) != 1 || got[0].Word != "laptop" {
+		t.Errorf("Expected the concordance of laptop alone, got %+v", got)
+	}
+}
+
+func TestAggregator_ContextCandidates(t *testing.T) {
+	sample := func(key uint64) concordance.Reservoir {
+		return concordance.Reservoir{{Key: key, Context: concordance.Context{Match: "word"}}}
+	}
+	add := func(agg *Aggregator, url string, counts map[string]int) {
+		contexts := make(map[string]concordance.Reservoir)
+		for word := range counts {
+			contexts[word] = sample(uint64(len(url)))
+		}
+		agg.AddResult(ProcessingResult{URL: url, WordCounts: counts, Contexts: contexts})
+	}
+
+	// With the top word asked for, contexts are kept for at most 4 words
+	agg := New(nil)
+	agg.KeepContexts(1, nil, 1)
+	add(agg, "https://example.com/1", map[string]int{"a": 5, "b": 4, "c": 3, "d": 2})
+	add(agg, "https://example.com/2", map[string]int{"e": 1})
+	add(agg, "https://example.com/33", map[string]int{"f": 9})
+
+	snapshot := agg.Snapshot()
+	if len(snapshot.Contexts) != 4 || snapshot.Contexts["e"] != nil || snapshot.Contexts["d"] != nil {
+		t.Errorf("Expected contexts of a, b, c and f alone, got %v", snapshot.Contexts)
+	}
+	if got := agg.GetConcordance(1); len(got) != 1 || got[0].Word != "f" {
+		t.Errorf("Expected the concordance of f, which displaced d, got %+v", got)
+	}
+
+	// Merged shards keep no more candidates than one would
+	other := New(nil)
+	other.KeepContexts(1, nil, 1)
+	add(other, "https://example.com/4", map[string]int{"g": 20, "h": 19})
+	merged := New(nil)
+	for _, shard := range []*Aggregator{agg, other} {
+		if err := merged.Merge(shard.Snapshot()); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+	if got := merged.Snapshot().Contexts; len(got) != 4 || got["b"] != nil || got["c"] != nil {
+		t.Errorf("Expected contexts of g, h, f and a after merging, got %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package aggregator
+
+import "sort"
+
+// Tally is the state kept for one group of articles, such as those counted with
+// one wordbank version or in one language
+type Tally struct {
+	Articles   int            `json:"articles"`
+	TotalWords int            `json:"total_words"`
+	WordCounts map[string]int `json:"word_counts"`
+}
+
+// tallies keeps a Tally for each group in the order first seen
+type tallies struct {
+	byName map[string]*Tally
+	order  []string
+}
+
+// get returns the tally of a group, creating it when first seen
+func (t *tallies) get(name string) *Tally {
+	if t.byName == nil {
+		t.byName = make(map[string]*Tally)
+	}
+	tally, ok := t.byName[name]
+	if !ok {
+		tally = &Tally{WordCounts: make(map[string]int)}
+		t.byName[name] = tally
+		t.order = append(t.order, name)
+	}
+	return tally
+}
+
+// count adds an article's word counts to a group, or removes them when sign is -1
+func (t *tallies) count(name string, wordCounts map[string]int, sign int) {
+	tally := t.get(name)
+	tally.Articles += sign
+	for word, count := range wordCounts {
+		tally.TotalWords += sign * count
+		addCount(tally.WordCounts, word, sign*count)
+	}
+}
+
+// snapshot returns a copy of every tally, or nil when there are none
+func (t *tallies) snapshot() map[string]*Tally {
+	if len(t.byName) == 0 {
+		return nil
+	}
+	copies := make(map[string]*Tally, len(t.byName))
+	for name, tally := range t.byName {
+		copied := &Tally{Articles: tally.Articles, TotalWords: tally.TotalWords, WordCounts: make(map[string]int, len(tally.WordCounts))}
+		for word, count := range tally.WordCounts {
+			copied.WordCounts[word] = count
+		}
+		copies[name] = copied
+	}
+	return copies
+}
+
+// merge adds tallies from a snapshot, taking groups not seen before in name order
+func (t *tallies) merge(merged map[string]*Tally) {
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tally := t.get(name)
+		tally.Articles += merged[name].Articles
+		tally.TotalWords += merged[name].TotalWords
+		for word, count := range merged[name].WordCounts {
+			tally.WordCounts[word] += count
+		}
+	}
+}
+
+// each calls fn with each group that has articles, in the order first seen, and
+// its top n words
+func (t *tallies) each(n int, fn func(name string, tally *Tally, top []WordCount)) {
+	for _, name := range t.order {
+		tally := t.byName[name]
+		if tally.Articles == 0 {
+			continue
+		}
+		words := make([]WordCount, 0, len(tally.WordCounts))
+		for word, count := range tally.WordCounts {
+			words = append(words, WordCount{Word: word, Count: count})
+		}
+		fn(name, tally, topWords(words, n))
+	}
+}
@@ -0,0 +1,111 @@
+package concordance
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultWidth is how many tokens either side of a word a context shows by default
+	DefaultWidth = 5
+)
+
+// Context is one occurrence of a word with the text around it
+type Context struct {
+	Left  string `json:"left"`
+	Match string `json:"match"` // The word as it appears in the text
+	Right string `json:"right"`
+	URL   string `json:"url"`
+}
+
+// Sample is a context with the random key that decides whether it is kept
+type Sample struct {
+	Key uint64 `json:"key"`
+	Context
+}
+
+// NewSample returns the context of the tokens spans[start:end] of text, with up to
+// width tokens either side. Its key is a hash of the text around the match and the
+// match's offset, so the same occurrence is always sampled alike.
+func NewSample(text string, spans [][]int, start, end, width int) Sample {
+	from := spans[max(start-width, 0)][0]
+	to := spans[min(end+width, len(spans))-1][1]
+	matchStart, matchEnd := spans[start][0], spans[end-1][1]
+
+	h := fnv.New64a()
+	h.Write([]byte(text[from:to]))
+	h.Write([]byte(strconv.Itoa(matchStart)))
+
+	return Sample{
+		Key: h.Sum64(),
+		Context: Context{
+			Left:  collapse(text[from:matchStart]),
+			Match: collapse(text[matchStart:matchEnd]),
+			Right: collapse(text[matchEnd:to]),
+		},
+	}
+}
+
+// collapse joins the words of s with single spaces
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Reservoir keeps the samples with the smallest keys, up to a limit. Since keys
+// are random, these are a uniform sample of every occurrence offered, and
+// reservoirs filled from separate articles or shards merge into the same sample
+// as one filled from all of them.
+type Reservoir []Sample
+
+// Offer adds a sample when it is among the k with the smallest keys. A sample
+// whose key is already kept is ignored, so offering an occurrence again does not
+// keep it twice.
+func (r *Reservoir) Offer(sample Sample, k int) {
+	samples := *r
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Key >= sample.Key })
+	if i >= k || (i < len(samples) && samples[i].Key == sample.Key) {
+		return
+	}
+	if len(samples) < k {
+		samples = append(samples, Sample{})
+	}
+	copy(samples[i+1:], samples[i:])
+	samples[i] = sample
+	*r = samples
+}
+
+// Merge offers every sample of other
+func (r *Reservoir) Merge(other Reservoir, k int) {
+	for _, sample := range other {
+		r.Offer(sample, k)
+	}
+}
+
+// Contexts returns the kept contexts, in the order of their keys
+func (r Reservoir) Contexts() []Context {
+	contexts := make([]Context, len(r))
+	for i, sample := range r {
+		contexts[i] = sample.Context
+	}
+	return contexts
+}
+
+// Entry lists the contexts sampled for one word
+type Entry struct {
+	Word     string    `json:"word"`
+	Contexts []Context `json:"contexts"`
+}
+
+// ParseWords returns the lowercase words and phrases in a comma-separated list,
+// with the words of each phrase separated by single spaces
+func ParseWords(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		if word = strings.Join(strings.Fields(strings.ToLower(word)), " "); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
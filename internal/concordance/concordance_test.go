@@ -0,0 +1,70 @@
+package concordance
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestNewSample(t *testing.T) {
+	text := "The new laptop has a faster\n processor, and the battery lasts all day."
+	spans := regexp.MustCompile(`[a-zA-Z]+`).FindAllStringIndex(text, -1)
+
+	sample := NewSample(text, spans, 6, 7, 2) // "processor"
+	want := Context{Left: "a faster", Match: "processor", Right: ", and the"}
+	if sample.Context != want {
+		t.Errorf("Expected %+v, got %+v", want, sample.Context)
+	}
+
+	// A context is cut short at the ends of the text
+	sample = NewSample(text, spans, 0, 2, 3) // "The new"
+	want = Context{Left: "", Match: "The new", Right: "laptop has a"}
+	if sample.Context != want {
+		t.Errorf("Expected %+v, got %+v", want, sample.Context)
+	}
+
+	if NewSample(text, spans, 6, 7, 2).Key != NewSample(text, spans, 6, 7, 2).Key {
+		t.Error("Expected the same occurrence to get the same key")
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	var r Reservoir
+	for _, key := range []uint64{50, 10, 40, 30, 10, 20, 60} {
+		r.Offer(Sample{Key: key}, 3)
+	}
+	var keys []uint64
+	for _, sample := range r {
+		keys = append(keys, sample.Key)
+	}
+	if !reflect.DeepEqual(keys, []uint64{10, 20, 30}) {
+		t.Errorf("Expected the 3 smallest keys once each, got %v", keys)
+	}
+
+	// Merging reservoirs filled separately keeps the same samples
+	var first, second, merged Reservoir
+	for key := uint64(1); key <= 20; key++ {
+		if key%2 == 0 {
+			first.Offer(Sample{Key: key * 7 % 23}, 4)
+		} else {
+			second.Offer(Sample{Key: key * 7 % 23}, 4)
+		}
+	}
+	merged.Merge(first, 4)
+	merged.Merge(second, 4)
+	var all Reservoir
+	for key := uint64(1); key <= 20; key++ {
+		all.Offer(Sample{Key: key * 7 % 23}, 4)
+	}
+	if !reflect.DeepEqual(merged, all) {
+		t.Errorf("Expected merged reservoirs %v, got %v", all, merged)
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	got := ParseWords(" Privacy, virtual   Reality,,tracking ")
+	want := []string{"privacy", "virtual reality", "tracking"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -3,35 +3,130 @@ package config
 import (
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/langid"
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/stopwords"
+	"github.com/firefly/essay-analyzer/internal/urlnorm"
 )
 
 // Config holds all configuration for the essay analyzer
 type Config struct {
-	URLsFile     string
-	WordBankFile string
-	Verbose      bool
-	Workers      int
-	RateLimit    float64 // 0 means no limit (unless robots.txt specifies crawl-delay)
+	URLsFile         string        `json:"urls_file"`
+	WordBankFile     string        `json:"wordbank_file"`
+	Verbose          bool          `json:"verbose"`    // Shorthand for --log-level=debug
+	LogLevel         string        `json:"log_level"`  // Minimum level logged: debug, info, warn or error
+	LogFormat        string        `json:"log_format"` // Log record format: text or json
+	Workers          int           `json:"workers"`
+	Fetchers         int           `json:"fetchers,omitempty"`   // Fetcher workers (0 = share of Workers)
+	Parsers          int           `json:"parsers,omitempty"`    // Parser workers (0 = share of Workers)
+	Processors       int           `json:"processors,omitempty"` // Processor workers (0 = share of Workers)
+	AutoTune         bool          `json:"auto_tune"`            // Move workers to the bottleneck stage during the run
+	RateLimit        float64       `json:"rate_limit"`           // 0 means no limit (unless robots.txt specifies crawl-delay)
+	Format           string        `json:"format"`               // Output format (json, ndjson, csv, tsv, markdown, sqlite)
+	Output           string        `json:"output"`               // Output path ("" or "-" means stdout)
+	Export           string        `json:"export"`               // Path for the full aggregator export ("" = none)
+	MetricsAddr      string        `json:"metrics_addr"`         // Address to serve Prometheus metrics on ("" = disabled)
+	Progress         bool          `json:"progress"`             // Show live progress on stderr (off when logging at debug)
+	TraceFile        string        `json:"trace_file"`           // Path for per-URL traces as OTLP/JSON lines ("" = disabled)
+	MaxBodyBytes     int64         `json:"max_body_bytes"`       // Largest page read (0 = unlimited)
+	RequestTimeout   time.Duration `json:"request_timeout"`      // Whole request including the body (0 = none)
+	DialTimeout      time.Duration `json:"dial_timeout"`         // TCP connect (0 = none)
+	TLSTimeout       time.Duration `json:"tls_timeout"`          // TLS handshake (0 = none)
+	HeaderTimeout    time.Duration `json:"header_timeout"`       // Waiting for response headers (0 = none)
+	IdleTimeout      time.Duration `json:"idle_timeout"`         // Idle keep-alive connections (0 = forever)
+	Dedup            bool          `json:"dedup"`                // Drop URLs that normalize to one already queued
+	StripParams      string        `json:"strip_params"`         // Comma-separated query parameters removed during normalization
+	NearDupThreshold int           `json:"near_dup_threshold"`   // Largest SimHash distance skipped as a near duplicate (negative = disabled)
+	MaxRedirects     int           `json:"max_redirects"`        // Redirects followed per fetch (0 = none)
+	WordBankFormat   string        `json:"wordbank_format"`      // auto, text, csv, tsv, json or compiled
+	WordBankBackend  string        `json:"wordbank_backend"`     // map or sorted
+	WordBankCache    string        `json:"wordbank_cache"`       // Directory caching http(s) wordbanks ("" = no cache)
+	WordBankCacheTTL time.Duration `json:"wordbank_cache_ttl"`   // How long a cached wordbank is used without fetching it
+	WordBankMaxBytes int64         `json:"wordbank_max_bytes"`   // Largest http(s) wordbank downloaded (0 = unlimited)
+	WordBankReload   bool          `json:"wordbank_reload"`      // Reload the wordbank on SIGHUP during the run
+	WordBankPoll     time.Duration `json:"wordbank_poll"`        // How often to check the wordbank for changes and reload it (0 = never)
+	WordBankRecount  bool          `json:"wordbank_recount"`     // Recount articles counted with an earlier wordbank at the end of the run
+	Stopwords        string        `json:"stopwords"`            // Built-in stopword list: english or none ("" = english without a wordbank, none with one)
+	StopwordsFile    string        `json:"stopwords_file"`       // Extra stopwords, one per line ("" = none)
+	WordPattern      string        `json:"word_pattern"`         // Regex every counted word must match
+	MinWordLength    int           `json:"min_word_length"`      // Shortest word counted, in characters
+	CountPhraseWords bool          `json:"count_phrase_words"`   // Count a phrase's words on their own as well as the phrase
+	FuzzyDistance    int           `json:"fuzzy_distance"`       // Edits within which a word outside the wordbank is credited to the closest word (0 = off)
+	Languages        string        `json:"languages"`            // Comma-separated languages counted, the first with the wordbank ("" = no detection)
+	LanguageBanks    string        `json:"language_wordbanks"`   // Comma-separated code=path wordbanks for the other languages
+	Statistics       bool          `json:"statistics"`           // Report vocabulary and readability statistics
+	Concordance      int           `json:"concordance"`          // Sample contexts kept per word (0 = none)
+	ConcordanceWidth int           `json:"concordance_width"`    // Tokens either side of a word in each context
+	ConcordanceWords string        `json:"concordance_words"`    // Comma-separated words sampled ("" = the top words)
+	ConcordanceFile  string        `json:"concordance_file"`     // Path for the concordance as JSON Lines ("" = none)
 }
 
 // WordFilterConfig holds word filtering configuration
 type WordFilterConfig struct {
-	// Pattern for valid words (3+ chars, alphabetic only)
+	// Pattern for valid words (alphabetic only by default)
 	Pattern *regexp.Regexp
+	// MinLength is the shortest valid word in characters
+	MinLength int
+}
+
+// Match reports whether word passes the filter
+func (f *WordFilterConfig) Match(word string) bool {
+	return utf8.RuneCountInString(word) >= f.MinLength && f.Pattern.MatchString(word)
 }
 
-// GetWordFilterConfig returns the word filtering configuration
+// GetWordFilterConfig returns the default word filtering configuration
 func GetWordFilterConfig() *WordFilterConfig {
 	return &WordFilterConfig{
-		Pattern: regexp.MustCompile(`^[a-zA-Z]{3,}$`),
+		Pattern:   regexp.MustCompile(DefaultWordPattern),
+		MinLength: DefaultMinWordLength,
+	}
+}
+
+// WordFilter returns the word filtering configuration described by the flags
+func (c *Config) WordFilter() (*WordFilterConfig, error) {
+	pattern := c.WordPattern
+	if pattern == "" {
+		pattern = DefaultWordPattern
 	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --word-pattern: %w", err)
+	}
+	return &WordFilterConfig{Pattern: re, MinLength: c.MinWordLength}, nil
 }
 
 const (
+	// DefaultWordPattern matches the words counted by default: letters only
+	DefaultWordPattern = `^[a-zA-Z]+$`
+
+	// LetterWordPattern matches words of letters in any script, used in place of
+	// DefaultWordPattern for languages other than English
+	LetterWordPattern = `^\p{L}+$`
+
+	// DefaultMinWordLength is the shortest word counted by default
+	DefaultMinWordLength = 3
+
+	// MaxFuzzyDistance is the largest edit distance fuzzy matching allows; beyond it
+	// most words are close to something
+	MaxFuzzyDistance = 2
+
 	// DefaultTopWords is the default number of top words to return
 	DefaultTopWords = 10
+
+	// DefaultWordBankCacheTTL is how long a fetched wordbank is used before it is fetched again
+	DefaultWordBankCacheTTL = 24 * time.Hour
 )
 
 // GetTopWordsCount returns the number of top words to include in results
@@ -44,10 +139,25 @@ func ParseFlags() (*Config, error) {
 	config := &Config{}
 
 	flag.StringVar(&config.URLsFile, "urls-file", "", "Path to file containing URLs (required)")
-	flag.StringVar(&config.WordBankFile, "wordbank-file", "", "Path to word bank file (required)")
-	flag.BoolVar(&config.Verbose, "verbose", false, "Enable verbose logging")
-	flag.IntVar(&config.Workers, "workers", 50, "Number of concurrent workers")
+	flag.StringVar(&config.WordBankFile, "wordbank-file", "", "Path or http(s) URL of word bank file (default: count every word)")
+	RegisterWordBankFlags(flag.CommandLine, config)
+	RegisterReloadFlags(flag.CommandLine, config)
+	RegisterWordFilterFlags(flag.CommandLine, config)
+	RegisterLanguageFlags(flag.CommandLine, config)
+	RegisterAnalysisFlags(flag.CommandLine, config)
+	RegisterWorkerFlags(flag.CommandLine, config)
+	RegisterFetchFlags(flag.CommandLine, config)
+	RegisterDedupFlags(flag.CommandLine, config)
+	RegisterNearDupFlags(flag.CommandLine, config)
 	flag.Float64Var(&config.RateLimit, "rate-limit", 0, "Requests per second (0 = no limit unless robots.txt specifies)")
+	flag.StringVar(&config.Format, "format", "json", "Output format: json, ndjson, csv, tsv, markdown or sqlite")
+	flag.StringVar(&config.Output, "output", "", "Output file (default stdout; required for sqlite)")
+	flag.StringVar(&config.Export, "export", "", "Write every word count and document frequency to this file for merging")
+	flag.StringVar(&config.ConcordanceFile, "concordance-file", "", "Write the concordance to this file as JSON Lines, one context per line")
+	flag.BoolVar(&config.Progress, "progress", true, "Show live progress on stderr (use --progress=false to disable; off when logging at debug)")
+	flag.StringVar(&config.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	flag.StringVar(&config.TraceFile, "trace-file", "", "Write a trace of every URL through the pipeline to this file as OTLP/JSON lines")
+	RegisterLogFlags(flag.CommandLine, config)
 
 	flag.Parse()
 
@@ -55,12 +165,32 @@ func ParseFlags() (*Config, error) {
 		return nil, fmt.Errorf("--urls-file is required")
 	}
 
-	if config.WordBankFile == "" {
-		return nil, fmt.Errorf("--wordbank-file is required")
+	if err := config.ValidateWordFilter(); err != nil {
+		return nil, err
+	}
+
+	if err := config.ValidateLanguages(); err != nil {
+		return nil, err
+	}
+
+	if err := config.ValidateAnalysis(); err != nil {
+		return nil, err
 	}
 
-	if config.Workers <= 0 {
-		return nil, fmt.Errorf("--workers must be positive")
+	if err := config.ValidateWorkers(); err != nil {
+		return nil, err
+	}
+
+	if err := config.ValidateReload(); err != nil {
+		return nil, err
+	}
+
+	if err := config.ValidateFetch(); err != nil {
+		return nil, err
+	}
+
+	if err := config.ValidateNearDup(); err != nil {
+		return nil, err
 	}
 
 	if config.RateLimit < 0 {
@@ -70,12 +200,348 @@ func ParseFlags() (*Config, error) {
 	return config, nil
 }
 
+// RegisterWordBankFlags adds the flags that control how the wordbank is read to flags
+func RegisterWordBankFlags(flags *flag.FlagSet, config *Config) {
+	flags.StringVar(&config.WordBankFormat, "wordbank-format", "auto", "Word bank format: auto, text, csv, tsv, json or compiled (auto detects from the extension or content)")
+	flags.StringVar(&config.WordBankBackend, "wordbank-backend", "map", "Word bank storage: map (fastest lookups) or sorted (least memory); compiled word banks are always sorted")
+	flags.StringVar(&config.WordBankCache, "wordbank-cache", DefaultWordBankCacheDir(), "Directory caching word banks fetched over http(s) (empty disables the cache)")
+	flags.DurationVar(&config.WordBankCacheTTL, "wordbank-cache-ttl", DefaultWordBankCacheTTL, "How long a cached word bank is used before fetching it again")
+	flags.Int64Var(&config.WordBankMaxBytes, "wordbank-max-bytes", 0, "Fail word banks fetched over http(s) larger than this many bytes (0 = unlimited); --max-body-bytes does not apply to them")
+}
+
+// RegisterReloadFlags adds the flags that reload the wordbank during a run to flags
+func RegisterReloadFlags(flags *flag.FlagSet, config *Config) {
+	flags.BoolVar(&config.WordBankReload, "wordbank-reload", false, "Reload the word bank when the process receives SIGHUP")
+	flags.DurationVar(&config.WordBankPoll, "wordbank-poll", 0, "Check the word bank file for changes this often and reload it (0 = never)")
+	flags.BoolVar(&config.WordBankRecount, "wordbank-recount", false, "Keep article text and recount articles counted with an earlier word bank at the end, instead of reporting counts per word bank version")
+}
+
+// Reloads reports whether the wordbank may be reloaded during a run
+func (c *Config) Reloads() bool {
+	return c.WordBankReload || c.WordBankPoll > 0
+}
+
+// ValidateReload checks the wordbank reload settings
+func (c *Config) ValidateReload() error {
+	if c.WordBankPoll < 0 {
+		return fmt.Errorf("--wordbank-poll must be non-negative (0 = never)")
+	}
+	if c.WordBankRecount && !c.Reloads() {
+		return fmt.Errorf("--wordbank-recount needs --wordbank-reload or --wordbank-poll")
+	}
+	return nil
+}
+
+// RegisterWordFilterFlags adds the flags that decide which words are counted to flags
+func RegisterWordFilterFlags(flags *flag.FlagSet, config *Config) {
+	flags.StringVar(&config.Stopwords, "stopwords", "", "Built-in stopword list never counted: english or none (default english without --wordbank-file, none with one)")
+	flags.StringVar(&config.StopwordsFile, "stopwords-file", "", "File of extra stopwords, one per line")
+	flags.StringVar(&config.WordPattern, "word-pattern", DefaultWordPattern, "Regular expression every counted word must match")
+	flags.IntVar(&config.MinWordLength, "min-word-length", DefaultMinWordLength, "Shortest word counted, in characters")
+	flags.BoolVar(&config.CountPhraseWords, "count-phrase-words", false, "Count each word of a matched wordbank phrase on its own as well as the phrase")
+	flags.IntVar(&config.FuzzyDistance, "fuzzy-distance", 0, "Credit a word outside the wordbank to the single closest word within this many edits (0 disables, at most 2)")
+}
+
+// ValidateWordFilter checks the word filter flags
+func (c *Config) ValidateWordFilter() error {
+	if _, err := stopwords.Builtin(c.Stopwords); err != nil {
+		return fmt.Errorf("invalid --stopwords: %w", err)
+	}
+	if c.MinWordLength < 0 {
+		return fmt.Errorf("--min-word-length must be non-negative")
+	}
+	if c.FuzzyDistance < 0 || c.FuzzyDistance > MaxFuzzyDistance {
+		return fmt.Errorf("--fuzzy-distance must be between 0 and %d", MaxFuzzyDistance)
+	}
+	_, err := c.WordFilter()
+	return err
+}
+
+// StopwordList returns the built-in stopword list in force. Unless one is named,
+// a wordbank is used as listed and counting every word leaves out English stopwords.
+func (c *Config) StopwordList() string {
+	switch {
+	case c.Stopwords != "":
+		return c.Stopwords
+	case c.WordBankFile != "":
+		return stopwords.None
+	default:
+		return stopwords.English
+	}
+}
+
+// LoadStopwords returns the built-in stopword list plus any in the stopwords file
+func (c *Config) LoadStopwords() (*stopwords.Set, error) {
+	set, err := stopwords.Builtin(c.StopwordList())
+	if err != nil {
+		return nil, fmt.Errorf("invalid --stopwords: %w", err)
+	}
+	if c.StopwordsFile != "" {
+		if err := set.Load(c.StopwordsFile); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// RegisterLanguageFlags adds the flags that identify each article's language and
+// choose how it is counted to flags
+func RegisterLanguageFlags(flags *flag.FlagSet, config *Config) {
+	flags.StringVar(&config.Languages, "languages", "",
+		"Comma-separated ISO 639-1 languages to count, identifying each article's language and skipping others; the first is counted with --wordbank-file (empty disables detection)")
+	flags.StringVar(&config.LanguageBanks, "language-wordbanks", "",
+		"Comma-separated code=path word banks for the other --languages (default: count every word)")
+}
+
+// DetectsLanguages reports whether each article's language is identified
+func (c *Config) DetectsLanguages() bool {
+	return len(c.LanguageList()) > 0
+}
+
+// LanguageList returns the languages to count, the main language first
+func (c *Config) LanguageList() []string {
+	var languages []string
+	for _, code := range strings.Split(c.Languages, ",") {
+		if code = strings.ToLower(strings.TrimSpace(code)); code != "" {
+			languages = append(languages, code)
+		}
+	}
+	return languages
+}
+
+// LanguageWordBanks returns the wordbank given for each language other than the
+// main one
+func (c *Config) LanguageWordBanks() (map[string]string, error) {
+	banks := make(map[string]string)
+	for _, pair := range strings.Split(c.LanguageBanks, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		code, path, ok := strings.Cut(pair, "=")
+		code, path = strings.ToLower(strings.TrimSpace(code)), strings.TrimSpace(path)
+		if !ok || code == "" || path == "" {
+			return nil, fmt.Errorf("invalid --language-wordbanks entry %q (want code=path)", pair)
+		}
+		banks[code] = path
+	}
+	return banks, nil
+}
+
+// ValidateLanguages checks that every language can be identified and each
+// language wordbank is for one of them
+func (c *Config) ValidateLanguages() error {
+	languages := c.LanguageList()
+	banks, err := c.LanguageWordBanks()
+	if err != nil {
+		return err
+	}
+	if len(languages) == 0 {
+		if len(banks) > 0 {
+			return fmt.Errorf("--language-wordbanks needs --languages")
+		}
+		return nil
+	}
+
+	id, err := langid.New()
+	if err != nil {
+		return err
+	}
+	counted := make(map[string]bool)
+	for _, code := range languages {
+		if !id.Supported(code) {
+			return fmt.Errorf("invalid --languages: cannot identify %q (want %s)", code, strings.Join(id.Languages(), ", "))
+		}
+		counted[code] = true
+	}
+	for code := range banks {
+		if code == languages[0] {
+			return fmt.Errorf("--language-wordbanks: %s is the main language, counted with --wordbank-file", code)
+		}
+		if !counted[code] {
+			return fmt.Errorf("--language-wordbanks: %s is not in --languages", code)
+		}
+	}
+	return nil
+}
+
+// ForLanguage returns the configuration that counts articles in a language: the
+// main language keeps the wordbank file, the others use theirs from
+// --language-wordbanks or count every word. Each uses the built-in stopwords of its
+// language unless its stopword list is none, and accented letters unless the word pattern
+// was changed.
+func (c *Config) ForLanguage(code string) *Config {
+	lc := *c
+	if languages := c.LanguageList(); len(languages) == 0 || code != languages[0] {
+		banks, _ := c.LanguageWordBanks() // Checked by ValidateLanguages
+		lc.WordBankFile = banks[code]
+		lc.WordBankReload, lc.WordBankPoll, lc.WordBankRecount = false, 0, false
+	}
+	if lc.StopwordList() != stopwords.None {
+		lc.Stopwords = stopwords.ForLanguage(code)
+	}
+	if code != "en" && (c.WordPattern == "" || c.WordPattern == DefaultWordPattern) {
+		lc.WordPattern = LetterWordPattern
+	}
+	return &lc
+}
+
+// RegisterAnalysisFlags adds the flags for optional analyses of the article text to flags
+func RegisterAnalysisFlags(flags *flag.FlagSet, config *Config) {
+	flags.BoolVar(&config.Statistics, "statistics", false, "Report vocabulary richness, readability and the Zipf fit of the articles")
+	flags.IntVar(&config.Concordance, "concordance", 0, "Keep a random sample of this many contexts of each top word for a concordance (0 disables)")
+	flags.IntVar(&config.ConcordanceWidth, "concordance-width", concordance.DefaultWidth, "Words either side of the word in each concordance context")
+	flags.StringVar(&config.ConcordanceWords, "concordance-words", "", "Comma-separated words and phrases to sample contexts of instead of the top words")
+}
+
+// ConcordanceWordList returns the words to sample contexts of, or nil for the top words
+func (c *Config) ConcordanceWordList() []string {
+	return concordance.ParseWords(c.ConcordanceWords)
+}
+
+// ValidateAnalysis checks the flags of the optional analyses
+func (c *Config) ValidateAnalysis() error {
+	if c.Concordance < 0 {
+		return fmt.Errorf("--concordance must be non-negative (0 disables)")
+	}
+	if c.Concordance == 0 && (c.ConcordanceWords != "" || c.ConcordanceFile != "") {
+		return fmt.Errorf("--concordance-words and --concordance-file need --concordance")
+	}
+	if c.ConcordanceWidth < 1 {
+		return fmt.Errorf("--concordance-width must be positive")
+	}
+	return nil
+}
+
+// DefaultWordBankCacheDir returns the directory fetched wordbanks are cached in, or ""
+// when the user has no cache directory
+func DefaultWordBankCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "essay-analyzer", "wordbanks")
+}
+
+// IsRemoteWordBank reports whether the wordbank is fetched over http(s) rather than read from a file
+func (c *Config) IsRemoteWordBank() bool {
+	return strings.HasPrefix(c.WordBankFile, "http://") || strings.HasPrefix(c.WordBankFile, "https://")
+}
+
+// RegisterWorkerFlags adds the flags that size the pipeline's worker pools to flags
+func RegisterWorkerFlags(flags *flag.FlagSet, config *Config) {
+	flags.IntVar(&config.Workers, "workers", 50, "Number of concurrent workers, split 60/20/20 across fetchers, parsers and processors")
+	flags.IntVar(&config.Fetchers, "fetchers", 0, "Number of fetcher workers (overrides their share of --workers)")
+	flags.IntVar(&config.Parsers, "parsers", 0, "Number of parser workers (overrides their share of --workers)")
+	flags.IntVar(&config.Processors, "processors", 0, "Number of processor workers (overrides their share of --workers)")
+	flags.BoolVar(&config.AutoTune, "auto-tune", false, "Move workers between stages towards the bottleneck during the run")
+}
+
+// ValidateWorkers checks the worker pool sizes
+func (c *Config) ValidateWorkers() error {
+	if c.Workers <= 0 {
+		return fmt.Errorf("--workers must be positive")
+	}
+	if c.Fetchers < 0 || c.Parsers < 0 || c.Processors < 0 {
+		return fmt.Errorf("--fetchers, --parsers and --processors must be non-negative (0 = share of --workers)")
+	}
+	return nil
+}
+
+// RegisterFetchFlags adds the HTTP client limits to flags
+func RegisterFetchFlags(flags *flag.FlagSet, config *Config) {
+	flags.Int64Var(&config.MaxBodyBytes, "max-body-bytes", fetcher.DefaultMaxBodyBytes, "Fail pages larger than this many bytes (0 = unlimited)")
+	flags.DurationVar(&config.RequestTimeout, "request-timeout", fetcher.DefaultTimeout, "Timeout for a whole request including reading the page (0 = none)")
+	flags.DurationVar(&config.DialTimeout, "dial-timeout", fetcher.DefaultDialTimeout, "Timeout for establishing a connection (0 = none)")
+	flags.DurationVar(&config.TLSTimeout, "tls-timeout", fetcher.DefaultTLSHandshakeTimeout, "Timeout for the TLS handshake (0 = none)")
+	flags.DurationVar(&config.HeaderTimeout, "header-timeout", fetcher.DefaultResponseHeaderTimeout, "Timeout for response headers after sending a request (0 = none)")
+	flags.IntVar(&config.MaxRedirects, "max-redirects", fetcher.DefaultMaxRedirects, "Redirects followed per page, each checked against the target host's robots.txt (0 = none)")
+	flags.DurationVar(&config.IdleTimeout, "idle-timeout", fetcher.DefaultIdleConnTimeout, "How long idle keep-alive connections stay open (0 = forever)")
+}
+
+// ValidateFetch checks the HTTP client limits
+func (c *Config) ValidateFetch() error {
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("--max-body-bytes must be non-negative (0 = unlimited)")
+	}
+	if c.MaxRedirects < 0 {
+		return fmt.Errorf("--max-redirects must be non-negative (0 = none)")
+	}
+	for _, d := range []time.Duration{c.RequestTimeout, c.DialTimeout, c.TLSTimeout, c.HeaderTimeout, c.IdleTimeout} {
+		if d < 0 {
+			return fmt.Errorf("timeouts must be non-negative (0 = none)")
+		}
+	}
+	return nil
+}
+
+// RegisterDedupFlags adds the URL normalization and deduplication flags to flags
+func RegisterDedupFlags(flags *flag.FlagSet, config *Config) {
+	flags.BoolVar(&config.Dedup, "dedup", true, "Normalize URLs and drop duplicates before fetching (use --dedup=false to fetch every line)")
+	flags.StringVar(&config.StripParams, "strip-params", strings.Join(urlnorm.DefaultStripParams, ","),
+		"Comma-separated query parameters removed when normalizing URLs; a trailing * matches a prefix")
+}
+
+// StripParamList returns the query parameters to strip from URLs
+func (c *Config) StripParamList() []string {
+	var params []string
+	for _, param := range strings.Split(c.StripParams, ",") {
+		if param = strings.TrimSpace(param); param != "" {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// RegisterNearDupFlags adds the near-duplicate article detection flag to flags
+func RegisterNearDupFlags(flags *flag.FlagSet, config *Config) {
+	flags.IntVar(&config.NearDupThreshold, "near-dup-threshold", -1,
+		fmt.Sprintf("Skip articles whose SimHash is within this many bits of an earlier article (0-%d, %d suits news articles; negative disables)", simhash.MaxThreshold, simhash.SuggestedThreshold))
+}
+
+// ValidateNearDup checks the near-duplicate threshold
+func (c *Config) ValidateNearDup() error {
+	if c.NearDupThreshold > simhash.MaxThreshold {
+		return fmt.Errorf("--near-dup-threshold must be at most %d (negative disables)", simhash.MaxThreshold)
+	}
+	return nil
+}
+
+// RegisterLogFlags adds the logging flags shared by every command to flags
+func RegisterLogFlags(flags *flag.FlagSet, config *Config) {
+	flags.BoolVar(&config.Verbose, "verbose", false, "Log at debug level (same as --log-level=debug)")
+	flags.StringVar(&config.LogLevel, "log-level", "warn", "Minimum level to log: debug, info, warn or error")
+	flags.StringVar(&config.LogFormat, "log-format", logging.FormatText, "Log format: text or json")
+}
+
+// Logger creates the logger described by the logging flags, writing to w
+func (c *Config) Logger(w io.Writer) (*slog.Logger, error) {
+	level := c.LogLevel
+	if c.Verbose {
+		level = "debug"
+	}
+
+	logger, err := logging.New(w, level, c.LogFormat)
+	if err != nil {
+		return nil, fmt.Errorf("configuring logging: %w", err)
+	}
+	return logger, nil
+}
+
 // ValidateFiles checks if required files exist
 func (c *Config) ValidateFiles() error {
 	if _, err := os.Stat(c.URLsFile); os.IsNotExist(err) {
 		return fmt.Errorf("URLs file does not exist: %s", c.URLsFile)
 	}
 
+	if c.StopwordsFile != "" {
+		if _, err := os.Stat(c.StopwordsFile); os.IsNotExist(err) {
+			return fmt.Errorf("stopwords file does not exist: %s", c.StopwordsFile)
+		}
+	}
+
+	if c.WordBankFile == "" || c.IsRemoteWordBank() {
+		return nil
+	}
 	if _, err := os.Stat(c.WordBankFile); os.IsNotExist(err) {
 		return fmt.Errorf("word bank file does not exist: %s", c.WordBankFile)
 	}
@@ -0,0 +1,285 @@
+package diff
+
+import (
+	"math"
+	"sort"
+)
+
+// Word statuses in a comparison
+const (
+	StatusNew       = "new"       // Only present in the target
+	StatusVanished  = "vanished"  // Only present in the base
+	StatusChanged   = "changed"   // Present in both with a different count
+	StatusUnchanged = "unchanged" // Present in both with the same count
+)
+
+// Corpus holds the word counts of one analysis run
+type Corpus struct {
+	Name        string
+	WordCounts  map[string]int
+	TotalWords  int
+	TotalEssays int
+
+	// Truncated is set when WordCounts holds only the top words, as in a result
+	// file, so a word missing from it may still have been counted
+	Truncated bool
+}
+
+// WordChange describes how a single word moved between two runs
+type WordChange struct {
+	Word   string `json:"word"`
+	Status string `json:"status"`
+
+	BaseCount   int `json:"base_count"`
+	TargetCount int `json:"target_count"`
+
+	// Ranks are 1-based; 0 means the word is absent from that run
+	BaseRank   int `json:"base_rank"`
+	TargetRank int `json:"target_rank"`
+	RankChange int `json:"rank_change"` // Positive when the word moved up
+
+	// Frequencies are per million words processed
+	BaseFrequency   float64 `json:"base_per_million"`
+	TargetFrequency float64 `json:"target_per_million"`
+
+	// LogRatio is log2(target frequency / base frequency), with 0.5 added to
+	// both counts so words missing from one side still get a finite value
+	LogRatio float64 `json:"log2_ratio"`
+
+	// LogLikelihood is Dunning's G² statistic for the difference in frequency;
+	// PValue is its chi-squared (1 degree of freedom) tail probability
+	LogLikelihood float64 `json:"log_likelihood"`
+	PValue        float64 `json:"p_value"`
+}
+
+// Summary describes one side of the comparison
+type Summary struct {
+	Name        string `json:"name"`
+	TotalWords  int    `json:"total_words_processed"`
+	TotalEssays int    `json:"total_essays_processed"`
+	UniqueWords int    `json:"unique_words,omitempty"` // Unless truncated
+	TopWords    int    `json:"top_words,omitempty"`    // Words listed when truncated
+}
+
+// Report is the full comparison of a base run against a target run
+type Report struct {
+	Base   Summary `json:"base"`
+	Target Summary `json:"target"`
+
+	// Words holds every word from either run, ordered by LogLikelihood descending
+	Words []WordChange `json:"words"`
+
+	New      []string `json:"new"`
+	Vanished []string `json:"vanished"`
+
+	// Entered lists the words missing from a truncated base's top words, and Left
+	// those missing from a truncated target's. Their count on that side is unknown,
+	// so they are not in Words and get no G² or p-value.
+	Entered []string `json:"entered,omitempty"`
+	Left    []string `json:"left,omitempty"`
+}
+
+// Compare compares the base run against the target run
+func Compare(base, target Corpus) *Report {
+	baseRanks := ranks(base.WordCounts)
+	targetRanks := ranks(target.WordCounts)
+
+	report := &Report{
+		Base:     summarize(base),
+		Target:   summarize(target),
+		New:      []string{},
+		Vanished: []string{},
+	}
+
+	words := make(map[string]bool, len(base.WordCounts)+len(target.WordCounts))
+	for word := range base.WordCounts {
+		words[word] = true
+	}
+	for word := range target.WordCounts {
+		words[word] = true
+	}
+
+	for word := range words {
+		baseCount, inBase := base.WordCounts[word]
+		targetCount, inTarget := target.WordCounts[word]
+		if !inBase && base.Truncated {
+			report.Entered = append(report.Entered, word)
+			continue
+		}
+		if !inTarget && target.Truncated {
+			report.Left = append(report.Left, word)
+			continue
+		}
+
+		change := WordChange{
+			Word:            word,
+			BaseCount:       baseCount,
+			TargetCount:     targetCount,
+			BaseRank:        baseRanks[word],
+			TargetRank:      targetRanks[word],
+			BaseFrequency:   perMillion(baseCount, base.TotalWords),
+			TargetFrequency: perMillion(targetCount, target.TotalWords),
+			LogRatio:        logRatio(baseCount, base.TotalWords, targetCount, target.TotalWords),
+		}
+		change.LogLikelihood = LogLikelihood(baseCount, base.TotalWords, targetCount, target.TotalWords)
+		change.PValue = ChiSquaredPValue(change.LogLikelihood)
+
+		switch {
+		case !inBase:
+			change.Status = StatusNew
+			report.New = append(report.New, word)
+		case !inTarget:
+			change.Status = StatusVanished
+			report.Vanished = append(report.Vanished, word)
+		case baseCount != targetCount:
+			change.Status = StatusChanged
+		default:
+			change.Status = StatusUnchanged
+		}
+
+		if inBase && inTarget {
+			change.RankChange = change.BaseRank - change.TargetRank
+		}
+
+		report.Words = append(report.Words, change)
+	}
+
+	sort.Slice(report.Words, func(i, j int) bool {
+		if report.Words[i].LogLikelihood == report.Words[j].LogLikelihood {
+			return report.Words[i].Word < report.Words[j].Word
+		}
+		return report.Words[i].LogLikelihood > report.Words[j].LogLikelihood
+	})
+	sort.Strings(report.New)
+	sort.Strings(report.Vanished)
+	sort.Strings(report.Entered)
+	sort.Strings(report.Left)
+
+	return report
+}
+
+// RankChanges returns words present in both runs whose rank moved, largest move first
+func (r *Report) RankChanges() []WordChange {
+	var changes []WordChange
+	for _, change := range r.Words {
+		if change.BaseRank > 0 && change.TargetRank > 0 && change.RankChange != 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return abs(changes[i].RankChange) > abs(changes[j].RankChange)
+	})
+	return changes
+}
+
+// LargestShifts returns up to n words with the largest relative frequency change
+func (r *Report) LargestShifts(n int) []WordChange {
+	shifts := make([]WordChange, len(r.Words))
+	copy(shifts, r.Words)
+
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return math.Abs(shifts[i].LogRatio) > math.Abs(shifts[j].LogRatio)
+	})
+
+	if n >= 0 && n < len(shifts) {
+		shifts = shifts[:n]
+	}
+	return shifts
+}
+
+// LogLikelihood computes Dunning's G² for a word seen count1 times in a corpus
+// of total1 words and count2 times in a corpus of total2 words
+func LogLikelihood(count1, total1, count2, total2 int) float64 {
+	if total1 == 0 || total2 == 0 {
+		return 0
+	}
+
+	c1, t1 := float64(count1), float64(total1)
+	c2, t2 := float64(count2), float64(total2)
+
+	expected1 := t1 * (c1 + c2) / (t1 + t2)
+	expected2 := t2 * (c1 + c2) / (t1 + t2)
+
+	g2 := 2 * (xLogXOverY(c1, expected1) + xLogXOverY(c2, expected2))
+	if g2 < 0 {
+		// Guard against tiny negative values from floating point error
+		return 0
+	}
+	return g2
+}
+
+// ChiSquaredPValue returns the upper tail probability of a chi-squared
+// statistic with one degree of freedom
+func ChiSquaredPValue(statistic float64) float64 {
+	if statistic <= 0 {
+		return 1
+	}
+	return math.Erfc(math.Sqrt(statistic / 2))
+}
+
+// xLogXOverY returns x*ln(x/y), treating 0*ln(0) as 0
+func xLogXOverY(x, y float64) float64 {
+	if x == 0 || y == 0 {
+		return 0
+	}
+	return x * math.Log(x/y)
+}
+
+// ranks assigns 1-based ranks by count descending, then word ascending
+func ranks(counts map[string]int) map[string]int {
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] == counts[words[j]] {
+			return words[i] < words[j]
+		}
+		return counts[words[i]] > counts[words[j]]
+	})
+
+	result := make(map[string]int, len(words))
+	for i, word := range words {
+		result[word] = i + 1
+	}
+	return result
+}
+
+func summarize(c Corpus) Summary {
+	s := Summary{
+		Name:        c.Name,
+		TotalWords:  c.TotalWords,
+		TotalEssays: c.TotalEssays,
+	}
+	if c.Truncated {
+		s.TopWords = len(c.WordCounts)
+	} else {
+		s.UniqueWords = len(c.WordCounts)
+	}
+	return s
+}
+
+func perMillion(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total) * 1e6
+}
+
+func logRatio(count1, total1, count2, total2 int) float64 {
+	if total1 == 0 || total2 == 0 {
+		return 0
+	}
+	freq1 := (float64(count1) + 0.5) / float64(total1)
+	freq2 := (float64(count2) + 0.5) / float64(total2)
+	return math.Log2(freq2 / freq1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testCorpora() (Corpus, Corpus) {
+	base := Corpus{
+		Name:        "base.json",
+		WordCounts:  map[string]int{"technology": 100, "science": 50, "computer": 30, "gaming": 20},
+		TotalWords:  1000,
+		TotalEssays: 10,
+	}
+	target := Corpus{
+		Name:        "target.json",
+		WordCounts:  map[string]int{"technology": 100, "computer": 60, "science": 50, "privacy": 40},
+		TotalWords:  1000,
+		TotalEssays: 12,
+	}
+	return base, target
+}
+
+func findWord(t *testing.T, r *Report, word string) WordChange {
+	t.Helper()
+	for _, c := range r.Words {
+		if c.Word == word {
+			return c
+		}
+	}
+	t.Fatalf("Word %q not found in report", word)
+	return WordChange{}
+}
+
+func TestCompare_Statuses(t *testing.T) {
+	report := Compare(testCorpora())
+
+	if len(report.Words) != 5 {
+		t.Fatalf("Expected 5 words, got %d", len(report.Words))
+	}
+
+	if len(report.New) != 1 || report.New[0] != "privacy" {
+		t.Errorf("Expected new words [privacy], got %v", report.New)
+	}
+	if len(report.Vanished) != 1 || report.Vanished[0] != "gaming" {
+		t.Errorf("Expected vanished words [gaming], got %v", report.Vanished)
+	}
+
+	if c := findWord(t, report, "technology"); c.Status != StatusUnchanged || c.LogLikelihood != 0 {
+		t.Errorf("Expected technology to be unchanged with G2 0, got %+v", c)
+	}
+
+	computer := findWord(t, report, "computer")
+	if computer.Status != StatusChanged {
+		t.Errorf("Expected computer to be changed, got %s", computer.Status)
+	}
+	// computer moved from #3 to #2
+	if computer.BaseRank != 3 || computer.TargetRank != 2 || computer.RankChange != 1 {
+		t.Errorf("Unexpected computer ranks: %+v", computer)
+	}
+	if computer.BaseFrequency != 30000 || computer.TargetFrequency != 60000 {
+		t.Errorf("Unexpected computer frequencies: %+v", computer)
+	}
+
+	privacy := findWord(t, report, "privacy")
+	if privacy.BaseRank != 0 || privacy.RankChange != 0 || privacy.LogRatio <= 0 {
+		t.Errorf("Unexpected privacy change: %+v", privacy)
+	}
+}
+
+func TestCompare_NormalizesByTotalWords(t *testing.T) {
+	base := Corpus{WordCounts: map[string]int{"technology": 10}, TotalWords: 1000}
+	target := Corpus{WordCounts: map[string]int{"technology": 20}, TotalWords: 2000}
+
+	c := Compare(base, target).Words[0]
+	if c.BaseFrequency != c.TargetFrequency {
+		t.Errorf("Expected equal frequencies, got %v and %v", c.BaseFrequency, c.TargetFrequency)
+	}
+	if c.LogLikelihood > 1e-9 {
+		t.Errorf("Expected no significant change, got G2 %v", c.LogLikelihood)
+	}
+}
+
+func TestCompare_OrderedBySignificance(t *testing.T) {
+	report := Compare(testCorpora())
+
+	for i := 1; i < len(report.Words); i++ {
+		if report.Words[i].LogLikelihood > report.Words[i-1].LogLikelihood {
+			t.Fatalf("Words not ordered by log-likelihood at %d: %+v", i, report.Words)
+		}
+	}
+}
+
+func TestCompare_Truncated(t *testing.T) {
+	// Both results list their top 3; gaming was 4th in the base with 29 and 3rd in
+	// the target, and computer fell from 3rd to just below the target's top 3
+	base := Corpus{
+		WordCounts:  map[string]int{"technology": 100, "science": 50, "computer": 30},
+		TotalWords:  1000,
+		TotalEssays: 10,
+		Truncated:   true,
+	}
+	target := Corpus{
+		WordCounts:  map[string]int{"technology": 100, "science": 50, "gaming": 31},
+		TotalWords:  1000,
+		TotalEssays: 10,
+		Truncated:   true,
+	}
+	report := Compare(base, target)
+
+	if len(report.Entered) != 1 || report.Entered[0] != "gaming" {
+		t.Errorf("Expected gaming to enter the top words, got %v", report.Entered)
+	}
+	if len(report.Left) != 1 || report.Left[0] != "computer" {
+		t.Errorf("Expected computer to leave the top words, got %v", report.Left)
+	}
+	if len(report.New) != 0 || len(report.Vanished) != 0 {
+		t.Errorf("Expected no new or vanished words, got %v and %v", report.New, report.Vanished)
+	}
+	for _, c := range report.Words {
+		if c.Word == "gaming" || c.Word == "computer" {
+			t.Errorf("Expected no G2 for %s, whose count on one side is unknown, got %+v", c.Word, c)
+		}
+	}
+	if len(report.Words) != 2 || report.Base.TopWords != 3 || report.Base.UniqueWords != 0 {
+		t.Errorf("Expected 2 compared words from a top 3, got %d words and %+v", len(report.Words), report.Base)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, report, 10); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, want := range []string{"Entered top 3: gaming", "Left top 3: computer"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestLogLikelihood(t *testing.T) {
+	// 10 vs 30 occurrences in equally sized corpora
+	got := LogLikelihood(10, 1000, 30, 1000)
+	expected := 2 * (10*math.Log(0.5) + 30*math.Log(1.5))
+	if math.Abs(got-expected) > 1e-9 {
+		t.Errorf("LogLikelihood = %v, expected %v", got, expected)
+	}
+
+	if got := LogLikelihood(5, 0, 5, 100); got != 0 {
+		t.Errorf("Expected 0 for empty corpus, got %v", got)
+	}
+}
+
+func TestChiSquaredPValue(t *testing.T) {
+	// 3.84 is the 5% critical value for one degree of freedom
+	if p := ChiSquaredPValue(3.841); math.Abs(p-0.05) > 0.001 {
+		t.Errorf("Expected p ~0.05 for 3.841, got %v", p)
+	}
+	if p := ChiSquaredPValue(0); p != 1 {
+		t.Errorf("Expected p 1 for statistic 0, got %v", p)
+	}
+}
+
+func TestLargestShifts(t *testing.T) {
+	report := Compare(testCorpora())
+
+	shifts := report.LargestShifts(2)
+	if len(shifts) != 2 {
+		t.Fatalf("Expected 2 shifts, got %d", len(shifts))
+	}
+	// privacy (0 -> 40) and gaming (20 -> 0) have the largest log ratios
+	if shifts[0].Word != "privacy" || shifts[1].Word != "gaming" {
+		t.Errorf("Unexpected largest shifts: %s, %s", shifts[0].Word, shifts[1].Word)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, Compare(testCorpora()), 10); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"base.json", "Rank changes:", "computer", "New words: privacy", "Vanished words: gaming"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Compare(testCorpora()), 3); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var decoded struct {
+		Words         []WordChange `json:"words"`
+		New           []string     `json:"new"`
+		RankChanges   []WordChange `json:"rank_changes"`
+		LargestShifts []WordChange `json:"largest_shifts"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+
+	if len(decoded.Words) != 5 || len(decoded.LargestShifts) != 3 || len(decoded.New) != 1 {
+		t.Errorf("Unexpected decoded report: %+v", decoded)
+	}
+	if len(decoded.RankChanges) == 0 {
+		t.Error("Expected rank changes in JSON output")
+	}
+}
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the report as indented JSON, with the rank changes and up to
+// limit largest shifts broken out alongside the full word list
+func WriteJSON(w io.Writer, r *Report, limit int) error {
+	rankChanges := r.RankChanges()
+	if rankChanges == nil {
+		rankChanges = []WordChange{}
+	}
+
+	output := struct {
+		*Report
+		RankChanges   []WordChange `json:"rank_changes"`
+		LargestShifts []WordChange `json:"largest_shifts"`
+	}{
+		Report:        r,
+		RankChanges:   rankChanges,
+		LargestShifts: r.LargestShifts(limit),
+	}
+
+	jsonData, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling diff to JSON: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(jsonData))
+	return err
+}
+
+// WriteText writes a human-readable report, listing at most limit words per section
+func WriteText(w io.Writer, r *Report, limit int) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Base:   %s (%d essays, %d words)\n", r.Base.Name, r.Base.TotalEssays, r.Base.TotalWords)
+	fmt.Fprintf(&b, "Target: %s (%d essays, %d words)\n", r.Target.Name, r.Target.TotalEssays, r.Target.TotalWords)
+
+	b.WriteString("\nRank changes:\n")
+	rankChanges := truncate(r.RankChanges(), limit)
+	if len(rankChanges) == 0 {
+		b.WriteString("  (none)\n")
+	} else {
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, c := range rankChanges {
+			fmt.Fprintf(tw, "  %s\t#%d -> #%d\t%+d\n", c.Word, c.BaseRank, c.TargetRank, c.RankChange)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintf(&b, "\nNew words: %s\n", joinOrNone(r.New))
+	fmt.Fprintf(&b, "Vanished words: %s\n", joinOrNone(r.Vanished))
+	if r.Base.TopWords > 0 {
+		fmt.Fprintf(&b, "Entered top %d: %s\n", r.Base.TopWords, joinOrNone(r.Entered))
+	}
+	if r.Target.TopWords > 0 {
+		fmt.Fprintf(&b, "Left top %d: %s\n", r.Target.TopWords, joinOrNone(r.Left))
+	}
+
+	b.WriteString("\nLargest frequency shifts (per million words):\n")
+	shifts := r.LargestShifts(limit)
+	if len(shifts) == 0 {
+		b.WriteString("  (none)\n")
+	} else {
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  WORD\tBASE\tTARGET\tLOG2 RATIO\tG2\tP-VALUE")
+		for _, c := range shifts {
+			fmt.Fprintf(tw, "  %s\t%.1f\t%.1f\t%+.2f\t%.2f\t%.4f%s\n",
+				c.Word, c.BaseFrequency, c.TargetFrequency, c.LogRatio, c.LogLikelihood, c.PValue, significance(c.PValue))
+		}
+		tw.Flush()
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// significance marks p-values below the conventional thresholds
+func significance(p float64) string {
+	switch {
+	case p < 0.001:
+		return " ***"
+	case p < 0.01:
+		return " **"
+	case p < 0.05:
+		return " *"
+	}
+	return ""
+}
+
+func truncate(changes []WordChange, limit int) []WordChange {
+	if limit >= 0 && limit < len(changes) {
+		return changes[:limit]
+	}
+	return changes
+}
+
+func joinOrNone(words []string) string {
+	if len(words) == 0 {
+		return "(none)"
+	}
+	return strings.Join(words, ", ")
+}
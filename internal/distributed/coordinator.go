@@ -0,0 +1,357 @@
+package distributed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+)
+
+// API paths served by the coordinator
+const (
+	PathLease  = "/v1/lease"
+	PathLeases = "/v1/leases/" // followed by {id}/renew or {id}/complete
+	PathStatus = "/v1/status"
+)
+
+// maxRetryAfter caps how long idle workers wait before asking again, so they
+// notice both expired leases and the end of the run promptly
+const maxRetryAfter = time.Second
+
+// LeaseRequest asks the coordinator for a batch of URLs
+type LeaseRequest struct {
+	WorkerID string `json:"worker_id"`
+}
+
+// Lease grants a worker exclusive use of a batch until it expires
+type Lease struct {
+	ID        string    `json:"id"`
+	URLs      []string  `json:"urls"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LeaseResponse answers a LeaseRequest. Exactly one of Lease, Done or
+// RetryAfterSeconds is set: a batch to process, no work left, or every
+// remaining batch is leased and the worker should ask again later.
+type LeaseResponse struct {
+	Lease             *Lease  `json:"lease,omitempty"`
+	Done              bool    `json:"done,omitempty"`
+	RetryAfterSeconds float64 `json:"retry_after_seconds,omitempty"`
+}
+
+// CompleteRequest reports the partial result of a leased batch
+type CompleteRequest struct {
+	WorkerID string               `json:"worker_id"`
+	Snapshot *aggregator.Snapshot `json:"snapshot"`
+	URLs     outputio.URLCounts   `json:"urls"`
+}
+
+// Status reports the coordinator's progress
+type Status struct {
+	TotalBatches     int                `json:"total_batches"`
+	PendingBatches   int                `json:"pending_batches"`
+	LeasedBatches    int                `json:"leased_batches"`
+	CompletedBatches int                `json:"completed_batches"`
+	ExpiredLeases    int                `json:"expired_leases"`
+	URLs             outputio.URLCounts `json:"urls"`
+	Done             bool               `json:"done"`
+}
+
+// batch is a fixed slice of the URL list, identified by its position
+type batch struct {
+	id   int
+	urls []string
+}
+
+// activeLease tracks a batch currently assigned to a worker
+type activeLease struct {
+	id        string
+	batch     batch
+	workerID  string
+	expiresAt time.Time
+}
+
+// Coordinator leases batches of URLs to workers over HTTP, reassigns batches
+// whose leases expire, and merges the partial results into one Aggregator.
+// Each batch is counted exactly once: the first completion wins.
+type Coordinator struct {
+	mu           sync.Mutex
+	agg          *aggregator.Aggregator
+	leaseTimeout time.Duration
+	now          func() time.Time
+
+	batches   []batch
+	pending   []int                // Batch IDs waiting to be leased, in order
+	leased    map[int]*activeLease // Batch ID to its current lease
+	issued    map[string]int       // Every lease ID ever issued to its batch ID
+	completed map[int]bool         // Batch IDs whose results were merged
+	urls      outputio.URLCounts   // Sum of the URL counts reported by workers
+	expired   int                  // Number of leases that expired
+	nextLease int
+	done      chan struct{}
+}
+
+// NewCoordinator splits urls into batches of batchSize and merges completed
+// batches into agg. Leases not renewed or completed within leaseTimeout are
+// handed to the next worker that asks.
+func NewCoordinator(urls []string, batchSize int, leaseTimeout time.Duration, agg *aggregator.Aggregator) *Coordinator {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	c := &Coordinator{
+		agg:          agg,
+		leaseTimeout: leaseTimeout,
+		now:          time.Now,
+		leased:       make(map[int]*activeLease),
+		issued:       make(map[string]int),
+		completed:    make(map[int]bool),
+		done:         make(chan struct{}),
+	}
+
+	for start := 0; start < len(urls); start += batchSize {
+		end := min(start+batchSize, len(urls))
+		c.batches = append(c.batches, batch{id: len(c.batches), urls: urls[start:end]})
+		c.pending = append(c.pending, len(c.batches)-1)
+	}
+
+	if len(c.batches) == 0 {
+		close(c.done)
+	}
+
+	return c
+}
+
+// Done is closed once every batch has been completed
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// URLCounts returns the URL counts reported by workers for completed batches
+func (c *Coordinator) URLCounts() outputio.URLCounts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.urls
+}
+
+// Status returns the coordinator's current progress
+func (c *Coordinator) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reclaimExpired()
+	return Status{
+		TotalBatches:     len(c.batches),
+		PendingBatches:   len(c.pending),
+		LeasedBatches:    len(c.leased),
+		CompletedBatches: len(c.completed),
+		ExpiredLeases:    c.expired,
+		URLs:             c.urls,
+		Done:             len(c.completed) == len(c.batches),
+	}
+}
+
+// Handler returns the coordinator's HTTP API
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathLease, c.handleLease)
+	mux.HandleFunc(PathLeases, c.handleLeaseAction)
+	mux.HandleFunc(PathStatus, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.Status())
+	})
+	return mux
+}
+
+func (c *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req LeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding lease request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c.lease(req.WorkerID))
+}
+
+// handleLeaseAction serves POST /v1/leases/{id}/renew and POST /v1/leases/{id}/complete
+func (c *Coordinator) handleLeaseAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, PathLeases), "/")
+	if !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch action {
+	case "renew":
+		lease, err := c.renew(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		writeJSON(w, http.StatusOK, lease)
+
+	case "complete":
+		var req CompleteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("decoding completion: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Snapshot == nil {
+			http.Error(w, "completion has no snapshot", http.StatusBadRequest)
+			return
+		}
+		status, err := c.complete(id, &req)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// lease hands the next pending batch to workerID
+func (c *Coordinator) lease(workerID string) LeaseResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reclaimExpired()
+
+	if len(c.completed) == len(c.batches) {
+		return LeaseResponse{Done: true}
+	}
+
+	if len(c.pending) == 0 {
+		// Everything left is leased; ask again once the earliest lease could expire
+		retry := maxRetryAfter
+		for _, l := range c.leased {
+			if wait := l.expiresAt.Sub(c.now()); wait < retry {
+				retry = wait
+			}
+		}
+		return LeaseResponse{RetryAfterSeconds: max(retry.Seconds(), 0.1)}
+	}
+
+	b := c.batches[c.pending[0]]
+	c.pending = c.pending[1:]
+
+	c.nextLease++
+	l := &activeLease{
+		id:        fmt.Sprintf("b%d-l%d", b.id, c.nextLease),
+		batch:     b,
+		workerID:  workerID,
+		expiresAt: c.now().Add(c.leaseTimeout),
+	}
+	c.leased[b.id] = l
+	c.issued[l.id] = b.id
+
+	return LeaseResponse{Lease: &Lease{ID: l.id, URLs: b.urls, ExpiresAt: l.expiresAt}}
+}
+
+// renew extends a lease that is still held
+func (c *Coordinator) renew(leaseID string) (*Lease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reclaimExpired()
+
+	batchID, ok := c.issued[leaseID]
+	if !ok {
+		return nil, fmt.Errorf("unknown lease %s", leaseID)
+	}
+
+	l := c.leased[batchID]
+	if l == nil || l.id != leaseID {
+		return nil, fmt.Errorf("lease %s is no longer held", leaseID)
+	}
+
+	l.expiresAt = c.now().Add(c.leaseTimeout)
+	return &Lease{ID: l.id, URLs: l.batch.urls, ExpiresAt: l.expiresAt}, nil
+}
+
+// complete merges a batch result. A late completion for an expired lease is
+// still accepted if nobody else has completed the batch yet.
+func (c *Coordinator) complete(leaseID string, req *CompleteRequest) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	batchID, ok := c.issued[leaseID]
+	if !ok {
+		return http.StatusNotFound, fmt.Errorf("unknown lease %s", leaseID)
+	}
+	if c.completed[batchID] {
+		return http.StatusConflict, fmt.Errorf("batch %d was already completed", batchID)
+	}
+
+	if err := c.agg.Merge(req.Snapshot); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("merging batch %d: %w", batchID, err)
+	}
+
+	c.completed[batchID] = true
+	delete(c.leased, batchID)
+	for i, id := range c.pending {
+		if id == batchID {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			break
+		}
+	}
+
+	c.urls.Queued += req.URLs.Queued
+	c.urls.Succeeded += req.URLs.Succeeded
+	c.urls.Failed += req.URLs.Failed
+	c.urls.Skipped += req.URLs.Skipped
+	c.urls.NearDuplicates += req.URLs.NearDuplicates
+	c.urls.CanonicalDuplicates += req.URLs.CanonicalDuplicates
+	c.urls.LanguageSkipped += req.URLs.LanguageSkipped
+
+	if len(c.completed) == len(c.batches) {
+		close(c.done)
+	}
+
+	return http.StatusNoContent, nil
+}
+
+// reclaimExpired returns batches with expired leases to the front of the
+// pending queue. Callers must hold c.mu.
+func (c *Coordinator) reclaimExpired() {
+	now := c.now()
+
+	var reclaimed []int
+	for batchID, l := range c.leased {
+		if now.After(l.expiresAt) {
+			delete(c.leased, batchID)
+			reclaimed = append(reclaimed, batchID)
+			c.expired++
+		}
+	}
+
+	if len(reclaimed) > 0 {
+		sort.Ints(reclaimed)
+		// Lost batches go first so a stalled shard doesn't hold up the end of the run
+		c.pending = append(reclaimed, c.pending...)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
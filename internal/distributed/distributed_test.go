@@ -0,0 +1,242 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/parser"
+	"github.com/firefly/essay-analyzer/internal/processor"
+)
+
+// testWords is a minimal WordValidator
+type testWords map[string]bool
+
+func (w testWords) IsValid(word string) bool { return w[word] }
+
+// newContentServer serves n Engadget-style articles; article i mentions
+// "technology" i times and "science" once
+func newContentServer(n int) (*httptest.Server, []string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		if _, err := fmt.Sscanf(r.URL.Path, "/article/%d", &i); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		body := "science"
+		for j := 0; j < i; j++ {
+			body += " technology"
+		}
+		fmt.Fprintf(w, `<html><body><article><header>Article %d</header><div data-article-body="true">%s</div></article></body></html>`, i, body)
+	}))
+
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/article/%d", server.URL, i)
+	}
+	return server, urls
+}
+
+// sequentialBatch fetches, parses and counts a batch one URL at a time
+func sequentialBatch(ctx context.Context, urls []string) (*aggregator.Snapshot, outputio.URLCounts, error) {
+	fetch := fetcher.New(0, nil)
+	htmlParser := parser.New(nil)
+	textProcessor := processor.New(testWords{"technology": true, "science": true}, nil)
+	agg := aggregator.New(nil)
+
+	counts := outputio.URLCounts{Queued: int64(len(urls))}
+	for _, url := range urls {
+		page, err := fetch.FetchURL(ctx, url)
+		if err != nil {
+			counts.Failed++
+			continue
+		}
+		text, err := htmlParser.ExtractText(page.Body)
+		page.Body.Close()
+		if err != nil {
+			counts.Failed++
+			continue
+		}
+		agg.AddResult(aggregator.ProcessingResult{URL: url, WordCounts: textProcessor.ProcessText(text)})
+		counts.Succeeded++
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, counts, err
+	}
+	return agg.Snapshot(), counts, nil
+}
+
+func TestCoordinatorAndWorkers(t *testing.T) {
+	content, urls := newContentServer(20)
+	defer content.Close()
+
+	agg := aggregator.New(nil)
+	coordinator := NewCoordinator(urls, 3, time.Minute, agg)
+	server := httptest.NewServer(coordinator.Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			worker := NewWorker(fmt.Sprintf("worker-%d", id), server.URL, sequentialBatch, nil)
+			errs <- worker.Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Worker failed: %v", err)
+		}
+	}
+
+	select {
+	case <-coordinator.Done():
+	default:
+		t.Fatal("Expected coordinator to be done")
+	}
+
+	// technology appears 0+1+...+19 = 190 times, science once per article
+	processed, totalWords, _, _ := agg.GetStats()
+	if processed != 20 {
+		t.Errorf("Expected 20 processed articles, got %d", processed)
+	}
+	if totalWords != 210 {
+		t.Errorf("Expected 210 total words, got %d", totalWords)
+	}
+	top := agg.GetTopWords(2)
+	if top[0] != (aggregator.WordCount{Word: "technology", Count: 190}) || top[1] != (aggregator.WordCount{Word: "science", Count: 20}) {
+		t.Errorf("Unexpected top words: %+v", top)
+	}
+
+	status := coordinator.Status()
+	if !status.Done || status.CompletedBatches != 7 || status.URLs.Succeeded != 20 {
+		t.Errorf("Unexpected final status: %+v", status)
+	}
+}
+
+// fakeClock lets tests move the coordinator's time forward
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestCoordinator_ReassignsExpiredLease(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	agg := aggregator.New(nil)
+	coordinator := NewCoordinator([]string{"a", "b"}, 2, time.Minute, agg)
+	coordinator.now = clock.Now
+
+	first := coordinator.lease("lost-worker")
+	if first.Lease == nil {
+		t.Fatal("Expected a lease")
+	}
+
+	// While leased, other workers are told to retry rather than given the batch
+	if resp := coordinator.lease("other"); resp.Lease != nil || resp.RetryAfterSeconds <= 0 {
+		t.Fatalf("Expected retry while batch is leased, got %+v", resp)
+	}
+
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	second := coordinator.lease("other")
+	if second.Lease == nil || second.Lease.ID == first.Lease.ID {
+		t.Fatalf("Expected the expired batch under a new lease, got %+v", second)
+	}
+	if len(second.Lease.URLs) != 2 {
+		t.Errorf("Expected the same 2 URLs, got %v", second.Lease.URLs)
+	}
+
+	// The lost worker can no longer renew
+	if _, err := coordinator.renew(first.Lease.ID); err == nil {
+		t.Error("Expected renewing an expired lease to fail")
+	}
+
+	snapshot := &aggregator.Snapshot{Version: aggregator.SnapshotVersion, WordCounts: map[string]int{"word": 1}, TotalWordsProcessed: 1, TotalEssaysProcessed: 2}
+	if _, err := coordinator.complete(second.Lease.ID, &CompleteRequest{Snapshot: snapshot}); err != nil {
+		t.Fatalf("Completing the new lease failed: %v", err)
+	}
+
+	// A late completion from the lost worker must not double count
+	status, err := coordinator.complete(first.Lease.ID, &CompleteRequest{Snapshot: snapshot})
+	if err == nil || status != http.StatusConflict {
+		t.Errorf("Expected conflict for duplicate completion, got %d %v", status, err)
+	}
+
+	if processed, _, _, _ := agg.GetStats(); processed != 2 {
+		t.Errorf("Expected batch to be counted once (2 essays), got %d", processed)
+	}
+	if s := coordinator.Status(); !s.Done || s.ExpiredLeases != 1 {
+		t.Errorf("Unexpected status: %+v", s)
+	}
+}
+
+func TestCoordinator_AcceptsLateCompletionOfPendingBatch(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	coordinator := NewCoordinator([]string{"a"}, 1, time.Minute, aggregator.New(nil))
+	coordinator.now = clock.Now
+
+	lease := coordinator.lease("slow-worker").Lease
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	// The batch is back in the pending queue but nobody has picked it up yet
+	if s := coordinator.Status(); s.PendingBatches != 1 {
+		t.Fatalf("Expected expired batch to be pending, got %+v", s)
+	}
+
+	snapshot := &aggregator.Snapshot{Version: aggregator.SnapshotVersion}
+	if _, err := coordinator.complete(lease.ID, &CompleteRequest{Snapshot: snapshot}); err != nil {
+		t.Fatalf("Expected late completion to be accepted, got %v", err)
+	}
+	if s := coordinator.Status(); !s.Done || s.PendingBatches != 0 {
+		t.Errorf("Expected coordinator done with nothing pending, got %+v", s)
+	}
+}
+
+func TestCoordinator_RenewExtendsLease(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	coordinator := NewCoordinator([]string{"a"}, 1, time.Minute, aggregator.New(nil))
+	coordinator.now = clock.Now
+
+	lease := coordinator.lease("worker").Lease
+
+	clock.now = clock.now.Add(45 * time.Second)
+	if _, err := coordinator.renew(lease.ID); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+
+	// Past the original expiry but within the renewed one
+	clock.now = clock.now.Add(45 * time.Second)
+	if resp := coordinator.lease("other"); resp.Lease != nil {
+		t.Errorf("Expected renewed lease to be kept, got %+v", resp.Lease)
+	}
+}
+
+func TestCoordinator_NoURLs(t *testing.T) {
+	coordinator := NewCoordinator(nil, 10, time.Minute, aggregator.New(nil))
+
+	select {
+	case <-coordinator.Done():
+	default:
+		t.Fatal("Expected coordinator with no URLs to be done")
+	}
+	if resp := coordinator.lease("worker"); !resp.Done {
+		t.Errorf("Expected done response, got %+v", resp)
+	}
+}
@@ -0,0 +1,181 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/logging"
+)
+
+// BatchFunc processes one batch of URLs and returns its partial result
+type BatchFunc func(ctx context.Context, urls []string) (*aggregator.Snapshot, outputio.URLCounts, error)
+
+// errLeaseLost means the coordinator no longer recognizes a lease
+var errLeaseLost = errors.New("lease lost")
+
+// Worker repeatedly leases batches from a coordinator, processes them and
+// reports the results until the coordinator has no work left
+type Worker struct {
+	ID             string
+	CoordinatorURL string
+	Process        BatchFunc
+	Client         *http.Client
+	logger         *slog.Logger
+}
+
+// NewWorker creates a Worker that processes batches with process. A nil
+// logger discards all log output.
+func NewWorker(id, coordinatorURL string, process BatchFunc, logger *slog.Logger) *Worker {
+	return &Worker{
+		ID:             id,
+		CoordinatorURL: strings.TrimSuffix(coordinatorURL, "/"),
+		Process:        process,
+		Client:         &http.Client{Timeout: time.Minute},
+		logger:         logging.OrDiscard(logger).With("worker", id),
+	}
+}
+
+// Run processes batches until the coordinator reports that all work is done
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		var resp LeaseResponse
+		if err := w.post(ctx, PathLease, LeaseRequest{WorkerID: w.ID}, &resp); err != nil {
+			return fmt.Errorf("requesting lease: %w", err)
+		}
+
+		switch {
+		case resp.Done:
+			return nil
+
+		case resp.Lease == nil:
+			wait := time.Duration(resp.RetryAfterSeconds * float64(time.Second))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+		default:
+			if err := w.runLease(ctx, resp.Lease); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// runLease processes a leased batch, renewing the lease while it runs
+func (w *Worker) runLease(ctx context.Context, lease *Lease) error {
+	logger := w.logger.With("lease", lease.ID)
+	logger.Info("processing lease", "urls", len(lease.URLs))
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewDone := make(chan struct{})
+	go func() {
+		defer close(renewDone)
+		w.renewLoop(batchCtx, lease)
+	}()
+
+	snapshot, counts, err := w.Process(batchCtx, lease.URLs)
+	cancel()
+	<-renewDone
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Let the lease expire so another worker retries the batch
+		logger.Warn("batch failed; leaving the lease to expire", "error", err)
+		return nil
+	}
+
+	req := CompleteRequest{WorkerID: w.ID, Snapshot: snapshot, URLs: counts}
+	err = w.post(ctx, PathLeases+lease.ID+"/complete", req, nil)
+	if errors.Is(err, errLeaseLost) {
+		// Another worker already completed the batch; its result stands
+		logger.Info("discarded result for a lease completed elsewhere", "error", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("completing lease %s: %w", lease.ID, err)
+	}
+
+	return nil
+}
+
+// renewLoop keeps the lease alive at a third of its remaining lifetime until ctx ends
+func (w *Worker) renewLoop(ctx context.Context, lease *Lease) {
+	expiresAt := lease.ExpiresAt
+	for {
+		interval := time.Until(expiresAt) / 3
+		if interval <= 0 {
+			interval = 100 * time.Millisecond
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+
+		var renewed Lease
+		if err := w.post(ctx, PathLeases+lease.ID+"/renew", struct{}{}, &renewed); err != nil {
+			if ctx.Err() == nil {
+				w.logger.Warn("could not renew lease", "lease", lease.ID, "error", err)
+			}
+			if errors.Is(err, errLeaseLost) {
+				return
+			}
+			continue
+		}
+		expiresAt = renewed.ExpiresAt
+	}
+}
+
+// post sends body as JSON and decodes the response into out (if non-nil)
+func (w *Worker) post(ctx context.Context, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.CoordinatorURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusConflict:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s", errLeaseLost, strings.TrimSpace(string(msg)))
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("coordinator returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -3,15 +3,22 @@ package fetcher
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/metrics"
+	"github.com/firefly/essay-analyzer/internal/tracing"
 	"golang.org/x/time/rate"
 )
 
@@ -22,6 +29,24 @@ const (
 	// DefaultTimeout for HTTP requests
 	DefaultTimeout = 30 * time.Second
 
+	// DefaultDialTimeout for establishing TCP connections
+	DefaultDialTimeout = 10 * time.Second
+
+	// DefaultTLSHandshakeTimeout for TLS handshakes
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+
+	// DefaultResponseHeaderTimeout for receiving response headers after sending a request
+	DefaultResponseHeaderTimeout = 20 * time.Second
+
+	// DefaultIdleConnTimeout for keeping idle connections open
+	DefaultIdleConnTimeout = 90 * time.Second
+
+	// DefaultMaxBodyBytes caps how much of a page is read
+	DefaultMaxBodyBytes = 10 << 20
+
+	// DefaultMaxRedirects is how many redirects a fetch follows before failing
+	DefaultMaxRedirects = 10
+
 	// MaxRetries for failed requests
 	MaxRetries = 3
 
@@ -29,6 +54,18 @@ const (
 	BackoffBase = time.Second
 )
 
+// ErrBodyTooLarge is returned when a response body exceeds the configured size limit
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// ErrTooManyRedirects is returned when a fetch would follow more redirects than allowed
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrRedirectDisallowed is returned when a redirect leads to a URL disallowed by its host's robots.txt
+var ErrRedirectDisallowed = errors.New("redirect target disallowed by robots.txt")
+
+// rateWaitBuckets spans waits from none to several Crawl-Delay intervals, in seconds
+var rateWaitBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 2, 5, 10, 30}
+
 // RobotsRule represents a robots.txt rule
 type RobotsRule struct {
 	UserAgent  string
@@ -42,17 +79,83 @@ type RobotsParser struct {
 	baseURL string
 }
 
+// Robots policy statuses
+const (
+	RobotsLoaded   = "loaded"
+	RobotsNotFound = "not_found"
+	RobotsError    = "error"
+)
+
+// RobotsPolicy records the outcome of loading robots.txt for a host
+type RobotsPolicy struct {
+	Host              string  `json:"host"`
+	Status            string  `json:"status"`
+	Error             string  `json:"error,omitempty"`
+	RuleGroups        int     `json:"rule_groups"`
+	CrawlDelaySeconds float64 `json:"crawl_delay_seconds"`
+}
+
+// Options configures the Fetcher's HTTP client
+type Options struct {
+	Timeout               time.Duration // Whole request including reading the body (0 = none)
+	DialTimeout           time.Duration // Establishing a TCP connection (0 = none)
+	TLSHandshakeTimeout   time.Duration // TLS handshake (0 = none)
+	ResponseHeaderTimeout time.Duration // Waiting for response headers (0 = none)
+	IdleConnTimeout       time.Duration // Keeping an idle connection open (0 = forever)
+	MaxBodyBytes          int64         // Largest body read before ErrBodyTooLarge (0 = unlimited)
+	MaxRedirects          int           // Redirects followed before ErrTooManyRedirects (0 = none)
+}
+
+// DefaultOptions returns the options used by New
+func DefaultOptions() Options {
+	return Options{
+		Timeout:               DefaultTimeout,
+		DialTimeout:           DefaultDialTimeout,
+		TLSHandshakeTimeout:   DefaultTLSHandshakeTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		IdleConnTimeout:       DefaultIdleConnTimeout,
+		MaxBodyBytes:          DefaultMaxBodyBytes,
+		MaxRedirects:          DefaultMaxRedirects,
+	}
+}
+
+// Page is a fetched page
+type Page struct {
+	URL  string        // The URL the page was served from, after any redirects
+	Body io.ReadCloser // Must be closed by the caller
+}
+
 // Fetcher handles HTTP requests with rate limiting and retries
 type Fetcher struct {
 	client        *http.Client
+	maxBodyBytes  int64
+	maxRedirects  int
 	rateLimiter   *rate.Limiter
-	robots        *RobotsParser
-	verbose       bool
+	robots        *RobotsParser // Rules loaded by LoadRobotsTxt, checked before every fetch
+	logger        *slog.Logger
 	userRateLimit float64 // User-specified rate limit (0 = no limit)
+
+	// Rules for each host reached by a redirect, loaded on first use
+	hostRobotsMu sync.Mutex
+	hostRobots   map[string]*hostRobots
+
+	policyMu       sync.Mutex
+	robotsPolicies []RobotsPolicy
+
+	// Metrics, nil unless Instrument is called
+	fetchDuration *metrics.HistogramVec
+	responses     *metrics.CounterVec
+	rateWait      *metrics.HistogramVec
+}
+
+// New creates a new Fetcher with rate limiting, robots.txt compliance and the
+// default options. A nil logger discards all log output.
+func New(requestsPerSecond float64, logger *slog.Logger) *Fetcher {
+	return NewWithOptions(requestsPerSecond, DefaultOptions(), logger)
 }
 
-// New creates a new Fetcher with rate limiting and robots.txt compliance
-func New(requestsPerSecond float64, verbose bool) *Fetcher {
+// NewWithOptions creates a new Fetcher whose HTTP client uses opts
+func NewWithOptions(requestsPerSecond float64, opts Options, logger *slog.Logger) *Fetcher {
 	var limiter *rate.Limiter
 	if requestsPerSecond > 0 {
 		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), int(requestsPerSecond)+1)
@@ -61,88 +164,230 @@ func New(requestsPerSecond float64, verbose bool) *Fetcher {
 		limiter = rate.NewLimiter(rate.Inf, 0)
 	}
 
-	return &Fetcher{
+	f := &Fetcher{
 		client: &http.Client{
-			Timeout: DefaultTimeout,
+			Timeout: opts.Timeout,
 			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 100, // Increased for higher concurrency
-				IdleConnTimeout:     90 * time.Second,
+				DialContext:           (&net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+				ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+				MaxIdleConns:          100,
+				MaxIdleConnsPerHost:   100, // Increased for higher concurrency
+				IdleConnTimeout:       opts.IdleConnTimeout,
 			},
 		},
+		maxBodyBytes:  opts.MaxBodyBytes,
+		maxRedirects:  opts.MaxRedirects,
 		rateLimiter:   limiter,
-		verbose:       verbose,
+		logger:        logging.OrDiscard(logger),
 		userRateLimit: requestsPerSecond,
+		hostRobots:    make(map[string]*hostRobots),
 	}
+	f.client.CheckRedirect = f.checkRedirect
+	return f
+}
+
+// hostRobots holds the robots.txt rules of one host. Loading is retried until
+// it succeeds or the server answers with a client error.
+type hostRobots struct {
+	mu     sync.Mutex
+	loaded bool
+	parser *RobotsParser
+}
+
+// robotsRequestKey marks the context of a robots.txt request, whose redirects are not checked against robots.txt
+type robotsRequestKey struct{}
+
+// checkRedirect limits the number of redirects, waits for the rate limiter before
+// each hop and checks each target against the robots.txt of its host
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.maxRedirects {
+		return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, f.maxRedirects)
+	}
+	if err := f.rateLimiter.Wait(req.Context()); err != nil {
+		return fmt.Errorf("rate limiter error: %w", err)
+	}
+	if req.Context().Value(robotsRequestKey{}) != nil {
+		return nil
+	}
+
+	from := via[len(via)-1].URL
+	f.logger.Debug("following redirect", "from", from.String(), "to", req.URL.String())
+
+	if !f.allowedOnHost(req.Context(), req.URL) {
+		return fmt.Errorf("%w: %s", ErrRedirectDisallowed, req.URL)
+	}
+	return nil
+}
+
+// allowedOnHost checks target against the robots.txt of its own host, loading it on first use.
+// A host whose robots.txt fails to load is allowed this time and loaded again on the next
+// redirect to it. The load outlives ctx, so one canceled request cannot fail it for others.
+func (f *Fetcher) allowedOnHost(ctx context.Context, target *url.URL) bool {
+	f.hostRobotsMu.Lock()
+	entry, ok := f.hostRobots[target.Host]
+	if !ok {
+		entry = &hostRobots{}
+		f.hostRobots[target.Host] = entry
+	}
+	f.hostRobotsMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.loaded {
+		if err := f.rateLimiter.Wait(ctx); err != nil {
+			return true // The redirect fails on the canceled request anyway
+		}
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultTimeout)
+		parser, _, err := f.fetchRobotsTxt(loadCtx, target.Scheme+"://"+target.Host)
+		cancel()
+		if err != nil {
+			f.logger.Warn("failed to load robots.txt for redirect target", "host", target.Host, "error", err)
+			return true
+		}
+		entry.parser, entry.loaded = parser, true
+	}
+	return entry.parser.IsAllowed(target.String(), UserAgent)
+}
+
+// setHostRobots records already loaded rules for a host so redirects to it do not load them again
+func (f *Fetcher) setHostRobots(host string, parser *RobotsParser) {
+	entry := &hostRobots{parser: parser, loaded: true}
+
+	f.hostRobotsMu.Lock()
+	defer f.hostRobotsMu.Unlock()
+	f.hostRobots[host] = entry
 }
 
 // LoadRobotsTxt fetches and parses robots.txt for the given domain
 func (f *Fetcher) LoadRobotsTxt(ctx context.Context, baseURL string) error {
+	parser, found, err := f.fetchRobotsTxt(ctx, baseURL)
+	if err != nil {
+		return err
+	}
+
+	host := hostOf(baseURL)
+	f.robots = parser
+	f.setHostRobots(host, parser)
+	if !found {
+		return nil
+	}
+
+	// Apply crawl-delay from robots.txt if user didn't specify a rate limit
+	if f.userRateLimit == 0 {
+		crawlDelay := parser.GetCrawlDelay(UserAgent)
+		if crawlDelay > 0 {
+			// Convert crawl delay to requests per second
+			reqPerSec := 1.0 / crawlDelay.Seconds()
+			f.rateLimiter = rate.NewLimiter(rate.Limit(reqPerSec), 1)
+			f.logger.Info("applying robots.txt crawl-delay",
+				"host", host, "crawl_delay", crawlDelay, "requests_per_second", reqPerSec)
+		} else {
+			f.logger.Debug("no crawl-delay in robots.txt, using unlimited rate", "host", host)
+		}
+	} else {
+		f.logger.Debug("using user-specified rate limit", "requests_per_second", f.userRateLimit)
+	}
+
+	f.logger.Info("loaded robots.txt", "host", host, "rule_groups", len(parser.rules))
+
+	return nil
+}
+
+// fetchRobotsTxt fetches and parses robots.txt for baseURL's host and records the outcome.
+// A missing or otherwise unavailable robots.txt (any 4xx status) yields an empty parser
+// that allows everything and found = false.
+func (f *Fetcher) fetchRobotsTxt(ctx context.Context, baseURL string) (parser *RobotsParser, found bool, err error) {
 	parsedURL, err := url.Parse(baseURL)
 	if err != nil {
-		return fmt.Errorf("parsing base URL: %w", err)
+		return nil, false, fmt.Errorf("parsing base URL: %w", err)
 	}
 	robotsURL := parsedURL.Scheme + "://" + parsedURL.Host + "/robots.txt"
 
-	if f.verbose {
-		fmt.Printf("Fetching robots.txt from: %s\n", robotsURL)
-	}
+	policy := RobotsPolicy{Host: parsedURL.Host, Status: RobotsError}
+	defer func() {
+		if err != nil {
+			policy.Error = err.Error()
+		}
+		f.recordRobotsPolicy(policy)
+	}()
+
+	f.logger.Debug("fetching robots.txt", "url", robotsURL)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, robotsRequestKey{}, true), "GET", robotsURL, nil)
 	if err != nil {
-		return fmt.Errorf("creating robots.txt request: %w", err)
+		return nil, false, fmt.Errorf("creating robots.txt request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
 
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("fetching robots.txt: %w", err)
+		return nil, false, fmt.Errorf("fetching robots.txt: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 404 {
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
 		// No robots.txt means everything is allowed
-		if f.verbose {
-			fmt.Println("No robots.txt found - all URLs allowed")
-		}
-		f.robots = &RobotsParser{baseURL: baseURL}
-		return nil
+		f.logger.Info("no robots.txt found, all URLs allowed", "host", parsedURL.Host, "status", resp.StatusCode)
+		policy.Status = RobotsNotFound
+		return &RobotsParser{baseURL: baseURL}, false, nil
 	}
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("robots.txt returned status %d", resp.StatusCode)
+		return nil, false, fmt.Errorf("robots.txt returned status %d", resp.StatusCode)
 	}
 
-	parser, err := parseRobotsTxt(resp.Body, baseURL)
+	parser, err = parseRobotsTxt(resp.Body, baseURL)
 	if err != nil {
-		return fmt.Errorf("parsing robots.txt: %w", err)
+		return nil, false, fmt.Errorf("parsing robots.txt: %w", err)
 	}
 
-	f.robots = parser
+	policy.Status = RobotsLoaded
+	policy.RuleGroups = len(parser.rules)
+	policy.CrawlDelaySeconds = parser.GetCrawlDelay(UserAgent).Seconds()
 
-	// Apply crawl-delay from robots.txt if user didn't specify a rate limit
-	if f.userRateLimit == 0 {
-		crawlDelay := parser.GetCrawlDelay(UserAgent)
-		if crawlDelay > 0 {
-			// Convert crawl delay to requests per second
-			reqPerSec := 1.0 / crawlDelay.Seconds()
-			f.rateLimiter = rate.NewLimiter(rate.Limit(reqPerSec), 1)
-			if f.verbose {
-				fmt.Printf("Applying robots.txt Crawl-Delay: %v (%.2f req/sec)\n", crawlDelay, reqPerSec)
-			}
-		} else if f.verbose {
-			fmt.Println("No Crawl-Delay in robots.txt - using unlimited rate")
-		}
-	} else if f.verbose {
-		fmt.Printf("Using user-specified rate limit: %.1f req/sec\n", f.userRateLimit)
-	}
+	return parser, true, nil
+}
 
-	if f.verbose {
-		fmt.Printf("Loaded robots.txt with %d rule groups\n", len(parser.rules))
+// recordRobotsPolicy stores the robots.txt outcome for a host
+func (f *Fetcher) recordRobotsPolicy(policy RobotsPolicy) {
+	f.policyMu.Lock()
+	defer f.policyMu.Unlock()
+
+	f.robotsPolicies = append(f.robotsPolicies, policy)
+}
+
+// RobotsPolicies returns the robots.txt outcome for every host loaded so far
+func (f *Fetcher) RobotsPolicies() []RobotsPolicy {
+	f.policyMu.Lock()
+	defer f.policyMu.Unlock()
+
+	policies := make([]RobotsPolicy, len(f.robotsPolicies))
+	copy(policies, f.robotsPolicies)
+	return policies
+}
+
+// EffectiveRateLimit returns the request rate in force (0 = unlimited)
+func (f *Fetcher) EffectiveRateLimit() float64 {
+	limit := f.rateLimiter.Limit()
+	if limit == rate.Inf {
+		return 0
 	}
+	return float64(limit)
+}
 
-	return nil
+// Instrument registers the fetcher's metrics with reg
+func (f *Fetcher) Instrument(reg *metrics.Registry) {
+	f.fetchDuration = reg.NewHistogramVec("essay_fetch_duration_seconds",
+		"Time from sending an HTTP request to receiving the response headers, per attempt.",
+		metrics.DefBuckets, "host")
+	f.responses = reg.NewCounterVec("essay_fetch_responses_total",
+		"HTTP responses by status code; code is \"error\" when no response was received.",
+		"host", "code")
+	f.rateWait = reg.NewHistogramVec("essay_rate_limiter_wait_seconds",
+		"Time spent waiting for the rate limiter before each request.",
+		rateWaitBuckets)
 }
 
 // IsAllowed checks if a URL is allowed by robots.txt
@@ -155,23 +400,40 @@ func (f *Fetcher) IsAllowed(urlStr string) bool {
 	return f.robots.IsAllowed(urlStr, UserAgent)
 }
 
-// FetchURL fetches content from a URL with rate limiting, retries, and robots.txt compliance
-func (f *Fetcher) FetchURL(ctx context.Context, urlStr string) (io.ReadCloser, error) {
+// FetchURL fetches content from a URL with rate limiting, retries, and robots.txt compliance.
+// Redirects are followed up to the configured limit, each checked against its host's robots.txt,
+// and the returned Page records the final URL. The caller must close the page's body; reading
+// past the size limit fails with ErrBodyTooLarge.
+// If ctx carries a trace span, the rate limiter wait and each HTTP attempt are traced under it.
+func (f *Fetcher) FetchURL(ctx context.Context, urlStr string) (*Page, error) {
+	return f.FetchURLLimit(ctx, urlStr, f.maxBodyBytes)
+}
+
+// FetchURLLimit fetches a URL like FetchURL, but with its own body size limit
+// (0 = unlimited) in place of the configured one
+func (f *Fetcher) FetchURLLimit(ctx context.Context, urlStr string, maxBodyBytes int64) (*Page, error) {
 	// Check robots.txt compliance first
 	if !f.IsAllowed(urlStr) {
 		return nil, fmt.Errorf("URL disallowed by robots.txt: %s", urlStr)
 	}
 
 	var lastErr error
+	host := hostOf(urlStr)
 
 	for attempt := 0; attempt < MaxRetries; attempt++ {
 		// Wait for rate limiter
+		waitStart := time.Now()
+		_, waitSpan := tracing.Start(ctx, "rate_limiter.wait")
 		if err := f.rateLimiter.Wait(ctx); err != nil {
+			waitSpan.RecordError(err)
+			waitSpan.End()
 			return nil, fmt.Errorf("rate limiter error: %w", err)
 		}
+		waitSpan.End()
+		f.rateWait.Observe(time.Since(waitStart).Seconds())
 
-		if f.verbose && attempt > 0 {
-			fmt.Printf("Retrying %s (attempt %d/%d)\n", urlStr, attempt+1, MaxRetries)
+		if attempt > 0 {
+			f.logger.Debug("retrying", "url", urlStr, "attempt", attempt+1, "max_attempts", MaxRetries)
 		}
 
 		req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
@@ -186,47 +448,120 @@ func (f *Fetcher) FetchURL(ctx context.Context, urlStr string) (io.ReadCloser, e
 		// handles gzip/deflate compression AND decompression when we don't set it
 		req.Header.Set("Connection", "keep-alive")
 
+		_, attemptSpan := tracing.Start(ctx, "http.attempt",
+			tracing.String("http.method", req.Method),
+			tracing.String("url.full", urlStr),
+			tracing.Int("http.attempt", attempt+1))
+		requestStart := time.Now()
 		resp, err := f.client.Do(req)
+		f.fetchDuration.Observe(time.Since(requestStart).Seconds(), host)
 		if err != nil {
+			f.responses.Inc(host, "error")
 			lastErr = fmt.Errorf("HTTP request failed: %w", err)
-			f.backoff(attempt)
+			attemptSpan.RecordError(lastErr)
+			attemptSpan.End()
+
+			// Following the same redirects again would fail the same way
+			if errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrRedirectDisallowed) {
+				return nil, lastErr
+			}
+
+			f.backoff(urlStr, attempt)
 			continue
 		}
+		f.responses.Inc(host, strconv.Itoa(resp.StatusCode))
+		attemptSpan.SetAttributes(tracing.Int("http.status_code", resp.StatusCode))
 
 		// Check for HTTP errors
 		if resp.StatusCode >= 400 {
 			resp.Body.Close()
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+			attemptSpan.RecordError(lastErr)
+			attemptSpan.End()
 
 			// Don't retry client errors (4xx), but do retry server errors (5xx)
 			if resp.StatusCode < 500 {
 				return nil, lastErr
 			}
 
-			f.backoff(attempt)
+			f.backoff(urlStr, attempt)
 			continue
 		}
 
-		if f.verbose {
-			fmt.Printf("Successfully fetched %s (%s)\n", urlStr, resp.Header.Get("Content-Type"))
+		// A declared length over the limit fails now rather than after reading it
+		if maxBodyBytes > 0 && resp.ContentLength > maxBodyBytes {
+			resp.Body.Close()
+			err := fmt.Errorf("%w: %d bytes declared, limit %d", ErrBodyTooLarge, resp.ContentLength, maxBodyBytes)
+			attemptSpan.RecordError(err)
+			attemptSpan.End()
+			return nil, err
+		}
+
+		finalURL := resp.Request.URL.String()
+		if finalURL != urlStr {
+			attemptSpan.SetAttributes(tracing.String("url.final", finalURL))
 		}
+		attemptSpan.End()
+		f.logger.Debug("fetched", "url", urlStr, "final_url", finalURL,
+			"status", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"))
 
-		return resp.Body, nil
+		page := &Page{URL: finalURL, Body: resp.Body}
+		if maxBodyBytes > 0 {
+			page.Body = &limitedBody{body: resp.Body, limit: maxBodyBytes, remaining: maxBodyBytes}
+		}
+		return page, nil
 	}
 
 	return nil, fmt.Errorf("failed after %d attempts: %w", MaxRetries, lastErr)
 }
 
+// limitedBody fails reads with ErrBodyTooLarge once more than limit bytes arrive,
+// so an endless or oversized page cannot grow memory without bound
+type limitedBody struct {
+	body      io.ReadCloser
+	limit     int64
+	remaining int64 // Negative once the limit has been exceeded
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, b.limit)
+	}
+
+	// Ask for one byte past the limit so an oversized body is detected
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, b.limit)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// hostOf returns the host of a URL for metric labels
+func hostOf(urlStr string) string {
+	if u, err := url.Parse(urlStr); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "unknown"
+}
+
 // backoff implements exponential backoff with jitter
-func (f *Fetcher) backoff(attempt int) {
+func (f *Fetcher) backoff(urlStr string, attempt int) {
 	backoff := BackoffBase * time.Duration(1<<uint(attempt))
 	if backoff > 30*time.Second {
 		backoff = 30 * time.Second
 	}
 
-	if f.verbose {
-		fmt.Printf("Backing off for %v\n", backoff)
-	}
+	f.logger.Debug("backing off", "url", urlStr, "delay", backoff)
 
 	time.Sleep(backoff)
 }
@@ -1,10 +1,22 @@
 package fetcher
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/firefly/essay-analyzer/internal/metrics"
+	"github.com/firefly/essay-analyzer/internal/tracing"
+	"golang.org/x/time/rate"
 )
 
 func TestParseRobotsTxt_Complete(t *testing.T) {
@@ -233,7 +245,7 @@ func TestMatchesPattern(t *testing.T) {
 }
 
 func TestFetcher_IsAllowed_NoRobots(t *testing.T) {
-	fetcher := New(1.0, false)
+	fetcher := New(1.0, nil)
 	
 	// Without loading robots.txt, everything should be allowed
 	tests := []string{
@@ -253,7 +265,7 @@ func TestFetcher_IsAllowed_NoRobots(t *testing.T) {
 }
 
 func TestFetcher_IsAllowed_WithRobots(t *testing.T) {
-	fetcher := New(1.0, false)
+	fetcher := New(1.0, nil)
 	
 	// Mock robots.txt data for example.com
 	robotsTxt := `User-agent: *
@@ -295,7 +307,7 @@ Disallow: /restricted/`
 
 func TestRateLimiter_Basic(t *testing.T) {
 	// Test that rate limiter doesn't panic and allows some requests
-	fetcher := New(10.0, false) // 10 requests per second
+	fetcher := New(10.0, nil) // 10 requests per second
 	
 	ctx := context.Background()
 	
@@ -373,3 +385,432 @@ func parseURL(baseURL string) (*struct{ Scheme, Host string }, error) {
 	}
 	return nil, nil
 }
+
+func TestLoadRobotsTxt_RecordsPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private/\nCrawl-delay: 2\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	fetcher := New(0, nil)
+	if err := fetcher.LoadRobotsTxt(context.Background(), server.URL); err != nil {
+		t.Fatalf("LoadRobotsTxt failed: %v", err)
+	}
+
+	policies := fetcher.RobotsPolicies()
+	if len(policies) != 1 {
+		t.Fatalf("Expected 1 robots policy, got %d", len(policies))
+	}
+
+	policy := policies[0]
+	if policy.Host != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("Expected host %s, got %s", server.URL, policy.Host)
+	}
+	if policy.Status != RobotsLoaded || policy.RuleGroups != 1 || policy.CrawlDelaySeconds != 2 {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	// The crawl delay becomes the effective rate limit when the user set none
+	if rate := fetcher.EffectiveRateLimit(); rate != 0.5 {
+		t.Errorf("Expected effective rate limit 0.5, got %v", rate)
+	}
+}
+
+func TestLoadRobotsTxt_RecordsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	fetcher := New(0, nil)
+	if err := fetcher.LoadRobotsTxt(context.Background(), server.URL); err == nil {
+		t.Fatal("Expected error for failing robots.txt")
+	}
+
+	policies := fetcher.RobotsPolicies()
+	if len(policies) != 1 || policies[0].Status != RobotsError || policies[0].Error == "" {
+		t.Errorf("Expected a recorded error policy, got %+v", policies)
+	}
+}
+
+func TestFetchURL_Instrumented(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	reg := metrics.NewRegistry()
+	fetcher := New(0, nil)
+	fetcher.Instrument(reg)
+
+	page, err := fetcher.FetchURL(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("FetchURL failed: %v", err)
+	}
+	body := page.Body
+	body.Close()
+	if _, err := fetcher.FetchURL(context.Background(), server.URL+"/missing"); err == nil {
+		t.Fatal("Expected error for 404")
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if got := fetcher.responses.Value(host, "200"); got != 1 {
+		t.Errorf("Expected one 200 response, got %v", got)
+	}
+	if got := fetcher.responses.Value(host, "404"); got != 1 {
+		t.Errorf("Expected one 404 response, got %v", got)
+	}
+	if got := fetcher.fetchDuration.Count(host); got != 2 {
+		t.Errorf("Expected 2 latency observations, got %d", got)
+	}
+	if got := fetcher.rateWait.Count(); got != 2 {
+		t.Errorf("Expected 2 rate limiter waits, got %d", got)
+	}
+}
+
+func TestFetchURL_Traced(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer(&buf, "test")
+	ctx, root := tracer.Start(context.Background(), "url")
+
+	fetcher := New(0, nil)
+	if _, err := fetcher.FetchURL(ctx, server.URL+"/missing"); err == nil {
+		t.Fatal("Expected error for 404")
+	}
+	root.End()
+	tracer.Close()
+
+	out := buf.String()
+	for _, want := range []string{`"name":"rate_limiter.wait"`, `"name":"http.attempt"`, `"key":"http.status_code","value":{"intValue":"404"}`, `"code":2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected trace output to contain %s, got %s", want, out)
+		}
+	}
+}
+
+func TestFetchURL_InfiniteBodyIsBounded(t *testing.T) {
+	chunk := bytes.Repeat([]byte("<p>endless</p>"), 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stream without a Content-Length until the client hangs up
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	const limit = 1 << 20
+	opts := DefaultOptions()
+	opts.MaxBodyBytes = limit
+	fetcher := NewWithOptions(0, opts, nil)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	page, err := fetcher.FetchURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchURL failed: %v", err)
+	}
+	body := page.Body
+	n, err := io.Copy(io.Discard, body)
+	body.Close()
+
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("Expected ErrBodyTooLarge, got %v", err)
+	}
+	if n != limit {
+		t.Errorf("Expected exactly %d bytes before the limit, got %d", limit, n)
+	}
+	// Reading is streamed, so allocation stays far below what an unbounded read would reach
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("Expected bounded allocation, got %d bytes", allocated)
+	}
+}
+
+func TestFetchURL_DeclaredLengthOverLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(bytes.Repeat([]byte("a"), 2048))
+	}))
+	defer server.Close()
+
+	opts := DefaultOptions()
+	opts.MaxBodyBytes = 1024
+	fetcher := NewWithOptions(0, opts, nil)
+
+	if _, err := fetcher.FetchURL(context.Background(), server.URL); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("Expected ErrBodyTooLarge, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected an oversized body not to be retried, got %d requests", requests)
+	}
+}
+
+func TestFetchURL_BodyWithinLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>small</html>"))
+	}))
+	defer server.Close()
+
+	opts := DefaultOptions()
+	opts.MaxBodyBytes = int64(len("<html>small</html>"))
+	fetcher := NewWithOptions(0, opts, nil)
+
+	page, err := fetcher.FetchURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchURL failed: %v", err)
+	}
+	body := page.Body
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("Expected a body exactly at the limit to be read, got %v", err)
+	}
+	if string(data) != "<html>small</html>" {
+		t.Errorf("Expected full body, got %q", data)
+	}
+}
+
+func TestFetchURL_FollowsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2019/old-slug":
+			http.Redirect(w, r, "/2024/new-slug", http.StatusMovedPermanently)
+		case "/robots.txt":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("<html>article</html>"))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(0, nil)
+	page, err := fetcher.FetchURL(context.Background(), server.URL+"/2019/old-slug")
+	if err != nil {
+		t.Fatalf("FetchURL failed: %v", err)
+	}
+	page.Body.Close()
+
+	if expected := server.URL + "/2024/new-slug"; page.URL != expected {
+		t.Errorf("Expected final URL %s, got %s", expected, page.URL)
+	}
+}
+
+func TestFetchURL_TooManyRedirects(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", requests), http.StatusFound)
+	}))
+	defer server.Close()
+
+	opts := DefaultOptions()
+	opts.MaxRedirects = 2
+	fetcher := NewWithOptions(0, opts, nil)
+
+	if _, err := fetcher.FetchURL(context.Background(), server.URL); !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("Expected ErrTooManyRedirects, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected the original request and 2 redirects without retries, got %d requests", requests)
+	}
+}
+
+func TestFetchURL_RedirectChecksTargetRobots(t *testing.T) {
+	robotsRequests := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests++
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		w.Write([]byte("<html>article</html>"))
+	}))
+	defer target.Close()
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusMovedPermanently)
+	}))
+	defer source.Close()
+
+	fetcher := New(0, nil)
+
+	if _, err := fetcher.FetchURL(context.Background(), source.URL+"/private/article"); !errors.Is(err, ErrRedirectDisallowed) {
+		t.Fatalf("Expected ErrRedirectDisallowed, got %v", err)
+	}
+
+	page, err := fetcher.FetchURL(context.Background(), source.URL+"/public/article")
+	if err != nil {
+		t.Fatalf("Expected a redirect to an allowed path to succeed, got %v", err)
+	}
+	page.Body.Close()
+
+	if robotsRequests != 1 {
+		t.Errorf("Expected the target's robots.txt to be loaded once, got %d requests", robotsRequests)
+	}
+	policies := fetcher.RobotsPolicies()
+	if len(policies) != 1 || policies[0].Host != strings.TrimPrefix(target.URL, "http://") || policies[0].Status != RobotsLoaded {
+		t.Errorf("Expected the target's robots.txt policy to be recorded, got %+v", policies)
+	}
+}
+
+// newRedirectingServer redirects every request to the same path on target
+func newRedirectingServer(target string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target+r.URL.Path, http.StatusMovedPermanently)
+	}))
+}
+
+func TestFetchURL_RedirectRetriesFailedRobots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			// The first load fails on a server error, the retry succeeds
+			if robotsRequests.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		w.Write([]byte("<html>article</html>"))
+	}))
+	defer target.Close()
+	source := newRedirectingServer(target.URL)
+	defer source.Close()
+
+	fetcher := New(0, nil)
+	page, err := fetcher.FetchURL(context.Background(), source.URL+"/private/article")
+	if err != nil {
+		t.Fatalf("Expected a redirect to be allowed while robots.txt is unavailable, got %v", err)
+	}
+	page.Body.Close()
+
+	if _, err := fetcher.FetchURL(context.Background(), source.URL+"/private/article"); !errors.Is(err, ErrRedirectDisallowed) {
+		t.Fatalf("Expected ErrRedirectDisallowed once robots.txt loads, got %v", err)
+	}
+	if _, err := fetcher.FetchURL(context.Background(), source.URL+"/private/other"); !errors.Is(err, ErrRedirectDisallowed) {
+		t.Fatalf("Expected ErrRedirectDisallowed, got %v", err)
+	}
+	if n := robotsRequests.Load(); n != 2 {
+		t.Errorf("Expected robots.txt to be loaded again only after the failure, got %d requests", n)
+	}
+}
+
+func TestFetchURL_RedirectCachesUnavailableRobots(t *testing.T) {
+	var robotsRequests atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("<html>article</html>"))
+	}))
+	defer target.Close()
+	source := newRedirectingServer(target.URL)
+	defer source.Close()
+
+	fetcher := New(0, nil)
+	for i := 0; i < 2; i++ {
+		page, err := fetcher.FetchURL(context.Background(), source.URL+"/private/article")
+		if err != nil {
+			t.Fatalf("Expected a client error on robots.txt to allow everything, got %v", err)
+		}
+		page.Body.Close()
+	}
+	if n := robotsRequests.Load(); n != 1 {
+		t.Errorf("Expected a client error on robots.txt to be kept, got %d requests", n)
+	}
+	if policies := fetcher.RobotsPolicies(); len(policies) != 1 || policies[0].Status != RobotsNotFound {
+		t.Errorf("Expected an unavailable robots.txt policy, got %+v", policies)
+	}
+}
+
+func TestFetchURL_RedirectRobotsOutlivesCanceledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var robotsRequests atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			// The request that triggered the load is canceled while robots.txt is fetched
+			robotsRequests.Add(1)
+			cancel()
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		w.Write([]byte("<html>article</html>"))
+	}))
+	defer target.Close()
+	source := newRedirectingServer(target.URL)
+	defer source.Close()
+
+	fetcher := New(0, nil)
+	if _, err := fetcher.FetchURL(ctx, source.URL+"/public/article"); err == nil {
+		t.Fatal("Expected the canceled request to fail")
+	}
+	if _, err := fetcher.FetchURL(context.Background(), source.URL+"/private/article"); !errors.Is(err, ErrRedirectDisallowed) {
+		t.Fatalf("Expected the rules loaded for the canceled request to apply, got %v", err)
+	}
+	if n := robotsRequests.Load(); n != 1 {
+		t.Errorf("Expected robots.txt to be loaded once, got %d requests", n)
+	}
+}
+
+func TestFetchURL_RedirectsAreRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hop int
+		switch {
+		case r.URL.Path == "/robots.txt":
+			http.NotFound(w, r)
+		case r.URL.Path == "/article":
+			w.Write([]byte("<html>article</html>"))
+		default:
+			fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+			if hop > 0 {
+				http.Redirect(w, r, fmt.Sprintf("/hop/%d", hop-1), http.StatusFound)
+				return
+			}
+			http.Redirect(w, r, "/article", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(0, nil)
+	fetcher.rateLimiter = rate.NewLimiter(20, 1)
+
+	// The request, 4 hops and the redirect host's robots.txt each wait 50ms after the first
+	start := time.Now()
+	page, err := fetcher.FetchURL(context.Background(), server.URL+"/hop/3")
+	if err != nil {
+		t.Fatalf("FetchURL failed: %v", err)
+	}
+	page.Body.Close()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected redirects to wait for the rate limiter, took %v", elapsed)
+	}
+}
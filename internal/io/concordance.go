@@ -0,0 +1,41 @@
+package io
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/firefly/essay-analyzer/internal/concordance"
+)
+
+// concordanceLine is one context in a concordance file
+type concordanceLine struct {
+	Word string `json:"word"`
+	concordance.Context
+}
+
+// WriteConcordance writes a concordance to path as JSON Lines, one context per line
+// with the word it was sampled for
+func WriteConcordance(path string, entries []concordance.Entry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating concordance file: %w", err)
+	}
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		for _, context := range entry.Contexts {
+			if err := encoder.Encode(concordanceLine{Word: entry.Word, Context: context}); err != nil {
+				file.Close()
+				return fmt.Errorf("writing concordance file: %w", err)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("writing concordance file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,70 @@
+package io
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+)
+
+// ErrNotExport is returned by ReadExport when a file is not an aggregator export
+var ErrNotExport = errors.New("not an aggregator export")
+
+// Export is the full-fidelity state of a run: every word count and document
+// frequency rather than just the top words, so shards can be merged exactly.
+// Duplicates are only dropped within a shard, not across shards.
+type Export struct {
+	Aggregator *aggregator.Snapshot `json:"aggregator"`
+	Run        *RunManifest         `json:"run,omitempty"`
+}
+
+// WriteExport writes an export as JSON to path
+func WriteExport(path string, export *Export) error {
+	jsonData, err := json.Marshal(export)
+	if err != nil {
+		return fmt.Errorf("marshaling export to JSON: %w", err)
+	}
+
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("writing export file: %w", err)
+	}
+	return nil
+}
+
+// Result renders the export as its run's result, with as many top words as the
+// run reported
+func (e *Export) Result() (*Result, error) {
+	agg := aggregator.New(nil)
+	agg.RetainArticles()
+	if err := agg.Merge(e.Aggregator); err != nil {
+		return nil, err
+	}
+
+	topN := 0
+	if e.Run != nil {
+		topN = e.Run.TopWords
+	}
+	result := NewResult(agg, topN)
+	result.Run = e.Run
+	return result, nil
+}
+
+// ReadExport reads an export written by WriteExport
+func ReadExport(path string) (*Export, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading export file: %w", err)
+	}
+
+	var export Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotExport)
+	}
+	if export.Aggregator == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotExport)
+	}
+
+	return &export, nil
+}
@@ -0,0 +1,73 @@
+package io
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+)
+
+func TestExport_RoundTrip(t *testing.T) {
+	agg := aggregator.New(nil)
+	agg.AddResult(aggregator.ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 3, "science": 1}})
+
+	path := filepath.Join(t.TempDir(), "shard.json")
+	export := &Export{Aggregator: agg.Snapshot(), Run: &RunManifest{URLs: URLCounts{Succeeded: 1}}}
+	if err := WriteExport(path, export); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+
+	read, err := ReadExport(path)
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(read.Aggregator.WordCounts, export.Aggregator.WordCounts) {
+		t.Errorf("Word counts differ: %v vs %v", read.Aggregator.WordCounts, export.Aggregator.WordCounts)
+	}
+	if read.Run == nil || read.Run.URLs.Succeeded != 1 {
+		t.Errorf("Expected run manifest to round-trip, got %+v", read.Run)
+	}
+}
+
+func TestExport_Result(t *testing.T) {
+	agg := aggregator.New(nil)
+	agg.RetainArticles()
+	agg.AddResult(aggregator.ProcessingResult{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 3, "science": 1}})
+	agg.AddResult(aggregator.ProcessingResult{URL: "https://example.com/2", WordCounts: map[string]int{"technology": 1}})
+
+	path := filepath.Join(t.TempDir(), "job.json")
+	if err := WriteExport(path, &Export{Aggregator: agg.Snapshot(), Run: &RunManifest{TopWords: 1}}); err != nil {
+		t.Fatalf("WriteExport failed: %v", err)
+	}
+	export, err := ReadExport(path)
+	if err != nil {
+		t.Fatalf("ReadExport failed: %v", err)
+	}
+	result, err := export.Result()
+	if err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+
+	if want := []aggregator.WordCount{{Word: "technology", Count: 4}}; !reflect.DeepEqual(result.TopWords, want) {
+		t.Errorf("Expected the run's top word %+v, got %+v", want, result.TopWords)
+	}
+	// Per-article counts survive for the SQLite tables
+	if !reflect.DeepEqual(result.Articles, agg.GetArticles()) {
+		t.Errorf("Expected articles %+v, got %+v", agg.GetArticles(), result.Articles)
+	}
+	if result.Run != export.Run || result.TotalEssaysProcessed != 2 {
+		t.Errorf("Expected the run and totals of the export, got %+v", result)
+	}
+}
+
+func TestReadExport_RejectsResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	writeResults(t, FormatJSON, path, testResult())
+
+	if _, err := ReadExport(path); !errors.Is(err, ErrNotExport) {
+		t.Errorf("Expected ErrNotExport for a result file, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package io
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+)
+
+// sqliteMagic is the header every SQLite database file starts with
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// ReadResult loads a result written by a Writer. JSON files are read whole,
+// NDJSON logs yield their last (most recent) line and SQLite databases their
+// latest run. CSV, TSV and Markdown outputs cannot be read back because they
+// omit the run totals.
+func ReadResult(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading result file: %w", err)
+	}
+
+	if bytes.HasPrefix(data, sqliteMagic) {
+		return readSQLiteResult(path)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, fmt.Errorf("%s is not a JSON, NDJSON or SQLite result", path)
+	}
+
+	var result Result
+	if err := json.Unmarshal(data, &result); err == nil {
+		return &result, nil
+	}
+
+	// Not a single JSON document - treat it as NDJSON and take the latest run
+	lines := bytes.Split(data, []byte("\n"))
+	last := bytes.TrimSpace(lines[len(lines)-1])
+	if err := json.Unmarshal(last, &result); err != nil {
+		return nil, fmt.Errorf("decoding result in %s: %w", path, err)
+	}
+
+	return &result, nil
+}
+
+// readSQLiteResult loads the most recent run from a SQLite database
+func readSQLiteResult(path string) (*Result, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening SQLite database: %w", err)
+	}
+	defer db.Close()
+
+	var runID int64
+	var result Result
+	err = db.QueryRow(
+		`SELECT id, total_words_processed, total_essays_processed, processing_time_seconds
+		 FROM runs ORDER BY id DESC LIMIT 1`,
+	).Scan(&runID, &result.TotalWordsProcessed, &result.TotalEssaysProcessed, &result.ProcessingTimeSeconds)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s contains no runs", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading latest run: %w", err)
+	}
+
+	rows, err := db.Query(
+		`SELECT w.word, rw.count FROM run_words rw
+		 JOIN words w ON w.id = rw.word_id
+		 WHERE rw.run_id = ? ORDER BY rw.rank`, runID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("reading top words: %w", err)
+	}
+	defer rows.Close()
+
+	result.TopWords = []aggregator.WordCount{}
+	for rows.Next() {
+		var wc aggregator.WordCount
+		if err := rows.Scan(&wc.Word, &wc.Count); err != nil {
+			return nil, fmt.Errorf("reading top word: %w", err)
+		}
+		result.TopWords = append(result.TopWords, wc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading top words: %w", err)
+	}
+
+	var manifest string
+	err = db.QueryRow(`SELECT value FROM run_metadata WHERE run_id = ? AND key = 'manifest'`, runID).Scan(&manifest)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, fmt.Errorf("reading run manifest: %w", err)
+	default:
+		result.Run = &RunManifest{}
+		if err := json.Unmarshal([]byte(manifest), result.Run); err != nil {
+			return nil, fmt.Errorf("decoding run manifest: %w", err)
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,78 @@
+package io
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// writeResults writes each result to path with a fresh Writer
+func writeResults(t *testing.T, format, path string, results ...*Result) {
+	t.Helper()
+
+	for _, result := range results {
+		writer, err := NewWriter(format, path)
+		if err != nil {
+			t.Fatalf("NewWriter(%q) failed: %v", format, err)
+		}
+		if err := writer.Write(result); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+}
+
+func TestReadResult_RoundTrip(t *testing.T) {
+	first := testResult()
+	latest := testResult()
+	latest.TotalEssaysProcessed = 5
+	latest.Run = &RunManifest{ToolVersion: "1.2.3"}
+
+	tests := []struct {
+		format string
+		file   string
+	}{
+		{FormatJSON, "result.json"},
+		{FormatNDJSON, "results.ndjson"},
+		{FormatSQLite, "results.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if tt.format == FormatJSON {
+				writeResults(t, tt.format, path, latest)
+			} else {
+				writeResults(t, tt.format, path, first, latest)
+			}
+
+			result, err := ReadResult(path)
+			if err != nil {
+				t.Fatalf("ReadResult failed: %v", err)
+			}
+
+			if result.TotalEssaysProcessed != 5 {
+				t.Errorf("Expected the latest run (5 essays), got %d", result.TotalEssaysProcessed)
+			}
+			if result.TotalWordsProcessed != 30 {
+				t.Errorf("Expected 30 total words, got %d", result.TotalWordsProcessed)
+			}
+			if len(result.TopWords) != 2 || result.TopWords[0].Word != "technology" || result.TopWords[0].Count != 18 {
+				t.Errorf("Unexpected top words: %+v", result.TopWords)
+			}
+			if result.Run == nil || result.Run.ToolVersion != "1.2.3" {
+				t.Errorf("Expected run manifest to round-trip, got %+v", result.Run)
+			}
+		})
+	}
+}
+
+func TestReadResult_UnreadableFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.csv")
+	writeResults(t, FormatCSV, path, testResult())
+
+	if _, err := ReadResult(path); err == nil {
+		t.Fatal("Expected error reading a CSV result")
+	}
+}
@@ -0,0 +1,91 @@
+package io
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	stdio "io"
+	"os"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/parser"
+)
+
+// RunManifest records how a result was produced so runs can be audited and compared
+type RunManifest struct {
+	ToolVersion string    `json:"tool_version"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+
+	URLsFile FileDigest `json:"urls_file"`
+	WordBank FileDigest `json:"wordbank"`
+
+	Config             *config.Config          `json:"config"`
+	WorkerDistribution WorkerDistribution      `json:"worker_distribution"`
+	EffectiveRateLimit float64                 `json:"effective_rate_limit"`
+	TopWords           int                     `json:"top_words"`
+	Robots             []fetcher.RobotsPolicy  `json:"robots"`
+	Extractor          parser.ExtractorProfile `json:"extractor"`
+	URLs               URLCounts               `json:"urls"`
+
+	// MergedFrom lists the exports combined by the merge command
+	MergedFrom []FileDigest `json:"merged_from,omitempty"`
+}
+
+// FileDigest identifies an input file by path and content hash
+type FileDigest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// WorkerDistribution records how many workers ran in each pipeline stage
+type WorkerDistribution struct {
+	Fetchers   int `json:"fetchers"`
+	Parsers    int `json:"parsers"`
+	Processors int `json:"processors"`
+
+	// With auto-tuning the counts above are the starting point and Final the distribution at the end
+	AutoTuned bool                `json:"auto_tuned,omitempty"`
+	Final     *WorkerDistribution `json:"final,omitempty"`
+}
+
+// URLCounts summarizes what happened to the URLs read from the URLs file
+type URLCounts struct {
+	Queued    int64 `json:"queued"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	Skipped   int64 `json:"skipped"`
+
+	// Duplicates were dropped before fetching because they normalized to a URL already queued
+	Duplicates int64 `json:"duplicates"`
+
+	// NearDuplicates were fetched but left out of the counts because their text nearly matched an earlier article
+	NearDuplicates int64 `json:"near_duplicates"`
+
+	// CanonicalDuplicates were fetched but left out because their canonical link or final URL after redirects matched an earlier article
+	CanonicalDuplicates int64 `json:"canonical_duplicates"`
+
+	// LanguageSkipped were fetched but left out because their language was not counted or could not be identified
+	LanguageSkipped int64 `json:"language_skipped"`
+}
+
+// DigestFile hashes the file at path with SHA-256
+func DigestFile(path string) (FileDigest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return FileDigest{}, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := stdio.Copy(hash, file); err != nil {
+		return FileDigest{}, fmt.Errorf("hashing %s: %w", path, err)
+	}
+
+	return FileDigest{
+		Path:   path,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
@@ -1,11 +1,10 @@
 package io
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
-
 	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/textstats"
 )
 
 // Result represents the final analysis result for JSON output
@@ -14,56 +13,113 @@ type Result struct {
 	TotalWordsProcessed   int                    `json:"total_words_processed"`
 	TotalEssaysProcessed  int                    `json:"total_essays_processed"`
 	ProcessingTimeSeconds float64                `json:"processing_time_seconds"`
+	Run                   *RunManifest           `json:"run,omitempty"`
+
+	// Categories reports the counts of each wordbank category, when the wordbank has categories
+	Categories []aggregator.CategoryCount `json:"categories,omitempty"`
+
+	// Variants lists the forms credited to each top word other than the word itself,
+	// such as aliases and misspellings
+	Variants map[string][]aggregator.VariantCount `json:"variants,omitempty"`
+
+	// WordBankVersions reports the articles counted with each version of the wordbank,
+	// when it was reloaded during the run
+	WordBankVersions []aggregator.VersionCount `json:"wordbank_versions,omitempty"`
+
+	// Languages reports the articles counted in each language, most first, when
+	// languages were detected
+	Languages []aggregator.LanguageCount `json:"languages,omitempty"`
+
+	// Skipped reports the articles left uncounted for each reason, such as their
+	// language being unsupported
+	Skipped []aggregator.SkipCount `json:"skipped,omitempty"`
+
+	// Statistics reports the vocabulary and readability of the articles, when they
+	// were measured
+	Statistics *textstats.Statistics `json:"statistics,omitempty"`
+
+	// Concordance lists sample contexts of the top words, or of the words asked for,
+	// when contexts were kept
+	Concordance []concordance.Entry `json:"concordance,omitempty"`
+
+	// NearDuplicates lists the articles skipped as near duplicates, grouped by the article they matched
+	NearDuplicates []simhash.Cluster `json:"near_duplicates,omitempty"`
+
+	// Articles holds per-article word counts when the aggregator retained them.
+	// Only the SQLite format stores them.
+	Articles []aggregator.ProcessingResult `json:"-"`
 }
 
-// OutputResult outputs the final result as JSON to stdout
-func OutputResult(agg *aggregator.Aggregator, topN int) error {
+// NewResult builds a Result from the aggregator's current state
+func NewResult(agg *aggregator.Aggregator, topN int) *Result {
 	processed, totalWords, _, elapsed := agg.GetStats()
 	topWords := agg.GetTopWords(topN)
 
-	result := Result{
+	return &Result{
 		TopWords:              topWords,
 		TotalWordsProcessed:   totalWords,
 		TotalEssaysProcessed:  processed,
 		ProcessingTimeSeconds: elapsed,
+		Categories:            agg.GetCategories(topN),
+		Variants:              agg.GetVariants(topWords),
+		WordBankVersions:      agg.GetVersions(topN),
+		Languages:             agg.GetLanguages(topN),
+		Skipped:               agg.GetSkipped(),
+		NearDuplicates:        agg.GetNearDuplicates(),
+		Statistics:            agg.GetStatistics(),
+		Concordance:           agg.GetConcordance(topN),
+		Articles:              agg.GetArticles(),
 	}
+}
 
-	jsonData, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling result to JSON: %w", err)
+// statisticMeasure is one row of the statistics table in Markdown and SQLite output
+type statisticMeasure struct {
+	Name        string
+	Corpus      float64
+	ArticleMean float64
+	HasMean     bool // Whether the measure is also taken per article
+}
+
+// statisticMeasures flattens statistics into one row per measure
+func statisticMeasures(s *textstats.Statistics) []statisticMeasure {
+	c, m := s.Corpus, s.ArticleMeans
+	measures := []statisticMeasure{
+		{"type_token_ratio", c.TypeTokenRatio, m.TypeTokenRatio, true},
+		{"hapax_legomena", float64(c.HapaxLegomena), m.HapaxLegomena, true},
+		{"average_word_length", c.AverageWordLength, m.AverageWordLength, true},
+		{"average_sentence_length", c.AverageSentenceLength, m.AverageSentenceLength, true},
+		{"flesch_kincaid_grade", c.FleschKincaidGrade, m.FleschKincaidGrade, true},
+	}
+	if c.Zipf != nil {
+		measures = append(measures,
+			statisticMeasure{Name: "zipf_slope", Corpus: c.Zipf.Slope},
+			statisticMeasure{Name: "zipf_r_squared", Corpus: c.Zipf.RSquared},
+		)
 	}
+	return measures
+}
 
-	fmt.Println(string(jsonData))
-	return nil
+// OutputResult outputs the final result as JSON to stdout
+func OutputResult(agg *aggregator.Aggregator, topN int) error {
+	return OutputResultAs(agg, topN, FormatJSON, "")
 }
 
 // OutputResultToFile outputs the final result as JSON to a file
 func OutputResultToFile(agg *aggregator.Aggregator, topN int, filename string) error {
-	processed, totalWords, _, elapsed := agg.GetStats()
-	topWords := agg.GetTopWords(topN)
-
-	result := Result{
-		TopWords:              topWords,
-		TotalWordsProcessed:   totalWords,
-		TotalEssaysProcessed:  processed,
-		ProcessingTimeSeconds: elapsed,
-	}
-
-	jsonData, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling result to JSON: %w", err)
-	}
+	return OutputResultAs(agg, topN, FormatJSON, filename)
+}
 
-	file, err := os.Create(filename)
+// OutputResultAs outputs the final result in the given format to path ("" or "-" for stdout)
+func OutputResultAs(agg *aggregator.Aggregator, topN int, format, path string) error {
+	writer, err := NewWriter(format, path)
 	if err != nil {
-		return fmt.Errorf("creating output file: %w", err)
+		return err
 	}
-	defer file.Close()
 
-	_, err = file.Write(jsonData)
-	if err != nil {
-		return fmt.Errorf("writing to output file: %w", err)
+	if err := writer.Write(NewResult(agg, topN)); err != nil {
+		writer.Close()
+		return err
 	}
 
-	return nil
+	return writer.Close()
 }
@@ -0,0 +1,399 @@
+package io
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	// Registers the "sqlite3" database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema normalizes results into runs, a shared word dictionary,
+// per-run top word rankings with the variants credited to them, per-article
+// word counts, near-duplicate pairs, per-run category totals with their top words
+// the articles counted with each wordbank version and in each language, the
+// articles skipped for their language, vocabulary and readability statistics
+// per run and per article, and the sample contexts of the concordance.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id                      INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at              TEXT    NOT NULL,
+	total_words_processed   INTEGER NOT NULL,
+	total_essays_processed  INTEGER NOT NULL,
+	processing_time_seconds REAL    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS words (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	word TEXT    NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS run_words (
+	run_id  INTEGER NOT NULL REFERENCES runs(id),
+	word_id INTEGER NOT NULL REFERENCES words(id),
+	rank    INTEGER NOT NULL,
+	count   INTEGER NOT NULL,
+	PRIMARY KEY (run_id, word_id)
+);
+CREATE TABLE IF NOT EXISTS run_word_variants (
+	run_id  INTEGER NOT NULL REFERENCES runs(id),
+	word_id INTEGER NOT NULL REFERENCES words(id),
+	form    TEXT    NOT NULL,
+	count   INTEGER NOT NULL,
+	PRIMARY KEY (run_id, word_id, form)
+);
+CREATE TABLE IF NOT EXISTS articles (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	url    TEXT    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS article_words (
+	article_id INTEGER NOT NULL REFERENCES articles(id),
+	word_id    INTEGER NOT NULL REFERENCES words(id),
+	count      INTEGER NOT NULL,
+	PRIMARY KEY (article_id, word_id)
+);
+CREATE TABLE IF NOT EXISTS near_duplicates (
+	run_id        INTEGER NOT NULL REFERENCES runs(id),
+	url           TEXT    NOT NULL,
+	duplicate_url TEXT    NOT NULL,
+	distance      INTEGER NOT NULL,
+	PRIMARY KEY (run_id, duplicate_url)
+);
+CREATE TABLE IF NOT EXISTS run_categories (
+	run_id   INTEGER NOT NULL REFERENCES runs(id),
+	category TEXT    NOT NULL,
+	count    INTEGER NOT NULL,
+	share    REAL    NOT NULL,
+	PRIMARY KEY (run_id, category)
+);
+CREATE TABLE IF NOT EXISTS run_category_words (
+	run_id   INTEGER NOT NULL REFERENCES runs(id),
+	category TEXT    NOT NULL,
+	word_id  INTEGER NOT NULL REFERENCES words(id),
+	rank     INTEGER NOT NULL,
+	count    INTEGER NOT NULL,
+	PRIMARY KEY (run_id, category, word_id)
+);
+CREATE TABLE IF NOT EXISTS run_wordbank_versions (
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	version     TEXT    NOT NULL,
+	articles    INTEGER NOT NULL,
+	total_words INTEGER NOT NULL,
+	PRIMARY KEY (run_id, version)
+);
+CREATE TABLE IF NOT EXISTS run_languages (
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	language    TEXT    NOT NULL,
+	articles    INTEGER NOT NULL,
+	total_words INTEGER NOT NULL,
+	PRIMARY KEY (run_id, language)
+);
+CREATE TABLE IF NOT EXISTS run_skipped (
+	run_id   INTEGER NOT NULL REFERENCES runs(id),
+	reason   TEXT    NOT NULL,
+	language TEXT    NOT NULL,
+	articles INTEGER NOT NULL,
+	PRIMARY KEY (run_id, reason, language)
+);
+CREATE TABLE IF NOT EXISTS run_statistics (
+	run_id       INTEGER NOT NULL REFERENCES runs(id),
+	measure      TEXT    NOT NULL,
+	corpus       REAL    NOT NULL,
+	article_mean REAL,
+	PRIMARY KEY (run_id, measure)
+);
+CREATE TABLE IF NOT EXISTS article_statistics (
+	article_id           INTEGER PRIMARY KEY REFERENCES articles(id),
+	sentences            INTEGER NOT NULL,
+	words                INTEGER NOT NULL,
+	tokens               INTEGER NOT NULL,
+	types                INTEGER NOT NULL,
+	hapax_legomena       INTEGER NOT NULL,
+	type_token_ratio     REAL    NOT NULL,
+	average_word_length  REAL    NOT NULL,
+	flesch_kincaid_grade REAL    NOT NULL
+);
+CREATE TABLE IF NOT EXISTS run_concordance (
+	run_id        INTEGER NOT NULL REFERENCES runs(id),
+	word_id       INTEGER NOT NULL REFERENCES words(id),
+	position      INTEGER NOT NULL,
+	left_context  TEXT    NOT NULL,
+	match         TEXT    NOT NULL,
+	right_context TEXT    NOT NULL,
+	url           TEXT    NOT NULL,
+	PRIMARY KEY (run_id, word_id, position)
+);
+CREATE TABLE IF NOT EXISTS run_metadata (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	key    TEXT    NOT NULL,
+	value  TEXT    NOT NULL,
+	PRIMARY KEY (run_id, key)
+);
+CREATE INDEX IF NOT EXISTS articles_run_id ON articles(run_id);
+`
+
+// sqliteWriter stores each result as a new run in a SQLite database
+type sqliteWriter struct {
+	db *sql.DB
+}
+
+func newSQLiteWriter(path string) (*sqliteWriter, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening SQLite database: %w", err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating SQLite schema: %w", err)
+	}
+
+	return &sqliteWriter{db: db}, nil
+}
+
+func (w *sqliteWriter) Write(result *Result) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO runs (created_at, total_words_processed, total_essays_processed, processing_time_seconds)
+		 VALUES (?, ?, ?, ?)`,
+		time.Now().UTC().Format(time.RFC3339), result.TotalWordsProcessed,
+		result.TotalEssaysProcessed, result.ProcessingTimeSeconds,
+	)
+	if err != nil {
+		return fmt.Errorf("inserting run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("reading run id: %w", err)
+	}
+
+	metadata, err := runMetadata(result.Run)
+	if err != nil {
+		return err
+	}
+	for key, value := range metadata {
+		if _, err := tx.Exec(
+			`INSERT INTO run_metadata (run_id, key, value) VALUES (?, ?, ?)`,
+			runID, key, value,
+		); err != nil {
+			return fmt.Errorf("inserting run metadata %q: %w", key, err)
+		}
+	}
+
+	words := &wordIDs{tx: tx, ids: make(map[string]int64)}
+
+	for i, wc := range result.TopWords {
+		wordID, err := words.get(wc.Word)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO run_words (run_id, word_id, rank, count) VALUES (?, ?, ?, ?)`,
+			runID, wordID, i+1, wc.Count,
+		); err != nil {
+			return fmt.Errorf("inserting top word %q: %w", wc.Word, err)
+		}
+
+		for _, variant := range result.Variants[wc.Word] {
+			if _, err := tx.Exec(
+				`INSERT INTO run_word_variants (run_id, word_id, form, count) VALUES (?, ?, ?, ?)`,
+				runID, wordID, variant.Form, variant.Count,
+			); err != nil {
+				return fmt.Errorf("inserting variant %q of %q: %w", variant.Form, wc.Word, err)
+			}
+		}
+	}
+
+	for _, article := range result.Articles {
+		res, err := tx.Exec(`INSERT INTO articles (run_id, url) VALUES (?, ?)`, runID, article.URL)
+		if err != nil {
+			return fmt.Errorf("inserting article %s: %w", article.URL, err)
+		}
+		articleID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("reading article id: %w", err)
+		}
+
+		for word, count := range article.WordCounts {
+			wordID, err := words.get(word)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(
+				`INSERT INTO article_words (article_id, word_id, count) VALUES (?, ?, ?)`,
+				articleID, wordID, count,
+			); err != nil {
+				return fmt.Errorf("inserting count for %q: %w", word, err)
+			}
+		}
+
+		if stats := article.Statistics; stats != nil {
+			if _, err := tx.Exec(
+				`INSERT INTO article_statistics (article_id, sentences, words, tokens, types, hapax_legomena, type_token_ratio, average_word_length, flesch_kincaid_grade)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				articleID, stats.Sentences, stats.Words, stats.Tokens, stats.Types, stats.Hapax,
+				stats.TypeTokenRatio(), stats.AverageWordLength(), stats.FleschKincaidGrade(),
+			); err != nil {
+				return fmt.Errorf("inserting statistics of %s: %w", article.URL, err)
+			}
+		}
+	}
+
+	for _, entry := range result.Concordance {
+		wordID, err := words.get(entry.Word)
+		if err != nil {
+			return err
+		}
+		for i, context := range entry.Contexts {
+			if _, err := tx.Exec(
+				`INSERT INTO run_concordance (run_id, word_id, position, left_context, match, right_context, url) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				runID, wordID, i+1, context.Left, context.Match, context.Right, context.URL,
+			); err != nil {
+				return fmt.Errorf("inserting context of %q: %w", entry.Word, err)
+			}
+		}
+	}
+
+	if result.Statistics != nil {
+		for _, measure := range statisticMeasures(result.Statistics) {
+			mean := sql.NullFloat64{Float64: measure.ArticleMean, Valid: measure.HasMean}
+			if _, err := tx.Exec(
+				`INSERT INTO run_statistics (run_id, measure, corpus, article_mean) VALUES (?, ?, ?, ?)`,
+				runID, measure.Name, measure.Corpus, mean,
+			); err != nil {
+				return fmt.Errorf("inserting statistic %q: %w", measure.Name, err)
+			}
+		}
+	}
+
+	for _, category := range result.Categories {
+		if _, err := tx.Exec(
+			`INSERT INTO run_categories (run_id, category, count, share) VALUES (?, ?, ?, ?)`,
+			runID, category.Category, category.Count, category.Share,
+		); err != nil {
+			return fmt.Errorf("inserting category %q: %w", category.Category, err)
+		}
+
+		for i, wc := range category.TopWords {
+			wordID, err := words.get(wc.Word)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(
+				`INSERT INTO run_category_words (run_id, category, word_id, rank, count) VALUES (?, ?, ?, ?, ?)`,
+				runID, category.Category, wordID, i+1, wc.Count,
+			); err != nil {
+				return fmt.Errorf("inserting top word %q of category %q: %w", wc.Word, category.Category, err)
+			}
+		}
+	}
+
+	for _, version := range result.WordBankVersions {
+		if _, err := tx.Exec(
+			`INSERT INTO run_wordbank_versions (run_id, version, articles, total_words) VALUES (?, ?, ?, ?)`,
+			runID, version.Version, version.Articles, version.TotalWords,
+		); err != nil {
+			return fmt.Errorf("inserting wordbank version %q: %w", version.Version, err)
+		}
+	}
+
+	for _, language := range result.Languages {
+		if _, err := tx.Exec(
+			`INSERT INTO run_languages (run_id, language, articles, total_words) VALUES (?, ?, ?, ?)`,
+			runID, language.Language, language.Articles, language.TotalWords,
+		); err != nil {
+			return fmt.Errorf("inserting language %q: %w", language.Language, err)
+		}
+	}
+
+	for _, skip := range result.Skipped {
+		if _, err := tx.Exec(
+			`INSERT INTO run_skipped (run_id, reason, language, articles) VALUES (?, ?, ?, ?)`,
+			runID, skip.Reason, skip.Language, skip.Articles,
+		); err != nil {
+			return fmt.Errorf("inserting skipped articles %q: %w", skip.Reason, err)
+		}
+	}
+
+	for _, cluster := range result.NearDuplicates {
+		for _, dup := range cluster.Duplicates {
+			if _, err := tx.Exec(
+				`INSERT INTO near_duplicates (run_id, url, duplicate_url, distance) VALUES (?, ?, ?, ?)`,
+				runID, cluster.URL, dup.URL, dup.Distance,
+			); err != nil {
+				return fmt.Errorf("inserting near duplicate %s: %w", dup.URL, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing run: %w", err)
+	}
+	return nil
+}
+
+func (w *sqliteWriter) Close() error {
+	return w.db.Close()
+}
+
+// runMetadata flattens the run manifest into key/value rows. The complete
+// manifest is kept as JSON under the "manifest" key.
+func runMetadata(run *RunManifest) (map[string]string, error) {
+	if run == nil {
+		return nil, nil
+	}
+
+	manifest, err := json.Marshal(run)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling run manifest: %w", err)
+	}
+
+	return map[string]string{
+		"tool_version":              run.ToolVersion,
+		"started_at":                run.StartedAt.UTC().Format(time.RFC3339),
+		"finished_at":               run.FinishedAt.UTC().Format(time.RFC3339),
+		"urls_file":                 run.URLsFile.Path,
+		"urls_sha256":               run.URLsFile.SHA256,
+		"wordbank":                  run.WordBank.Path,
+		"wordbank_sha256":           run.WordBank.SHA256,
+		"urls_succeeded":            strconv.FormatInt(run.URLs.Succeeded, 10),
+		"urls_failed":               strconv.FormatInt(run.URLs.Failed, 10),
+		"urls_skipped":              strconv.FormatInt(run.URLs.Skipped, 10),
+		"urls_duplicates":           strconv.FormatInt(run.URLs.Duplicates, 10),
+		"urls_near_duplicates":      strconv.FormatInt(run.URLs.NearDuplicates, 10),
+		"urls_canonical_duplicates": strconv.FormatInt(run.URLs.CanonicalDuplicates, 10),
+		"urls_language_skipped":     strconv.FormatInt(run.URLs.LanguageSkipped, 10),
+		"manifest":                  string(manifest),
+	}, nil
+}
+
+// wordIDs resolves words to rows in the words table, caching lookups within a transaction
+type wordIDs struct {
+	tx  *sql.Tx
+	ids map[string]int64
+}
+
+func (w *wordIDs) get(word string) (int64, error) {
+	if id, ok := w.ids[word]; ok {
+		return id, nil
+	}
+
+	if _, err := w.tx.Exec(`INSERT OR IGNORE INTO words (word) VALUES (?)`, word); err != nil {
+		return 0, fmt.Errorf("inserting word %q: %w", word, err)
+	}
+
+	var id int64
+	if err := w.tx.QueryRow(`SELECT id FROM words WHERE word = ?`, word).Scan(&id); err != nil {
+		return 0, fmt.Errorf("looking up word %q: %w", word, err)
+	}
+
+	w.ids[word] = id
+	return id, nil
+}
@@ -0,0 +1,376 @@
+package io
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	stdio "io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported output formats
+const (
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatMarkdown = "markdown"
+	FormatSQLite   = "sqlite"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatJSON, FormatNDJSON, FormatCSV, FormatTSV, FormatMarkdown, FormatSQLite}
+
+// Writer renders analysis results in a particular output format
+type Writer interface {
+	// Write renders a single result
+	Write(result *Result) error
+	// Close flushes buffered output and releases the destination
+	Close() error
+}
+
+// ValidateFormat reports whether format names a supported output format
+func ValidateFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(Formats, ", "))
+}
+
+// IsStdout reports whether path refers to standard output
+func IsStdout(path string) bool {
+	return path == "" || path == "-"
+}
+
+// NewWriter returns a Writer for format that writes to path ("" or "-" for stdout).
+// Other text formats replace path only once the Writer is closed after a
+// successful write, so a failed run leaves any earlier output intact. NDJSON
+// output is appended to an existing file so repeated runs build up a log;
+// SQLite output adds a new run to an existing database.
+func NewWriter(format, path string) (Writer, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+
+	if format == FormatSQLite {
+		if IsStdout(path) {
+			return nil, fmt.Errorf("the %s format requires --output", FormatSQLite)
+		}
+		return newSQLiteWriter(path)
+	}
+
+	if IsStdout(path) {
+		return NewStreamWriter(format, nopCloser{os.Stdout})
+	}
+
+	if format == FormatNDJSON {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("creating output file: %w", err)
+		}
+		return NewStreamWriter(format, file)
+	}
+
+	file, err := createAtomicFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamWriter(format, file)
+}
+
+// atomicFile writes to a temporary file beside path and renames it over path
+// when closed. Nothing replaces path if nothing was written or a write failed.
+type atomicFile struct {
+	file    *os.File
+	path    string
+	written bool
+	failed  bool
+	closed  bool
+}
+
+// createAtomicFile creates the temporary file for path in the same directory,
+// so the rename cannot cross file systems
+func createAtomicFile(path string) (*atomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+	return &atomicFile{file: file, path: path}, nil
+}
+
+func (f *atomicFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.written = f.written || n > 0
+	f.failed = f.failed || err != nil
+	return n, err
+}
+
+// Close renames the temporary file into place, or removes it when the output is
+// incomplete. Closing again does nothing.
+func (f *atomicFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	err := f.file.Close()
+	if err == nil && f.written && !f.failed {
+		if err = os.Rename(f.file.Name(), f.path); err == nil {
+			return nil
+		}
+	}
+	os.Remove(f.file.Name())
+	if err != nil {
+		return fmt.Errorf("closing output file: %w", err)
+	}
+	return nil
+}
+
+// NewStreamWriter returns a Writer for a text format that writes to w.
+// w is closed when the Writer is closed.
+func NewStreamWriter(format string, w stdio.WriteCloser) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{out: w, indent: true}, nil
+	case FormatNDJSON:
+		return &jsonWriter{out: w}, nil
+	case FormatCSV:
+		return newDelimitedWriter(w, ','), nil
+	case FormatTSV:
+		return newDelimitedWriter(w, '\t'), nil
+	case FormatMarkdown:
+		return &markdownWriter{out: w}, nil
+	case FormatSQLite:
+		return nil, fmt.Errorf("the %s format cannot be written to a stream", FormatSQLite)
+	}
+	return nil, ValidateFormat(format)
+}
+
+// nopCloser keeps stdout open when a Writer is closed
+type nopCloser struct {
+	stdio.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// jsonWriter writes results as indented JSON, or as one compact line per result (NDJSON)
+type jsonWriter struct {
+	out    stdio.WriteCloser
+	indent bool
+}
+
+func (w *jsonWriter) Write(result *Result) error {
+	var jsonData []byte
+	var err error
+	if w.indent {
+		jsonData, err = json.MarshalIndent(result, "", "  ")
+	} else {
+		jsonData, err = json.Marshal(result)
+	}
+	if err != nil {
+		return fmt.Errorf("marshaling result to JSON: %w", err)
+	}
+
+	if _, err := w.out.Write(append(jsonData, '\n')); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	return w.out.Close()
+}
+
+// delimitedWriter writes the ranked top words as CSV or TSV
+type delimitedWriter struct {
+	out stdio.WriteCloser
+	csv *csv.Writer
+}
+
+func newDelimitedWriter(w stdio.WriteCloser, comma rune) *delimitedWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	return &delimitedWriter{out: w, csv: cw}
+}
+
+func (w *delimitedWriter) Write(result *Result) error {
+	if err := w.csv.Write([]string{"rank", "word", "count"}); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+
+	for i, wc := range result.TopWords {
+		record := []string{strconv.Itoa(i + 1), wc.Word, strconv.Itoa(wc.Count)}
+		if err := w.csv.Write(record); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+func (w *delimitedWriter) Close() error {
+	return w.out.Close()
+}
+
+// markdownWriter writes a summary followed by a Markdown table of the top words
+type markdownWriter struct {
+	out stdio.WriteCloser
+}
+
+func (w *markdownWriter) Write(result *Result) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "- Total essays processed: %d\n", result.TotalEssaysProcessed)
+	fmt.Fprintf(&b, "- Total words processed: %d\n", result.TotalWordsProcessed)
+	fmt.Fprintf(&b, "- Processing time: %.1f seconds\n", result.ProcessingTimeSeconds)
+	if run := result.Run; run != nil {
+		fmt.Fprintf(&b, "- Tool version: %s\n", run.ToolVersion)
+		fmt.Fprintf(&b, "- Started: %s\n", run.StartedAt.Format(time.RFC3339))
+		fmt.Fprintf(&b, "- Finished: %s\n", run.FinishedAt.Format(time.RFC3339))
+		fmt.Fprintf(&b, "- URLs: %d succeeded, %d failed, %d skipped, %d duplicates dropped, %d near duplicates skipped\n",
+			run.URLs.Succeeded, run.URLs.Failed, run.URLs.Skipped+run.URLs.LanguageSkipped, run.URLs.Duplicates+run.URLs.CanonicalDuplicates, run.URLs.NearDuplicates)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("| Rank | Word | Count |\n")
+	b.WriteString("|-----:|------|------:|\n")
+	for i, wc := range result.TopWords {
+		fmt.Fprintf(&b, "| %d | %s | %d |\n", i+1, escapeMarkdown(wc.Word), wc.Count)
+	}
+
+	if len(result.Categories) > 0 {
+		b.WriteString("\n## Categories\n\n")
+		b.WriteString("| Category | Count | Share | Top Words |\n")
+		b.WriteString("|----------|------:|------:|-----------|\n")
+		for _, category := range result.Categories {
+			words := make([]string, len(category.TopWords))
+			for i, wc := range category.TopWords {
+				words[i] = fmt.Sprintf("%s (%d)", escapeMarkdown(wc.Word), wc.Count)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %.1f%% | %s |\n",
+				escapeMarkdown(category.Category), category.Count, category.Share*100, strings.Join(words, ", "))
+		}
+	}
+
+	if len(result.Variants) > 0 {
+		b.WriteString("\n## Variants\n\n")
+		b.WriteString("| Word | Variants |\n")
+		b.WriteString("|------|----------|\n")
+		for _, wc := range result.TopWords {
+			variants := result.Variants[wc.Word]
+			if len(variants) == 0 {
+				continue
+			}
+			forms := make([]string, len(variants))
+			for i, v := range variants {
+				forms[i] = fmt.Sprintf("%s (%d)", escapeMarkdown(v.Form), v.Count)
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", escapeMarkdown(wc.Word), strings.Join(forms, ", "))
+		}
+	}
+
+	if len(result.WordBankVersions) > 0 {
+		b.WriteString("\n## Wordbank Versions\n\n")
+		b.WriteString("| Version | Articles | Words | Top Words |\n")
+		b.WriteString("|---------|---------:|------:|-----------|\n")
+		for _, version := range result.WordBankVersions {
+			words := make([]string, len(version.TopWords))
+			for i, wc := range version.TopWords {
+				words[i] = fmt.Sprintf("%s (%d)", escapeMarkdown(wc.Word), wc.Count)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n",
+				escapeMarkdown(version.Version), version.Articles, version.TotalWords, strings.Join(words, ", "))
+		}
+	}
+
+	if len(result.Languages) > 0 {
+		b.WriteString("\n## Languages\n\n")
+		b.WriteString("| Language | Articles | Words | Top Words |\n")
+		b.WriteString("|----------|---------:|------:|-----------|\n")
+		for _, language := range result.Languages {
+			words := make([]string, len(language.TopWords))
+			for i, wc := range language.TopWords {
+				words[i] = fmt.Sprintf("%s (%d)", escapeMarkdown(wc.Word), wc.Count)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n",
+				escapeMarkdown(language.Language), language.Articles, language.TotalWords, strings.Join(words, ", "))
+		}
+	}
+
+	if len(result.Skipped) > 0 {
+		b.WriteString("\n## Skipped Articles\n\n")
+		for _, skip := range result.Skipped {
+			if skip.Language != "" {
+				fmt.Fprintf(&b, "- %s (%s): %d\n", skip.Reason, escapeMarkdown(skip.Language), skip.Articles)
+			} else {
+				fmt.Fprintf(&b, "- %s: %d\n", skip.Reason, skip.Articles)
+			}
+		}
+	}
+
+	if result.Statistics != nil {
+		corpus := result.Statistics.Corpus
+		b.WriteString("\n## Statistics\n\n")
+		fmt.Fprintf(&b, "%d tokens of %d distinct, in %d sentences of %d words across %d articles\n\n",
+			corpus.Tokens, corpus.Types, corpus.Sentences, corpus.Words, result.Statistics.ArticleMeans.Articles)
+		b.WriteString("| Measure | Corpus | Article Mean |\n")
+		b.WriteString("|---------|-------:|-------------:|\n")
+		for _, measure := range statisticMeasures(result.Statistics) {
+			mean := "-"
+			if measure.HasMean {
+				mean = fmt.Sprintf("%.3f", measure.ArticleMean)
+			}
+			fmt.Fprintf(&b, "| %s | %.3f | %s |\n", measure.Name, measure.Corpus, mean)
+		}
+	}
+
+	if len(result.Concordance) > 0 {
+		b.WriteString("\n## Concordance\n")
+		for _, entry := range result.Concordance {
+			fmt.Fprintf(&b, "\n### %s\n\n", entry.Word)
+			for _, context := range entry.Contexts {
+				fmt.Fprintf(&b, "- %s\n", strings.TrimSpace(fmt.Sprintf("%s **%s** %s", context.Left, context.Match, context.Right)))
+				fmt.Fprintf(&b, "  <%s>\n", context.URL)
+			}
+		}
+	}
+
+	if len(result.NearDuplicates) > 0 {
+		b.WriteString("\n## Near Duplicates\n\n")
+		for _, cluster := range result.NearDuplicates {
+			fmt.Fprintf(&b, "- %s\n", cluster.URL)
+			for _, dup := range cluster.Duplicates {
+				fmt.Fprintf(&b, "  - %s (distance %d)\n", dup.URL, dup.Distance)
+			}
+		}
+	}
+
+	if _, err := stdio.WriteString(w.out, b.String()); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+func (w *markdownWriter) Close() error {
+	return w.out.Close()
+}
+
+// escapeMarkdown escapes characters that would break a Markdown table cell
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,666 @@
+package io
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/simhash"
+	"github.com/firefly/essay-analyzer/internal/textstats"
+)
+
+// closeBuffer is a bytes.Buffer that satisfies io.WriteCloser
+type closeBuffer struct {
+	bytes.Buffer
+}
+
+func (*closeBuffer) Close() error { return nil }
+
+func testResult() *Result {
+	return &Result{
+		TopWords: []aggregator.WordCount{
+			{Word: "technology", Count: 18},
+			{Word: "science", Count: 7},
+		},
+		TotalWordsProcessed:   30,
+		TotalEssaysProcessed:  2,
+		ProcessingTimeSeconds: 1.5,
+		Articles: []aggregator.ProcessingResult{
+			{URL: "https://example.com/1", WordCounts: map[string]int{"technology": 10, "computer": 3}},
+			{URL: "https://example.com/2", WordCounts: map[string]int{"technology": 8, "science": 7}},
+		},
+	}
+}
+
+func writeToBuffer(t *testing.T, format string) string {
+	t.Helper()
+
+	buf := &closeBuffer{}
+	writer, err := NewStreamWriter(format, buf)
+	if err != nil {
+		t.Fatalf("NewStreamWriter(%q) failed: %v", format, err)
+	}
+	if err := writer.Write(testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestJSONWriter(t *testing.T) {
+	out := writeToBuffer(t, FormatJSON)
+
+	var decoded Result
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded.TotalEssaysProcessed != 2 || len(decoded.TopWords) != 2 {
+		t.Errorf("Unexpected decoded result: %+v", decoded)
+	}
+	if strings.Contains(out, "example.com") {
+		t.Error("Expected per-article counts to be omitted from JSON")
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	out := writeToBuffer(t, FormatNDJSON)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line, got %d", len(lines))
+	}
+
+	var decoded Result
+	if err := json.Unmarshal([]byte(lines[0]), &decoded); err != nil {
+		t.Fatalf("Line is not valid JSON: %v", err)
+	}
+}
+
+func TestDelimitedWriters(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatCSV, "rank,word,count\n1,technology,18\n2,science,7\n"},
+		{FormatTSV, "rank\tword\tcount\n1\ttechnology\t18\n2\tscience\t7\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if out := writeToBuffer(t, tt.format); out != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	out := writeToBuffer(t, FormatMarkdown)
+
+	for _, want := range []string{
+		"- Total essays processed: 2",
+		"| Rank | Word | Count |",
+		"| 1 | technology | 18 |",
+		"| 2 | science | 7 |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriters_NearDuplicates(t *testing.T) {
+	result := testResult()
+	result.NearDuplicates = []simhash.Cluster{{
+		URL:        "https://example.com/1",
+		Duplicates: []simhash.Duplicate{{URL: "https://mirror.example.org/1", Distance: 2}},
+	}}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Near Duplicates",
+		"- https://example.com/1\n  - https://mirror.example.org/1 (distance 2)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var url string
+	var distance int
+	err = db.QueryRow(`SELECT url, distance FROM near_duplicates WHERE duplicate_url = 'https://mirror.example.org/1'`).Scan(&url, &distance)
+	if err != nil {
+		t.Fatalf("Querying near duplicates failed: %v", err)
+	}
+	if url != "https://example.com/1" || distance != 2 {
+		t.Errorf("Expected example.com/1 at distance 2, got %s at %d", url, distance)
+	}
+}
+
+func TestWriters_Categories(t *testing.T) {
+	result := testResult()
+	result.Categories = []aggregator.CategoryCount{{
+		Category: "privacy",
+		Count:    9,
+		Share:    0.25,
+		TopWords: []aggregator.WordCount{{Word: "tracking", Count: 5}, {Word: "privacy", Count: 4}},
+	}}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Categories",
+		"| privacy | 9 | 25.0% | tracking (5), privacy (4) |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	var share float64
+	if err := db.QueryRow(`SELECT count, share FROM run_categories WHERE category = 'privacy'`).Scan(&count, &share); err != nil {
+		t.Fatalf("Querying categories failed: %v", err)
+	}
+	if count != 9 || share != 0.25 {
+		t.Errorf("Expected privacy count 9 and share 0.25, got %d and %v", count, share)
+	}
+
+	var word string
+	err = db.QueryRow(`SELECT w.word FROM run_category_words c JOIN words w ON w.id = c.word_id
+		WHERE c.category = 'privacy' AND c.rank = 1`).Scan(&word)
+	if err != nil {
+		t.Fatalf("Querying category words failed: %v", err)
+	}
+	if word != "tracking" {
+		t.Errorf("Expected tracking to rank first in privacy, got %s", word)
+	}
+}
+
+func TestNewWriter_UnsupportedFormat(t *testing.T) {
+	if _, err := NewWriter("xml", ""); err == nil {
+		t.Fatal("Expected error for unsupported format")
+	}
+}
+
+func TestNewWriter_SQLiteRequiresOutput(t *testing.T) {
+	if _, err := NewWriter(FormatSQLite, "-"); err == nil {
+		t.Fatal("Expected error when writing SQLite to stdout")
+	}
+}
+
+func TestNewWriter_ReplacesOnClose(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "result.csv")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readOutput := func() string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// A writer closed without a result leaves the earlier output alone
+	writer, err := NewWriter(FormatCSV, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readOutput(); got != "earlier\n" {
+		t.Errorf("Expected the earlier output to be kept, got %q", got)
+	}
+
+	writer, err = NewWriter(FormatCSV, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := writer.Write(testResult()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := readOutput(); got != "earlier\n" {
+		t.Errorf("Expected the earlier output until Close, got %q", got)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readOutput(); !strings.HasPrefix(got, "rank,word,count") {
+		t.Errorf("Expected the new output after Close, got %q", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file to remain, got %d entries", len(entries))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+}
+
+func TestNewWriter_NDJSONAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.ndjson")
+
+	for i := 0; i < 2; i++ {
+		writer, err := NewWriter(FormatNDJSON, path)
+		if err != nil {
+			t.Fatalf("NewWriter failed: %v", err)
+		}
+		if err := writer.Write(testResult()); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		writer.Close()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading output failed: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 appended lines, got %d", lines)
+	}
+}
+
+func TestSQLiteWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+
+	// Write two runs to check that the word dictionary is shared
+	for i := 0; i < 2; i++ {
+		writer, err := NewWriter(FormatSQLite, path)
+		if err != nil {
+			t.Fatalf("NewWriter failed: %v", err)
+		}
+		if err := writer.Write(testResult()); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	counts := map[string]int{
+		"SELECT COUNT(*) FROM runs":          2,
+		"SELECT COUNT(*) FROM words":         3,
+		"SELECT COUNT(*) FROM run_words":     4,
+		"SELECT COUNT(*) FROM articles":      4,
+		"SELECT COUNT(*) FROM article_words": 8,
+	}
+	for query, expected := range counts {
+		var got int
+		if err := db.QueryRow(query).Scan(&got); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+		if got != expected {
+			t.Errorf("%s = %d, expected %d", query, got, expected)
+		}
+	}
+
+	var word string
+	var count int
+	err = db.QueryRow(`
+		SELECT w.word, aw.count FROM article_words aw
+		JOIN words w ON w.id = aw.word_id
+		JOIN articles a ON a.id = aw.article_id
+		WHERE a.url = 'https://example.com/1' AND w.word = 'computer' AND a.run_id = 1`).Scan(&word, &count)
+	if err != nil {
+		t.Fatalf("Querying article counts failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected computer count 3 for article 1, got %d", count)
+	}
+}
+
+func TestWriters_Variants(t *testing.T) {
+	result := testResult()
+	result.Variants = map[string][]aggregator.VariantCount{
+		"technology": {{Form: "tech nology", Count: 3}, {Form: "technolgy", Count: 1}},
+	}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Variants",
+		"| technology | tech nology (3), technolgy (1) |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	err = db.QueryRow(`SELECT v.count FROM run_word_variants v JOIN words w ON w.id = v.word_id
+		WHERE w.word = 'technology' AND v.form = 'technolgy'`).Scan(&count)
+	if err != nil {
+		t.Fatalf("Querying variants failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected technolgy to be counted once, got %d", count)
+	}
+}
+
+func TestWriters_WordBankVersions(t *testing.T) {
+	result := testResult()
+	result.WordBankVersions = []aggregator.VersionCount{
+		{Version: "3f2a9c1d0b7e", Articles: 2, TotalWords: 9, TopWords: []aggregator.WordCount{{Word: "robot", Count: 5}}},
+		{Version: "a41c07e5d2f8", Articles: 1, TotalWords: 4, TopWords: []aggregator.WordCount{{Word: "laptop", Count: 4}}},
+	}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Wordbank Versions",
+		"| 3f2a9c1d0b7e | 2 | 9 | robot (5) |",
+		"| a41c07e5d2f8 | 1 | 4 | laptop (4) |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var articles, totalWords int
+	err = db.QueryRow(`SELECT articles, total_words FROM run_wordbank_versions WHERE version = 'a41c07e5d2f8'`).Scan(&articles, &totalWords)
+	if err != nil {
+		t.Fatalf("Querying wordbank versions failed: %v", err)
+	}
+	if articles != 1 || totalWords != 4 {
+		t.Errorf("Expected 1 article and 4 words, got %d and %d", articles, totalWords)
+	}
+}
+
+func TestWriters_Languages(t *testing.T) {
+	result := testResult()
+	result.Languages = []aggregator.LanguageCount{
+		{Language: "en", Articles: 3, TotalWords: 12, TopWords: []aggregator.WordCount{{Word: "robot", Count: 5}}},
+		{Language: "es", Articles: 1, TotalWords: 4, TopWords: []aggregator.WordCount{{Word: "señal", Count: 2}}},
+	}
+	result.Skipped = []aggregator.SkipCount{
+		{Reason: "unsupported", Language: "de", Articles: 2},
+		{Reason: "undetermined", Articles: 1},
+	}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Languages",
+		"| en | 3 | 12 | robot (5) |",
+		"| es | 1 | 4 | señal (2) |",
+		"- unsupported (de): 2",
+		"- undetermined: 1",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var articles int
+	if err := db.QueryRow(`SELECT articles FROM run_languages WHERE language = 'es'`).Scan(&articles); err != nil {
+		t.Fatalf("Querying languages failed: %v", err)
+	}
+	if articles != 1 {
+		t.Errorf("Expected 1 Spanish article, got %d", articles)
+	}
+	if err := db.QueryRow(`SELECT articles FROM run_skipped WHERE reason = 'unsupported' AND language = 'de'`).Scan(&articles); err != nil {
+		t.Fatalf("Querying skipped articles failed: %v", err)
+	}
+	if articles != 2 {
+		t.Errorf("Expected 2 skipped German articles, got %d", articles)
+	}
+}
+
+func TestWriters_Statistics(t *testing.T) {
+	result := testResult()
+	article := textstats.NewArticle("Technology is here. Computers too.", map[string]int{"technology": 1, "is": 1, "here": 1, "computers": 1, "too": 1})
+	result.Articles[0].Statistics = &article
+	var totals textstats.Totals
+	totals.Add(article, 1)
+	result.Statistics = totals.Summarize()
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Statistics",
+		"5 tokens of 5 distinct, in 2 sentences of 5 words across 1 articles",
+		"| type_token_ratio | 1.000 | 1.000 |",
+		"| zipf_slope |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var mean sql.NullFloat64
+	if err := db.QueryRow(`SELECT article_mean FROM run_statistics WHERE measure = 'zipf_slope'`).Scan(&mean); err != nil {
+		t.Fatalf("Querying statistics failed: %v", err)
+	}
+	if mean.Valid {
+		t.Errorf("Expected no article mean for the Zipf slope, got %f", mean.Float64)
+	}
+	var words, rows int
+	if err := db.QueryRow(`SELECT words FROM article_statistics`).Scan(&words); err != nil {
+		t.Fatalf("Querying article statistics failed: %v", err)
+	}
+	if words != 5 {
+		t.Errorf("Expected 5 words in the article, got %d", words)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM article_statistics`).Scan(&rows); err != nil || rows != 1 {
+		t.Errorf("Expected statistics for the one measured article, got %d rows (%v)", rows, err)
+	}
+}
+
+func TestWriters_Concordance(t *testing.T) {
+	result := testResult()
+	result.Concordance = []concordance.Entry{
+		{Word: "technology", Contexts: []concordance.Context{
+			{Left: "new", Match: "Technology", Right: "arrives today", URL: "https://example.com/1"},
+			{Left: "", Match: "technology", Right: "is everywhere", URL: "https://example.com/2"},
+		}},
+	}
+
+	buf := &closeBuffer{}
+	writer, _ := NewStreamWriter(FormatMarkdown, buf)
+	if err := writer.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{
+		"## Concordance",
+		"### technology",
+		"- new **Technology** arrives today\n  <https://example.com/1>",
+		"- **technology** is everywhere\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "results.db")
+	sqliteWriter, err := NewWriter(FormatSQLite, path)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := sqliteWriter.Write(result); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	sqliteWriter.Close()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Opening database failed: %v", err)
+	}
+	defer db.Close()
+
+	var match, url string
+	err = db.QueryRow(`SELECT c.match, c.url FROM run_concordance c JOIN words w ON w.id = c.word_id WHERE w.word = 'technology' AND c.position = 2`).Scan(&match, &url)
+	if err != nil {
+		t.Fatalf("Querying concordance failed: %v", err)
+	}
+	if match != "technology" || url != "https://example.com/2" {
+		t.Errorf("Expected the second context from https://example.com/2, got %q from %s", match, url)
+	}
+}
+
+func TestWriteConcordance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "concordance.jsonl")
+	entries := []concordance.Entry{
+		{Word: "robot", Contexts: []concordance.Context{{Left: "a", Match: "robot", Right: "walks", URL: "https://example.com/1"}}},
+		{Word: "laptop", Contexts: []concordance.Context{{Left: "the", Match: "laptops", Right: "", URL: "https://example.com/2"}}},
+	}
+	if err := WriteConcordance(path, entries); err != nil {
+		t.Fatalf("WriteConcordance failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading concordance file failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), data)
+	}
+	var line map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &line); err != nil {
+		t.Fatalf("Parsing line failed: %v", err)
+	}
+	if line["word"] != "laptop" || line["match"] != "laptops" || line["url"] != "https://example.com/2" {
+		t.Errorf("Expected the laptop context, got %v", line)
+	}
+}
@@ -0,0 +1,255 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+)
+
+// API paths served by the job Handler
+const (
+	PathJobs = "/v1/jobs"  // GET to list, POST to submit
+	PathJob  = "/v1/jobs/" // followed by {id} (GET, DELETE) or {id}/result (GET)
+)
+
+// maxUploadBytes bounds the size of a submitted URL list
+const maxUploadBytes = 64 << 20
+
+// contentTypes maps output formats to the Content-Type of their result
+var contentTypes = map[string]string{
+	outputio.FormatJSON:     "application/json",
+	outputio.FormatNDJSON:   "application/x-ndjson",
+	outputio.FormatCSV:      "text/csv; charset=utf-8",
+	outputio.FormatTSV:      "text/tab-separated-values; charset=utf-8",
+	outputio.FormatMarkdown: "text/markdown; charset=utf-8",
+	outputio.FormatSQLite:   "application/vnd.sqlite3",
+}
+
+// SubmitRequest is the JSON body for submitting a job
+type SubmitRequest struct {
+	URLs []string `json:"urls"`
+	Spec
+}
+
+// Handler returns the job HTTP API
+func (m *Manager) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathJobs, m.handleJobs)
+	mux.HandleFunc(PathJob, m.handleJob)
+	return mux
+}
+
+// handleJobs serves GET /v1/jobs and POST /v1/jobs
+func (m *Manager) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, m.List())
+
+	case http.MethodPost:
+		urls, spec, err := decodeSubmission(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err := m.Submit(urls, spec)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", PathJob+job.ID)
+		writeJSON(w, http.StatusAccepted, job)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleJob serves GET and DELETE /v1/jobs/{id} and GET /v1/jobs/{id}/result
+func (m *Manager) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, PathJob), "/")
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		job, err := m.Get(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+
+	case action == "" && r.Method == http.MethodDelete:
+		job, err := m.Cancel(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job)
+
+	case action == "result" && r.Method == http.MethodGet:
+		m.serveResult(w, r, id)
+
+	case action == "" || action == "result":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveResult renders a job's result in the format named by ?format= (default json)
+func (m *Manager) serveResult(w http.ResponseWriter, r *http.Request, id string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = outputio.FormatJSON
+	}
+	if err := outputio.ValidateFormat(format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := m.Result(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == outputio.FormatSQLite {
+		serveSQLite(w, id, result)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	writer, err := outputio.NewStreamWriter(format, nopCloser{w})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writer.Write(result); err != nil {
+		// Headers are already sent; the truncated body is all we can report
+		return
+	}
+	writer.Close()
+}
+
+// serveSQLite builds a SQLite database in a temporary file and sends it
+func serveSQLite(w http.ResponseWriter, id string, result *outputio.Result) {
+	tmp, err := os.CreateTemp("", "essay-job-*.db")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	writer, err := outputio.NewWriter(outputio.FormatSQLite, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writer.Write(result); err != nil {
+		writer.Close()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	db, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	w.Header().Set("Content-Type", contentTypes[outputio.FormatSQLite])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".db"))
+	io.Copy(w, db)
+}
+
+// decodeSubmission reads a job from either a JSON SubmitRequest or an
+// uploaded URLs file (any other content type) with options in the query string
+func decodeSubmission(r *http.Request) ([]string, Spec, error) {
+	body := http.MaxBytesReader(nil, r.Body, maxUploadBytes)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var req SubmitRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			return nil, Spec{}, fmt.Errorf("decoding job: %w", err)
+		}
+		return req.URLs, req.Spec, nil
+	}
+
+	var urls []string
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		url := strings.TrimSpace(scanner.Text())
+		if url == "" || strings.HasPrefix(url, "#") {
+			continue // Skip empty lines and comments, as in a URLs file
+		}
+		urls = append(urls, url)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, Spec{}, fmt.Errorf("reading uploaded URLs: %w", err)
+	}
+
+	query := r.URL.Query()
+	spec := Spec{Wordbank: query.Get("wordbank")}
+	if v := query.Get("workers"); v != "" {
+		workers, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, Spec{}, fmt.Errorf("invalid workers %q", v)
+		}
+		spec.Workers = workers
+	}
+	if v := query.Get("rate_limit"); v != "" {
+		rateLimit, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, Spec{}, fmt.Errorf("invalid rate_limit %q", v)
+		}
+		spec.RateLimit = rateLimit
+	}
+
+	return urls, spec, nil
+}
+
+// writeError maps Manager errors to HTTP statuses
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrFinished), errors.Is(err, ErrNoResult):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// nopCloser keeps the response open when a Writer is closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
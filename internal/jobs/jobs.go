@@ -0,0 +1,494 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+	"github.com/firefly/essay-analyzer/internal/logging"
+)
+
+// State is the lifecycle stage of a job
+type State string
+
+// Job states. Succeeded, failed, canceled and interrupted are final.
+const (
+	StateQueued      State = "queued"
+	StateRunning     State = "running"
+	StateSucceeded   State = "succeeded"
+	StateFailed      State = "failed"
+	StateCanceled    State = "canceled"
+	StateInterrupted State = "interrupted" // The server stopped before the job finished
+)
+
+// Final reports whether a job in state s will not change again
+func (s State) Final() bool {
+	return s != StateQueued && s != StateRunning
+}
+
+var (
+	// ErrNotFound is returned for an unknown job ID
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when canceling a job that already finished
+	ErrFinished = errors.New("job already finished")
+	// ErrNoResult is returned when asking for the result of a job that did not succeed
+	ErrNoResult = errors.New("job has no result")
+)
+
+// Spec holds the options a job was submitted with
+type Spec struct {
+	Wordbank  string  `json:"wordbank,omitempty"` // Name of a wordbank known to the server ("" = default)
+	Workers   int     `json:"workers,omitempty"`
+	RateLimit float64 `json:"rate_limit,omitempty"`
+}
+
+// Progress reports how far a job has got, from its aggregator
+type Progress struct {
+	ArticlesProcessed int     `json:"articles_processed"`
+	WordsProcessed    int     `json:"words_processed"`
+	UniqueWords       int     `json:"unique_words"`
+	ElapsedSeconds    float64 `json:"elapsed_seconds"`
+}
+
+// Job describes a submitted analysis and what has happened to it
+type Job struct {
+	ID         string     `json:"id"`
+	State      State      `json:"state"`
+	Spec       Spec       `json:"spec"`
+	URLCount   int        `json:"url_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Progress   *Progress  `json:"progress,omitempty"`
+}
+
+// Runner analyzes the URLs listed in urlsFile into agg and returns the run's
+// manifest, logging to a logger tagged with the job ID. It must return an error
+// if ctx is canceled before the run completes.
+type Runner func(ctx context.Context, urlsFile string, spec Spec, agg *aggregator.Aggregator, logger *slog.Logger) (*outputio.RunManifest, error)
+
+// entry is a job plus the state needed while it is queued or running
+type entry struct {
+	job    Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	agg    *aggregator.Aggregator // Set while running
+	logger *slog.Logger
+}
+
+// Manager runs jobs in submission order with bounded concurrency and persists
+// each job's metadata and result under a data directory so they survive restarts
+type Manager struct {
+	dataDir string
+	run     Runner
+
+	// Validate, if set, rejects a Spec before the job is accepted
+	Validate func(*Spec) error
+
+	maxConcurrent int
+	mu            sync.Mutex
+	jobs          map[string]*entry
+	queue         []*entry // Queued jobs, oldest first
+	running       int
+	closing       bool
+	wg            sync.WaitGroup
+	logger        *slog.Logger
+}
+
+// NewManager creates a Manager that runs at most maxConcurrent jobs at once.
+// Jobs found in dataDir are loaded; any that were queued or running when the
+// previous server stopped are marked interrupted. A nil logger discards all log output.
+func NewManager(dataDir string, maxConcurrent int, run Runner, logger *slog.Logger) (*Manager, error) {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+
+	m := &Manager{
+		dataDir:       dataDir,
+		run:           run,
+		maxConcurrent: maxConcurrent,
+		jobs:          make(map[string]*entry),
+		logger:        logging.OrDiscard(logger),
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Submit persists the URL list and queues a new job
+func (m *Manager) Submit(urls []string, spec Spec) (Job, error) {
+	if len(urls) == 0 {
+		return Job{}, fmt.Errorf("no URLs given")
+	}
+	if spec.Workers < 0 {
+		return Job{}, fmt.Errorf("workers must be positive")
+	}
+	if spec.RateLimit < 0 {
+		return Job{}, fmt.Errorf("rate_limit must be non-negative (0 = no limit)")
+	}
+	if m.Validate != nil {
+		if err := m.Validate(&spec); err != nil {
+			return Job{}, err
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	if err := os.MkdirAll(m.jobDir(id), 0755); err != nil {
+		return Job{}, fmt.Errorf("creating job directory: %w", err)
+	}
+	if err := os.WriteFile(m.urlsPath(id), []byte(strings.Join(urls, "\n")+"\n"), 0644); err != nil {
+		return Job{}, fmt.Errorf("writing job URLs: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		job: Job{
+			ID:        id,
+			State:     StateQueued,
+			Spec:      spec,
+			URLCount:  len(urls),
+			CreatedAt: time.Now(),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+		logger: m.logger.With("job", id),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closing {
+		cancel()
+		return Job{}, fmt.Errorf("server is shutting down")
+	}
+	if err := m.save(e.job); err != nil {
+		cancel()
+		return Job{}, err
+	}
+	m.jobs[id] = e
+	m.queue = append(m.queue, e)
+
+	e.logger.Info("queued job", "urls", len(urls))
+
+	job := e.job
+	m.dispatch()
+	return job, nil
+}
+
+// Get returns a job, with live progress if it is running
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return e.snapshot(), nil
+}
+
+// List returns every job, newest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, e := range m.jobs {
+		jobs = append(jobs, e.snapshot())
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Cancel stops a queued or running job. The job becomes canceled once its
+// pipeline has shut down.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if e.job.State.Final() {
+		return e.job, ErrFinished
+	}
+
+	e.cancel()
+	if e.job.State == StateQueued {
+		m.dequeue(e)
+		m.record(e, StateCanceled, "")
+	}
+	return e.snapshot(), nil
+}
+
+// Result loads the result of a job that succeeded
+func (m *Manager) Result(id string) (*outputio.Result, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != StateSucceeded {
+		return nil, fmt.Errorf("%w: job is %s", ErrNoResult, job.State)
+	}
+
+	export, err := outputio.ReadExport(m.exportPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		// Jobs finished before exports were stored kept only the rendered result
+		return outputio.ReadResult(m.resultPath(id))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return export.Result()
+}
+
+// Shutdown stops every queued and running job and waits for them to stop.
+// Their state is recorded as interrupted rather than canceled.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closing = true
+	for _, e := range m.queue {
+		e.cancel()
+		m.record(e, StateInterrupted, "server shut down before the job started")
+	}
+	m.queue = nil
+	for _, e := range m.jobs {
+		if e.job.State == StateRunning {
+			e.cancel()
+		}
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatch starts queued jobs while there are free slots. Callers must hold m.mu.
+func (m *Manager) dispatch() {
+	for !m.closing && m.running < m.maxConcurrent && len(m.queue) > 0 {
+		e := m.queue[0]
+		m.queue = m.queue[1:]
+
+		now := time.Now()
+		e.job.State = StateRunning
+		e.job.StartedAt = &now
+		e.agg = aggregator.New(e.logger)
+		e.agg.RetainArticles() // For the per-article tables of SQLite results
+		if err := m.save(e.job); err != nil {
+			e.logger.Error("saving job", "error", err)
+		}
+
+		m.running++
+		m.wg.Add(1)
+		go m.execute(e)
+
+		e.logger.Info("running job")
+	}
+}
+
+// dequeue removes a job from the queue. Callers must hold m.mu.
+func (m *Manager) dequeue(e *entry) {
+	for i, queued := range m.queue {
+		if queued == e {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// execute runs a job and records the outcome
+func (m *Manager) execute(e *entry) {
+	defer m.wg.Done()
+	defer e.cancel()
+
+	run, err := m.run(e.ctx, m.urlsPath(e.job.ID), e.job.Spec, e.agg, e.logger)
+	if err == nil && e.ctx.Err() != nil {
+		err = e.ctx.Err()
+	}
+	if err == nil {
+		err = m.writeExport(e.job.ID, &outputio.Export{Aggregator: e.agg.Snapshot(), Run: run})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case err == nil:
+		m.record(e, StateSucceeded, "")
+	case errors.Is(err, context.Canceled) && m.closing:
+		m.record(e, StateInterrupted, "server shut down before the job finished")
+	case errors.Is(err, context.Canceled):
+		m.record(e, StateCanceled, "")
+	default:
+		m.record(e, StateFailed, err.Error())
+	}
+
+	m.running--
+	m.dispatch()
+}
+
+// record moves a job to a final state and persists it. Callers must hold m.mu.
+func (m *Manager) record(e *entry, state State, errMsg string) {
+	now := time.Now()
+	e.job.State = state
+	e.job.Error = errMsg
+	e.job.FinishedAt = &now
+	e.job.Progress = e.progress()
+	e.agg = nil
+
+	if err := m.save(e.job); err != nil {
+		e.logger.Error("saving job", "error", err)
+	}
+
+	e.logger.Info("job finished", "state", e.job.State, "error", e.job.Error)
+}
+
+// snapshot returns a copy of the job with current progress. Callers must hold m.mu.
+func (e *entry) snapshot() Job {
+	job := e.job
+	if e.agg != nil {
+		job.Progress = e.progress()
+	}
+	return job
+}
+
+// progress reads the job's aggregator, keeping the last known progress once it has stopped
+func (e *entry) progress() *Progress {
+	if e.agg == nil {
+		return e.job.Progress
+	}
+	processed, totalWords, uniqueWords, elapsed := e.agg.GetStats()
+	return &Progress{
+		ArticlesProcessed: processed,
+		WordsProcessed:    totalWords,
+		UniqueWords:       uniqueWords,
+		ElapsedSeconds:    elapsed,
+	}
+}
+
+// load reads persisted jobs, marking unfinished ones as interrupted
+func (m *Manager) load() error {
+	dirs, err := os.ReadDir(m.dataDir)
+	if err != nil {
+		return fmt.Errorf("reading data directory: %w", err)
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(m.dataDir, dir.Name(), "job.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading job %s: %w", dir.Name(), err)
+		}
+
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			return fmt.Errorf("decoding job %s: %w", dir.Name(), err)
+		}
+
+		if !job.State.Final() {
+			job.State = StateInterrupted
+			job.Error = "server restarted before the job finished"
+			if err := m.save(job); err != nil {
+				return err
+			}
+		}
+
+		m.jobs[job.ID] = &entry{job: job}
+	}
+
+	return nil
+}
+
+// save writes a job's metadata atomically
+func (m *Manager) save(job Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling job %s: %w", job.ID, err)
+	}
+
+	path := filepath.Join(m.jobDir(job.ID), "job.json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("writing job %s: %w", job.ID, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("writing job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// writeExport stores a job's full state so its result can be rendered in any format later
+func (m *Manager) writeExport(id string, export *outputio.Export) error {
+	if err := outputio.WriteExport(m.exportPath(id), export); err != nil {
+		return fmt.Errorf("storing result: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) jobDir(id string) string {
+	return filepath.Join(m.dataDir, id)
+}
+
+func (m *Manager) urlsPath(id string) string {
+	return filepath.Join(m.jobDir(id), "urls.txt")
+}
+
+func (m *Manager) exportPath(id string) string {
+	return filepath.Join(m.jobDir(id), "export.json")
+}
+
+// resultPath is where jobs finished before exports were stored kept their result
+func (m *Manager) resultPath(id string) string {
+	return filepath.Join(m.jobDir(id), "result.json")
+}
+
+// newID returns a random job ID
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/aggregator"
+	outputio "github.com/firefly/essay-analyzer/internal/io"
+)
+
+// countingRunner counts one "technology" per URL; it blocks until release is
+// closed (if set) so tests can observe running jobs
+func countingRunner(release <-chan struct{}) Runner {
+	return func(ctx context.Context, urlsFile string, spec Spec, agg *aggregator.Aggregator, logger *slog.Logger) (*outputio.RunManifest, error) {
+		data, err := os.ReadFile(urlsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, url := range strings.Fields(string(data)) {
+			agg.AddResult(aggregator.ProcessingResult{URL: url, WordCounts: map[string]int{"technology": 1}})
+		}
+
+		if release != nil {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		return &outputio.RunManifest{TopWords: 10}, nil
+	}
+}
+
+// waitForState polls until a job reaches state or the test times out
+func waitForState(t *testing.T, m *Manager, id string, state State) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if job.State == state {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, _ := m.Get(id)
+	t.Fatalf("Job %s stuck in %s, expected %s", id, job.State, state)
+	return Job{}
+}
+
+func TestManager_RunsJob(t *testing.T) {
+	m, err := NewManager(t.TempDir(), 2, countingRunner(nil), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	job, err := m.Submit([]string{"http://a", "http://b", "http://c"}, Spec{})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	job = waitForState(t, m, job.ID, StateSucceeded)
+	if job.Progress == nil || job.Progress.ArticlesProcessed != 3 {
+		t.Errorf("Expected 3 articles in progress, got %+v", job.Progress)
+	}
+
+	result, err := m.Result(job.ID)
+	if err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+	if len(result.TopWords) != 1 || result.TopWords[0].Count != 3 {
+		t.Errorf("Unexpected result: %+v", result.TopWords)
+	}
+}
+
+func TestManager_BoundsConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	m, err := NewManager(t.TempDir(), 1, countingRunner(release), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	first, _ := m.Submit([]string{"http://a"}, Spec{})
+	second, _ := m.Submit([]string{"http://b"}, Spec{})
+
+	waitForState(t, m, first.ID, StateRunning)
+	if job, _ := m.Get(second.ID); job.State != StateQueued {
+		t.Errorf("Expected second job to wait for a slot, got %s", job.State)
+	}
+
+	close(release)
+	waitForState(t, m, first.ID, StateSucceeded)
+	waitForState(t, m, second.ID, StateSucceeded)
+}
+
+func TestManager_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m, err := NewManager(t.TempDir(), 1, countingRunner(release), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	running, _ := m.Submit([]string{"http://a"}, Spec{})
+	queued, _ := m.Submit([]string{"http://b"}, Spec{})
+	waitForState(t, m, running.ID, StateRunning)
+
+	for _, id := range []string{running.ID, queued.ID} {
+		if _, err := m.Cancel(id); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		waitForState(t, m, id, StateCanceled)
+	}
+
+	if _, err := m.Cancel(running.ID); err != ErrFinished {
+		t.Errorf("Expected ErrFinished canceling a finished job, got %v", err)
+	}
+	if _, err := m.Result(running.ID); err == nil {
+		t.Error("Expected no result for a canceled job")
+	}
+}
+
+func TestManager_RestartKeepsFinishedJobs(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	m, err := NewManager(dir, 1, countingRunner(release), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	finished, _ := m.Submit([]string{"http://a"}, Spec{Workers: 5})
+	close(release)
+	waitForState(t, m, finished.ID, StateSucceeded)
+
+	blocked := make(chan struct{})
+	m.run = countingRunner(blocked)
+	unfinished, _ := m.Submit([]string{"http://b"}, Spec{})
+	waitForState(t, m, unfinished.ID, StateRunning)
+
+	// Simulate a crash: the job file still says running when a new server starts
+	restarted, err := NewManager(dir, 1, countingRunner(nil), nil)
+	if err != nil {
+		t.Fatalf("NewManager after restart failed: %v", err)
+	}
+	close(blocked)
+	waitForState(t, m, unfinished.ID, StateSucceeded)
+
+	job, err := restarted.Get(finished.ID)
+	if err != nil || job.State != StateSucceeded || job.Spec.Workers != 5 {
+		t.Errorf("Expected finished job to survive restart, got %+v %v", job, err)
+	}
+	if result, err := restarted.Result(finished.ID); err != nil || len(result.Articles) != 1 {
+		t.Errorf("Expected finished result with its article to survive restart: %v", err)
+	}
+
+	if job, _ := restarted.Get(unfinished.ID); job.State != StateInterrupted {
+		t.Errorf("Expected running job to be interrupted, got %s", job.State)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m, err := NewManager(t.TempDir(), 2, countingRunner(nil), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	// Upload a URLs file with options in the query string
+	resp, err := http.Post(server.URL+PathJobs+"?workers=4", "text/plain", strings.NewReader("# comment\nhttp://a\n\nhttp://b\n"))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	var job Job
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || job.URLCount != 2 || job.Spec.Workers != 4 {
+		t.Fatalf("Unexpected submission response %d: %+v", resp.StatusCode, job)
+	}
+
+	waitForState(t, m, job.ID, StateSucceeded)
+
+	resp, err = http.Get(server.URL + PathJob + job.ID + "/result?format=csv")
+	if err != nil {
+		t.Fatalf("GET result failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if got := string(body); got != "rank,word,count\n1,technology,2\n" {
+		t.Errorf("Unexpected CSV result: %q", got)
+	}
+
+	resp, err = http.Get(server.URL + PathJob + job.ID + "/result?format=sqlite")
+	if err != nil {
+		t.Fatalf("GET SQLite result failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(string(body), "SQLite format 3") {
+		t.Errorf("Expected a SQLite database, got %d bytes", len(body))
+	}
+
+	for path, status := range map[string]int{
+		PathJob + "missing":                      http.StatusNotFound,
+		PathJob + job.ID + "/result?format=xlsx": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("GET %s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+PathJob+job.ID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 canceling a finished job, got %d", resp.StatusCode)
+	}
+}
+
+func TestDecodeSubmission_JSON(t *testing.T) {
+	body := `{"urls": ["http://a"], "wordbank": "tech", "rate_limit": 2}`
+	req := httptest.NewRequest(http.MethodPost, PathJobs, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	urls, spec, err := decodeSubmission(req)
+	if err != nil {
+		t.Fatalf("decodeSubmission failed: %v", err)
+	}
+	if len(urls) != 1 || spec.Wordbank != "tech" || spec.RateLimit != 2 {
+		t.Errorf("Unexpected submission: %v %+v", urls, spec)
+	}
+}
+
+func TestManager_ValidateRejects(t *testing.T) {
+	m, err := NewManager(t.TempDir(), 1, countingRunner(nil), nil)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	m.Validate = func(spec *Spec) error {
+		return fmt.Errorf("unknown wordbank %q", spec.Wordbank)
+	}
+
+	if _, err := m.Submit([]string{"http://a"}, Spec{Wordbank: "nope"}); err == nil {
+		t.Error("Expected Validate to reject the job")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(m.dataDir)); len(entries) != 0 {
+		t.Errorf("Expected nothing persisted for a rejected job, got %d entries", len(entries))
+	}
+}
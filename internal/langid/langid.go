@@ -0,0 +1,165 @@
+package langid
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// profiles holds a sample text in each language, named by its ISO 639-1 code
+//
+//go:embed profiles/*.txt
+var profiles embed.FS
+
+const (
+	// MaxSample is how many bytes at the start of a text are looked at
+	MaxSample = 4096
+
+	// MinTrigrams is the fewest letter trigrams a text needs to be identified
+	MinTrigrams = 20
+
+	// MinMargin is how much more likely, per trigram, the best language must make
+	// a text than the next one, as a difference of natural logarithms
+	MinMargin = 0.05
+
+	// MinKnown is the share of a text's trigrams that must occur in some sample,
+	// so text in a script none of them use is not identified
+	MinKnown = 0.3
+)
+
+// profile holds how often each trigram occurs in one language's sample
+type profile struct {
+	counts map[string]int
+	total  int
+}
+
+// Identifier identifies the language of a text from its letter trigrams, scoring
+// them against a sample of each language with naive Bayes
+type Identifier struct {
+	languages []string
+	profiles  map[string]*profile
+	seen      map[string]bool // Trigrams occurring in any sample
+}
+
+// New returns an Identifier for every language it has a sample of
+func New() (*Identifier, error) {
+	entries, err := profiles.ReadDir("profiles")
+	if err != nil {
+		return nil, fmt.Errorf("reading language profiles: %w", err)
+	}
+
+	id := &Identifier{profiles: make(map[string]*profile), seen: make(map[string]bool)}
+	for _, entry := range entries {
+		sample, err := profiles.ReadFile(path.Join("profiles", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading language profile: %w", err)
+		}
+		p := &profile{counts: make(map[string]int)}
+		trigrams(string(sample), func(trigram string) {
+			p.counts[trigram]++
+			p.total++
+			id.seen[trigram] = true
+		})
+		language := strings.TrimSuffix(entry.Name(), ".txt")
+		id.profiles[language] = p
+		id.languages = append(id.languages, language)
+	}
+	sort.Strings(id.languages)
+	return id, nil
+}
+
+// Languages returns the codes of the languages the Identifier knows, sorted
+func (id *Identifier) Languages() []string {
+	return append([]string(nil), id.languages...)
+}
+
+// Supported reports whether the Identifier knows a language
+func (id *Identifier) Supported(language string) bool {
+	_, ok := id.profiles[language]
+	return ok
+}
+
+// Identify returns the code of the language text is most likely in, or "" when
+// the text is too short or too unlike any sample to tell
+func (id *Identifier) Identify(text string) string {
+	if len(text) > MaxSample {
+		text = text[:MaxSample]
+	}
+
+	counts := make(map[string]int)
+	n, known := 0, 0
+	trigrams(text, func(trigram string) {
+		counts[trigram]++
+		n++
+		if id.seen[trigram] {
+			known++
+		}
+	})
+	if n < MinTrigrams || float64(known) < MinKnown*float64(n) {
+		return ""
+	}
+
+	// Unseen trigrams get add-one smoothing over every trigram in any sample
+	vocabulary := float64(len(id.seen) + 1)
+	best, second := math.Inf(-1), math.Inf(-1)
+	var language string
+	for _, code := range id.languages {
+		p := id.profiles[code]
+		denominator := math.Log(float64(p.total) + vocabulary)
+		score := 0.0
+		for trigram, count := range counts {
+			score += float64(count) * (math.Log(float64(p.counts[trigram]+1)) - denominator)
+		}
+		switch {
+		case score > best:
+			best, second, language = score, best, code
+		case score > second:
+			second = score
+		}
+	}
+
+	if (best-second)/float64(n) < MinMargin {
+		return ""
+	}
+	return language
+}
+
+// trigrams calls fn with each trigram of letters in text, lowercased, with a space
+// marking the start and end of each word
+func trigrams(text string, fn func(trigram string)) {
+	var window [3]rune
+	filled := 0
+	push := func(r rune) {
+		window[0], window[1], window[2] = window[1], window[2], r
+		if filled < 3 {
+			filled++
+		}
+		if filled == 3 {
+			fn(string(window[:]))
+		}
+	}
+
+	inWord := false
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			if !inWord {
+				filled = 0
+				push(' ')
+				inWord = true
+			}
+			push(unicode.ToLower(r))
+			continue
+		}
+		if inWord {
+			push(' ')
+			inWord = false
+		}
+	}
+	if inWord {
+		push(' ')
+	}
+}
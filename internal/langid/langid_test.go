@@ -0,0 +1,61 @@
+package langid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+	id, err := New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"en": "Apple unveiled a new laptop today with a brighter display and longer battery life, and said it would ship next week.",
+		"es": "Apple presentó hoy un nuevo portátil con una pantalla más brillante y una batería que dura más, y dijo que llegará la próxima semana.",
+		"fr": "Apple a présenté aujourd'hui un nouvel ordinateur portable avec un écran plus lumineux et une meilleure autonomie, disponible la semaine prochaine.",
+		"de": "Apple hat heute einen neuen Laptop mit hellerem Bildschirm und längerer Akkulaufzeit vorgestellt, der nächste Woche ausgeliefert werden soll.",
+		"it": "Apple ha presentato oggi un nuovo portatile con uno schermo più luminoso e una batteria che dura di più, in arrivo la prossima settimana.",
+		"pt": "A Apple apresentou hoje um novo portátil com um ecrã mais brilhante e uma bateria que dura mais, e disse que chegará na próxima semana.",
+		"nl": "Apple heeft vandaag een nieuwe laptop gepresenteerd met een helderder scherm en een langere batterijduur, die volgende week wordt geleverd.",
+	}
+	for want, text := range tests {
+		if got := id.Identify(text); got != want {
+			t.Errorf("Expected %s, got %q for %q", want, got, text)
+		}
+	}
+}
+
+func TestIdentify_Undetermined(t *testing.T) {
+	id, err := New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	for name, text := range map[string]string{
+		"empty":          "",
+		"too short":      "Hello there",
+		"numbers":        strings.Repeat("2024 ", 100),
+		"unknown script": "Компания представила сегодня новый ноутбук с более ярким экраном и более долгим временем работы от батареи.",
+	} {
+		if got := id.Identify(text); got != "" {
+			t.Errorf("%s: expected no language, got %q", name, got)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	id, err := New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	want := "de,en,es,fr,it,nl,pt"
+	if got := strings.Join(id.Languages(), ","); got != want {
+		t.Errorf("Expected languages %s, got %s", want, got)
+	}
+	if !id.Supported("fr") || id.Supported("ru") {
+		t.Error("Expected fr to be supported and ru not")
+	}
+}
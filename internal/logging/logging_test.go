@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warn":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+	for name, expected := range tests {
+		level, err := ParseLevel(name)
+		if err != nil || level != expected {
+			t.Errorf("ParseLevel(%q) = %v, %v; expected %v", name, level, err, expected)
+		}
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestNew_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	logger.Debug("hidden")
+	logger.Info("fetched", "url", "https://example.com/a")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 record above debug, got %d: %q", len(lines), buf.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Record is not JSON: %v", err)
+	}
+	if record["msg"] != "fetched" || record["url"] != "https://example.com/a" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestOrDiscard(t *testing.T) {
+	if OrDiscard(nil).Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected nil logger to be replaced by a discarding logger")
+	}
+
+	logger := slog.Default()
+	if OrDiscard(logger) != logger {
+		t.Error("Expected non-nil logger to be returned unchanged")
+	}
+}
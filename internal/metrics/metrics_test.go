@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, reg *Registry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounterVec("requests_total", "Requests by code.", "host", "code")
+
+	c.Inc("example.com", "200")
+	c.Inc("example.com", "200")
+	c.Add(3, "example.com", "500")
+
+	if got := c.Value("example.com", "200"); got != 2 {
+		t.Errorf("Expected 2, got %v", got)
+	}
+
+	expected := `# HELP requests_total Requests by code.
+# TYPE requests_total counter
+requests_total{host="example.com",code="200"} 2
+requests_total{host="example.com",code="500"} 3
+`
+	if got := render(t, reg); got != expected {
+		t.Errorf("Unexpected exposition:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestGaugeVec(t *testing.T) {
+	reg := NewRegistry()
+	g := reg.NewGaugeVec("queue_depth", "Items waiting.", "channel")
+
+	depth := 7
+	g.SetFunc(func() float64 { return float64(depth) }, "url")
+	g.Set(2, "html")
+
+	out := render(t, reg)
+	for _, want := range []string{`queue_depth{channel="url"} 7`, `queue_depth{channel="html"} 2`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+
+	g.Delete("url")
+	if strings.Contains(render(t, reg), `channel="url"`) {
+		t.Error("Expected deleted series to be gone")
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "host")
+
+	h.Observe(0.05, "a")
+	h.Observe(0.1, "a") // Bounds are inclusive
+	h.Observe(0.5, "a")
+	h.Observe(5, "a")
+
+	expected := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{host="a",le="0.1"} 2
+latency_seconds_bucket{host="a",le="1"} 3
+latency_seconds_bucket{host="a",le="+Inf"} 4
+latency_seconds_sum{host="a"} 5.65
+latency_seconds_count{host="a"} 4
+`
+	if got := render(t, reg); got != expected {
+		t.Errorf("Unexpected exposition:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var reg *Registry
+
+	// None of these may panic
+	reg.NewCounterVec("c", "").Inc()
+	reg.NewGaugeVec("g", "", "l").SetFunc(func() float64 { return 1 }, "v")
+	reg.NewHistogramVec("h", "", DefBuckets).Observe(1)
+
+	if got := reg.NewCounterVec("c", "").Value(); got != 0 {
+		t.Errorf("Expected 0 from nil counter, got %v", got)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("c", "Line one\nline two.", "selector").Inc(`[data-x="true"]`)
+
+	out := render(t, reg)
+	if !strings.Contains(out, `# HELP c Line one\nline two.`) {
+		t.Errorf("Expected escaped help text in:\n%s", out)
+	}
+	if !strings.Contains(out, `c{selector="[data-x=\"true\"]"} 1`) {
+		t.Errorf("Expected escaped label value in:\n%s", out)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounterVec("c", "Help.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "c 1\n") {
+		t.Errorf("Unexpected body:\n%s", rec.Body.String())
+	}
+}
@@ -3,62 +3,144 @@ package parser
 import (
 	"fmt"
 	"io"
+	"log/slog"
+	"net/url"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/metrics"
 )
 
+// ProfileName identifies the set of content selectors the parser uses
+const ProfileName = "engadget"
+
+// contentSelectors lists the selectors tried in order - prioritize clean content over noisy fallbacks
+var contentSelectors = []struct {
+	selector string
+	desc     string
+}{
+	{"article header, [data-article-body='true']", "header + body (ideal)"},
+	{"[data-article-body='true']", "body only (good)"},
+}
+
+// SelectorUsage reports how many articles were extracted with a selector
+type SelectorUsage struct {
+	Selector    string `json:"selector"`
+	Description string `json:"description"`
+	Matched     int64  `json:"matched"`
+}
+
+// ExtractorProfile describes the selectors used for extraction and how often each matched
+type ExtractorProfile struct {
+	Name      string          `json:"name"`
+	Selectors []SelectorUsage `json:"selectors"`
+	Failed    int64           `json:"failed"`
+}
+
+// Article is the content extracted from a page
+type Article struct {
+	Text      string
+	Canonical string // Absolute URL from <link rel="canonical">, or "" when the page declares none
+}
+
 // Parser extracts text content from HTML with selective content filtering
 type Parser struct {
-	verbose     bool
-	failedCount int64 // Atomic counter for failed parsing attempts
+	logger        *slog.Logger
+	failedCount   int64   // Atomic counter for failed parsing attempts
+	selectorCount []int64 // Atomic counters of successful extractions per selector
+
+	// Metrics, nil unless Instrument is called
+	results  *metrics.CounterVec
+	duration *metrics.HistogramVec
 }
 
-// New creates a new Parser
-func New(verbose bool) *Parser {
+// New creates a new Parser. A nil logger discards all log output.
+func New(logger *slog.Logger) *Parser {
 	return &Parser{
-		verbose: verbose,
+		logger:        logging.OrDiscard(logger),
+		selectorCount: make([]int64, len(contentSelectors)),
 	}
 }
 
+// Instrument registers the parser's metrics with reg
+func (p *Parser) Instrument(reg *metrics.Registry) {
+	p.results = reg.NewCounterVec("essay_parse_results_total",
+		"Articles parsed, by the content selector that matched; failures have selector \"none\".",
+		"result", "selector")
+	p.duration = reg.NewHistogramVec("essay_parse_duration_seconds",
+		"Time to parse an article's HTML and extract its text.",
+		metrics.DefBuckets)
+}
+
 // ExtractText extracts clean text content from HTML using selective parsing
 func (p *Parser) ExtractText(reader io.Reader) (string, error) {
-	doc, err := goquery.NewDocumentFromReader(reader)
+	article, err := p.Extract(reader, "")
 	if err != nil {
-		return "", fmt.Errorf("parsing HTML: %w", err)
+		return "", err
 	}
+	return article.Text, nil
+}
 
-	// Selective content extraction - prioritize clean content over noisy fallbacks
-	contentSelectors := []struct {
-		selector string
-		desc     string
-	}{
-		{"article header, [data-article-body='true']", "header + body (ideal)"},
-		{"[data-article-body='true']", "body only (good)"},
+// Extract extracts clean text content and the canonical link from the HTML of the
+// page at pageURL, against which a relative canonical link is resolved
+func (p *Parser) Extract(reader io.Reader, pageURL string) (*Article, error) {
+	start := time.Now()
+	defer func() {
+		p.duration.Observe(time.Since(start).Seconds())
+	}()
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML: %w", err)
 	}
 
-	for _, sel := range contentSelectors {
+	// Selective content extraction
+	for i, sel := range contentSelectors {
 		content := doc.Find(sel.selector)
 		if content.Length() > 0 {
 			text := strings.TrimSpace(content.Text())
 			if len(text) > 0 {
-				if p.verbose {
-					fmt.Printf("✅ Extracted text using: %s (%d chars)\n", sel.desc, len(text))
-				}
-				return text, nil
+				atomic.AddInt64(&p.selectorCount[i], 1)
+				p.results.Inc("success", sel.selector)
+				p.logger.Debug("extracted text", "selector", sel.desc, "chars", len(text))
+				return &Article{Text: text, Canonical: canonicalLink(doc, pageURL)}, nil
 			}
 		}
 	}
 
 	// If we reach here, parsing failed - increment counter
 	atomic.AddInt64(&p.failedCount, 1)
+	p.results.Inc("failure", "none")
+
+	p.logger.Debug("failed to extract clean content, no suitable selectors found")
 
-	if p.verbose {
-		fmt.Printf("❌ Failed to extract clean content - no suitable selectors found\n")
+	return nil, fmt.Errorf("failed to extract clean content: no suitable selectors matched")
+}
+
+// canonicalLink returns the page's first <link rel="canonical"> resolved against
+// pageURL, or "" when there is none or it is not an absolute http(s) URL
+func canonicalLink(doc *goquery.Document, pageURL string) string {
+	href, ok := doc.Find(`link[rel~="canonical"]`).First().Attr("href")
+	href = strings.TrimSpace(href)
+	if !ok || href == "" {
+		return ""
 	}
 
-	return "", fmt.Errorf("failed to extract clean content: no suitable selectors matched")
+	canonical, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if base, err := url.Parse(pageURL); err == nil {
+		canonical = base.ResolveReference(canonical)
+	}
+	if (canonical.Scheme != "http" && canonical.Scheme != "https") || canonical.Host == "" {
+		return ""
+	}
+	return canonical.String()
 }
 
 // GetFailedCount returns the number of articles that failed to parse
@@ -66,25 +148,45 @@ func (p *Parser) GetFailedCount() int64 {
 	return atomic.LoadInt64(&p.failedCount)
 }
 
-// PrintStats prints parsing statistics (call this at the end of processing)
-func (p *Parser) PrintStats(totalArticles int64) {
-	failedCount := p.GetFailedCount()
-	successCount := totalArticles - failedCount
-
-	if p.verbose {
-		fmt.Printf("\n=== PARSING STATISTICS ===\n")
-		fmt.Printf("Successfully parsed articles: %d\n", successCount)
-		fmt.Printf("Failed to parse articles: %d\n", failedCount)
-		if totalArticles > 0 {
-			successRate := float64(successCount) / float64(totalArticles) * 100
-			fmt.Printf("Success rate: %.1f%%\n", successRate)
-		}
+// Profile returns the extractor profile with per-selector match counts
+func (p *Parser) Profile() ExtractorProfile {
+	profile := ExtractorProfile{
+		Name:      ProfileName,
+		Selectors: make([]SelectorUsage, len(contentSelectors)),
+		Failed:    p.GetFailedCount(),
+	}
 
-		if failedCount > 0 {
-			fmt.Printf("\n⚠️  WARNING: %d articles failed to parse.\n", failedCount)
-			fmt.Printf("This may indicate that Engadget has changed their HTML structure.\n")
-			fmt.Printf("Consider updating the content selectors in the parser.\n")
+	for i, sel := range contentSelectors {
+		profile.Selectors[i] = SelectorUsage{
+			Selector:    sel.selector,
+			Description: sel.desc,
+			Matched:     atomic.LoadInt64(&p.selectorCount[i]),
 		}
-		fmt.Printf("===========================\n\n")
+	}
+
+	return profile
+}
+
+// LogStats logs parsing statistics (call this at the end of processing)
+func (p *Parser) LogStats() {
+	failedCount := p.GetFailedCount()
+	var successCount int64
+	for i := range p.selectorCount {
+		successCount += atomic.LoadInt64(&p.selectorCount[i])
+	}
+
+	totalArticles := successCount + failedCount
+	if totalArticles == 0 {
+		return
+	}
+
+	p.logger.Info("parsing statistics",
+		"parsed", successCount,
+		"failed", failedCount,
+		"success_rate", float64(successCount)/float64(totalArticles))
+
+	if failedCount > 0 {
+		// Usually means Engadget changed their HTML structure and the content selectors need updating
+		p.logger.Warn("articles failed to parse; the content selectors may be out of date", "failed", failedCount)
 	}
 }
@@ -1,13 +1,17 @@
 package parser
 
 import (
+	"io"
+	"log/slog"
 	"strings"
 	"testing"
+
+	"github.com/firefly/essay-analyzer/internal/metrics"
 )
 
 // TestExtractText_IdealSelector tests extraction using the ideal selector (header + body)
 func TestExtractText_IdealSelector(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// Create HTML with both header and body content that meets 200+ char requirement
 	html := `
@@ -54,7 +58,7 @@ func TestExtractText_IdealSelector(t *testing.T) {
 
 // TestExtractText_FallbackSelector tests fallback to body-only selector
 func TestExtractText_FallbackSelector(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// Create HTML with only body content (no header), meeting 100+ char requirement
 	html := `
@@ -91,7 +95,7 @@ func TestExtractText_FallbackSelector(t *testing.T) {
 
 // TestExtractText_InvalidHTML tests handling of invalid HTML
 func TestExtractText_InvalidHTML(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// Malformed HTML that should cause goquery to fail
 	invalidHTML := `<html><body><div><p>Unclosed tags and malformed content`
@@ -121,7 +125,7 @@ func TestExtractText_InvalidHTML(t *testing.T) {
 
 // TestExtractText_NoSuitableContent tests when no selectors match
 func TestExtractText_NoSuitableContent(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// HTML with no matching selectors
 	html := `
@@ -156,7 +160,7 @@ func TestExtractText_NoSuitableContent(t *testing.T) {
 
 // TestExtractText_ShortContent tests that short content is still extracted
 func TestExtractText_ShortContent(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// HTML with short content that should still be extracted
 	html := `
@@ -193,7 +197,7 @@ func TestExtractText_ShortContent(t *testing.T) {
 
 // TestFailureCount_MultipleFailures tests that failure count increments correctly
 func TestFailureCount_MultipleFailures(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// Test multiple failures
 	testCases := []string{
@@ -222,28 +226,29 @@ func TestFailureCount_MultipleFailures(t *testing.T) {
 
 // TestNew tests parser creation
 func TestNew(t *testing.T) {
-	// Test non-verbose parser
-	parser := New(false)
+	// Test parser without a logger
+	parser := New(nil)
 	if parser == nil {
 		t.Fatal("Expected parser to be created")
 	}
-	if parser.verbose {
-		t.Error("Expected verbose to be false")
+	if parser.logger == nil {
+		t.Error("Expected a discarding logger when none is given")
 	}
 	if parser.GetFailedCount() != 0 {
 		t.Error("Expected initial failure count to be 0")
 	}
 
-	// Test verbose parser
-	verboseParser := New(true)
-	if !verboseParser.verbose {
-		t.Error("Expected verbose to be true")
+	// Test parser with a logger
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	loggingParser := New(logger)
+	if loggingParser.logger != logger {
+		t.Error("Expected the given logger to be used")
 	}
 }
 
 // TestGetFailedCount tests the failure count getter
 func TestGetFailedCount(t *testing.T) {
-	parser := New(false)
+	parser := New(nil)
 
 	// Initial count should be 0
 	if parser.GetFailedCount() != 0 {
@@ -260,3 +265,90 @@ func TestGetFailedCount(t *testing.T) {
 		t.Errorf("Expected failure count to be 1, got %d", parser.GetFailedCount())
 	}
 }
+
+// TestProfile tests that selector usage is counted per selector
+func TestProfile(t *testing.T) {
+	parser := New(nil)
+
+	pages := []string{
+		`<html><body><article><header>Title</header><div data-article-body="true">Body</div></article></body></html>`,
+		`<html><body><div data-article-body="true">Body only</div></body></html>`,
+		`<html><body><div>No matching content</div></body></html>`,
+	}
+	for _, html := range pages {
+		parser.ExtractText(strings.NewReader(html))
+	}
+
+	profile := parser.Profile()
+	if profile.Name != ProfileName {
+		t.Errorf("Expected profile name %q, got %q", ProfileName, profile.Name)
+	}
+	if len(profile.Selectors) != 2 {
+		t.Fatalf("Expected 2 selectors, got %d", len(profile.Selectors))
+	}
+
+	// The ideal selector also matches body-only pages, so it wins both times
+	if profile.Selectors[0].Matched != 2 {
+		t.Errorf("Expected ideal selector to match 2 pages, got %d", profile.Selectors[0].Matched)
+	}
+	if profile.Selectors[1].Matched != 0 {
+		t.Errorf("Expected fallback selector to match 0 pages, got %d", profile.Selectors[1].Matched)
+	}
+	if profile.Failed != 1 {
+		t.Errorf("Expected 1 failed page, got %d", profile.Failed)
+	}
+}
+
+func TestExtractText_Instrumented(t *testing.T) {
+	reg := metrics.NewRegistry()
+	parser := New(nil)
+	parser.Instrument(reg)
+
+	parser.ExtractText(strings.NewReader(`<div data-article-body="true">Body text</div>`))
+	parser.ExtractText(strings.NewReader(`<div>No article here</div>`))
+
+	if got := parser.results.Value("success", contentSelectors[0].selector); got != 1 {
+		t.Errorf("Expected 1 success for the first selector, got %v", got)
+	}
+	if got := parser.results.Value("failure", "none"); got != 1 {
+		t.Errorf("Expected 1 failure, got %v", got)
+	}
+	if got := parser.duration.Count(); got != 2 {
+		t.Errorf("Expected 2 duration observations, got %d", got)
+	}
+}
+
+func TestExtract_CanonicalLink(t *testing.T) {
+	page := func(head string) io.Reader {
+		return strings.NewReader(`<html><head>` + head + `</head><body>
+			<div data-article-body="true"><p>Article body text.</p></div>
+		</body></html>`)
+	}
+
+	tests := []struct {
+		name     string
+		head     string
+		expected string
+	}{
+		{"absolute", `<link rel="canonical" href="https://www.engadget.com/2024/new-slug">`, "https://www.engadget.com/2024/new-slug"},
+		{"relative", `<link rel="canonical" href="/2024/new-slug">`, "https://www.engadget.com/2024/new-slug"},
+		{"among other rels", `<link rel="alternate canonical" href="https://www.engadget.com/a">`, "https://www.engadget.com/a"},
+		{"missing", `<link rel="stylesheet" href="/style.css">`, ""},
+		{"not http", `<link rel="canonical" href="mailto:news@engadget.com">`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := New(nil).Extract(page(tt.head), "https://www.engadget.com/2019/old-slug")
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+			if article.Canonical != tt.expected {
+				t.Errorf("Expected canonical %q, got %q", tt.expected, article.Canonical)
+			}
+			if article.Text != "Article body text." {
+				t.Errorf("Expected article text, got %q", article.Text)
+			}
+		})
+	}
+}
@@ -1,49 +1,467 @@
 package processor
 
 import (
+	"log/slog"
 	"regexp"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/concordance"
+	"github.com/firefly/essay-analyzer/internal/logging"
+	"github.com/firefly/essay-analyzer/internal/metrics"
+	"github.com/firefly/essay-analyzer/internal/textstats"
+)
+
+// Reasons a text is skipped rather than counted
+const (
+	SkipUndetermined = "undetermined" // The language could not be identified
+	SkipUnsupported  = "unsupported"  // The language has no wordbank to count it with
 )
 
 // Processor handles word processing and counting
 type Processor struct {
-	wordBank WordValidator
-	verbose  bool
+	main   *language // The language of the wordbank given to New
+	logger *slog.Logger
+
+	// Languages counted when identifying each text's language, nil unless
+	// DetectLanguages is called
+	identifier Identifier
+	languages  map[string]*language
+
+	// Count each word of a matched phrase on its own as well, set by CountPhraseWords
+	countPhraseWords bool
+
+	// Measure the vocabulary and readability of each text, set by CollectStatistics
+	statistics bool
+
+	// Sample the contexts of counted words, nil unless KeepContexts is called
+	contexts *contextSampler
+
+	// Metrics, nil unless Instrument is called
+	articles *metrics.CounterVec
+	tokens   *metrics.CounterVec
+	skipped  *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+// language is how the processor counts the words of one language
+type language struct {
+	// The wordbank and its capabilities, swapped as one by SetWordBank
+	validator atomic.Pointer[validator]
 
 	// Word extraction regex
 	wordRegex *regexp.Regexp
 }
 
+func newLanguage(wordBank WordValidator, wordRegex *regexp.Regexp) *language {
+	l := &language{wordRegex: wordRegex}
+	l.validator.Store(newValidator(wordBank))
+	return l
+}
+
+// Identifier identifies the language of a text
+type Identifier interface {
+	// Identify returns the ISO 639-1 code of the language text is in, or "" when
+	// it cannot tell
+	Identify(text string) string
+}
+
 // WordValidator interface for checking word validity
 type WordValidator interface {
 	IsValid(word string) bool
 }
 
-// New creates a new Processor
-func New(wordBank WordValidator, verbose bool) *Processor {
+// Categorizer is implemented by wordbanks that label words with categories
+type Categorizer interface {
+	// Category returns the word's category, or "" when it has none
+	Category(word string) string
+}
+
+// PhraseMatcher is implemented by wordbanks with multi-word terms
+type PhraseMatcher interface {
+	// MatchPhrase returns the longest phrase that the lowercase tokens start with
+	// and how many tokens it spans, or 0 when they start with none
+	MatchPhrase(tokens []string) (string, int)
+	// Phrases reports whether there are any phrases to match
+	Phrases() bool
+}
+
+// Versioned is implemented by wordbanks that identify their contents, so counts
+// can be told apart after the wordbank is replaced
+type Versioned interface {
+	// Version returns an identifier that changes whenever the words do
+	Version() string
+}
+
+// validator is a wordbank with the optional interfaces it implements
+type validator struct {
+	wordBank    WordValidator
+	categorizer Categorizer   // nil when the wordbank has no categories
+	phrases     PhraseMatcher // nil when the wordbank has no phrases
+	resolver    Resolver      // nil when the wordbank credits no variants
+	version     string        // "" unless the wordbank is Versioned
+}
+
+func newValidator(wordBank WordValidator) *validator {
+	v := &validator{wordBank: wordBank}
+	v.categorizer, _ = wordBank.(Categorizer)
+	v.phrases, _ = wordBank.(PhraseMatcher)
+	if v.phrases != nil && !v.phrases.Phrases() {
+		v.phrases = nil
+	}
+	v.resolver, _ = wordBank.(Resolver)
+	if versioned, ok := wordBank.(Versioned); ok {
+		v.version = versioned.Version()
+	}
+	return v
+}
+
+// Resolver is implemented by wordbanks that credit variants, such as aliases or
+// misspellings, to a word in the wordbank
+type Resolver interface {
+	// Resolve returns the word credited for a lowercase word or phrase that is not
+	// valid itself, or "" when there is none
+	Resolve(word string) string
+}
+
+// Counts is what the processor counts in one text
+type Counts struct {
+	Words map[string]int
+
+	// Variants counts the forms credited to each word other than the word itself,
+	// nil when there are none
+	Variants map[string]map[string]int
+
+	// Categories holds the category of each counted word that has one, nil when none do
+	Categories map[string]string
+
+	// WordBank is the version of the wordbank the text was counted with
+	WordBank string
+
+	// Language is the language the text was identified as, "" unless the
+	// processor detects languages
+	Language string
+
+	// Skipped is why the text was not counted, such as SkipUnsupported, or ""
+	// when it was. A skipped text has no Words.
+	Skipped string
+
+	// Statistics holds the text's vocabulary and readability, nil unless the
+	// processor collects statistics
+	Statistics *textstats.Article
+
+	// Contexts holds sample contexts of each word counted, nil unless the processor
+	// keeps contexts. Their URLs are left empty.
+	Contexts map[string]concordance.Reservoir
+}
+
+// contextSampler decides which contexts the processor keeps
+type contextSampler struct {
+	k     int             // Contexts kept per word
+	width int             // Tokens either side of the word
+	words map[string]bool // Words sampled, nil for every counted word
+}
+
+// sample offers the context of tokens spans[start:end], counted as term
+func (s *contextSampler) sample(counts *Counts, term, text string, spans [][]int, start, end int) {
+	if s == nil || (s.words != nil && !s.words[term]) {
+		return
+	}
+	if counts.Contexts == nil {
+		counts.Contexts = make(map[string]concordance.Reservoir)
+	}
+	r := counts.Contexts[term]
+	r.Offer(concordance.NewSample(text, spans, start, end, s.width), s.k)
+	counts.Contexts[term] = r
+}
+
+// credit counts a word, and form as a variant of it unless it is the word itself
+func (c *Counts) credit(word, form string) {
+	c.Words[word]++
+	if form == word {
+		return
+	}
+	if c.Variants == nil {
+		c.Variants = make(map[string]map[string]int)
+	}
+	if c.Variants[word] == nil {
+		c.Variants[word] = make(map[string]int)
+	}
+	c.Variants[word][form]++
+}
+
+// New creates a new Processor. A nil logger discards all log output. Counts are
+// attributed to categories when the wordbank is also a Categorizer, its phrases
+// are counted as single terms when it is a PhraseMatcher, and variants are
+// credited to their words when it is a Resolver.
+func New(wordBank WordValidator, logger *slog.Logger) *Processor {
 	return &Processor{
-		wordBank:  wordBank,
-		verbose:   verbose,
-		wordRegex: regexp.MustCompile(`[a-zA-Z]+`),
+		main:   newLanguage(wordBank, regexp.MustCompile(`[a-zA-Z]+`)),
+		logger: logging.OrDiscard(logger),
 	}
 }
 
-// ProcessText processes text and returns word counts
+// DetectLanguages makes the processor identify the language of each text with
+// identifier, counting texts in mainLanguage with the wordbank given to New, taking
+// words to be the runs of letters matching wordRegex, and those in a language added
+// with AddLanguage with its own. Other texts are skipped.
+func (p *Processor) DetectLanguages(identifier Identifier, mainLanguage string, wordRegex *regexp.Regexp) {
+	p.identifier = identifier
+	p.main.wordRegex = wordRegex
+	p.languages = map[string]*language{mainLanguage: p.main}
+}
+
+// AddLanguage counts texts identified as the language with the ISO 639-1 code
+// using wordBank, taking words to be the runs of letters matching wordRegex. Call
+// it after DetectLanguages and before processing any text.
+func (p *Processor) AddLanguage(code string, wordBank WordValidator, wordRegex *regexp.Regexp) {
+	p.languages[code] = newLanguage(wordBank, wordRegex)
+}
+
+// SetWordBank replaces the wordbank given to New. Texts already being processed
+// finish with the previous one; it is safe to call while other goroutines process text.
+func (p *Processor) SetWordBank(wordBank WordValidator) {
+	v := newValidator(wordBank)
+	p.main.validator.Store(v)
+	p.logger.Info("replaced wordbank", "version", v.version)
+}
+
+// WordBankVersion returns the version of the current wordbank given to New, or ""
+// when it is not Versioned
+func (p *Processor) WordBankVersion() string {
+	return p.main.validator.Load().version
+}
+
+// CountPhraseWords makes the processor count each word of a matched phrase on its
+// own as well as the phrase. By default a phrase's words count only as the phrase.
+func (p *Processor) CountPhraseWords() {
+	p.countPhraseWords = true
+}
+
+// CollectStatistics makes the processor measure the vocabulary and readability of
+// each text it counts
+func (p *Processor) CollectStatistics() {
+	p.statistics = true
+}
+
+// KeepContexts makes the processor sample up to k contexts of each counted word
+// in a text, each with width tokens either side, for a concordance. With words,
+// only those words and phrases are sampled.
+func (p *Processor) KeepContexts(k, width int, words []string) {
+	p.contexts = &contextSampler{k: k, width: width}
+	if len(words) > 0 {
+		p.contexts.words = make(map[string]bool, len(words))
+		for _, word := range words {
+			p.contexts.words[word] = true
+		}
+	}
+}
+
+// Instrument registers the processor's metrics with reg
+func (p *Processor) Instrument(reg *metrics.Registry) {
+	p.articles = reg.NewCounterVec("essay_processor_articles_total",
+		"Articles tokenized and counted.")
+	p.tokens = reg.NewCounterVec("essay_processor_tokens_total",
+		"Tokens seen by the processor; result is \"counted\" for wordbank words and \"rejected\" otherwise.",
+		"result")
+	p.skipped = reg.NewCounterVec("essay_processor_skipped_total",
+		"Articles not counted because of their language, by reason (\"undetermined\" or \"unsupported\").",
+		"reason")
+	p.duration = reg.NewHistogramVec("essay_processor_duration_seconds",
+		"Time to tokenize and count one article.",
+		metrics.DefBuckets)
+}
+
+// ProcessText processes text and returns word counts, with any wordbank phrases counted as single terms
 func (p *Processor) ProcessText(text string) map[string]int {
-	wordCounts := make(map[string]int)
+	return p.Process(text).Words
+}
+
+// Process counts the words in text like ProcessText, also reporting the variants
+// credited to each word. When detecting languages, a text whose language is not
+// counted is skipped, with the reason in Skipped.
+func (p *Processor) Process(text string) Counts {
+	start := time.Now()
+
+	lang := p.main
+	var code string
+	if p.identifier != nil {
+		code = p.identifier.Identify(text)
+		if code == "" {
+			return p.skip(Counts{Skipped: SkipUndetermined})
+		}
+		if lang = p.languages[code]; lang == nil {
+			return p.skip(Counts{Language: code, Skipped: SkipUnsupported})
+		}
+	}
+	v := lang.validator.Load()
+
+	var counts Counts
+	if v.phrases != nil {
+		counts = p.processPhrases(v, lang.wordRegex, text, start)
+	} else {
+		counts = p.processWords(v, lang.wordRegex, text, start)
+	}
+	counts.Categories = v.categories(counts.Words)
+	counts.WordBank = v.version
+	counts.Language = code
+	if p.statistics {
+		stats := textstats.NewArticle(text, tokenFrequencies(lang.wordRegex, text))
+		counts.Statistics = &stats
+	}
+	return counts
+}
+
+// tokenFrequencies counts every token of text, lowercased, whether or not the
+// wordbank counts it
+func tokenFrequencies(wordRegex *regexp.Regexp, text string) map[string]int {
+	frequencies := make(map[string]int)
+	for _, token := range wordRegex.FindAllString(text, -1) {
+		frequencies[strings.ToLower(token)]++
+	}
+	return frequencies
+}
+
+// skip records a text that is not counted
+func (p *Processor) skip(counts Counts) Counts {
+	p.skipped.Inc(counts.Skipped)
+	p.logger.Debug("skipped text", "reason", counts.Skipped, "language", counts.Language)
+	return counts
+}
+
+// processWords counts the wordbank words in text
+func (p *Processor) processWords(v *validator, wordRegex *regexp.Regexp, text string, start time.Time) Counts {
+	counts := Counts{Words: make(map[string]int)}
+	counted := 0
 
 	// Extract all words using regex
-	words := p.wordRegex.FindAllString(text, -1)
+	spans := wordRegex.FindAllStringIndex(text, -1)
 
-	for _, word := range words {
+	for i, span := range spans {
 		// Convert to lowercase for case-insensitive counting
-		word = strings.ToLower(word)
+		word := strings.ToLower(text[span[0]:span[1]])
 
-		// Validate word using wordbank (already filtered during loading)
-		if p.wordBank.IsValid(word) {
-			wordCounts[word]++
+		if term := v.count(&counts, word); term != "" {
+			counted++
+			p.contexts.sample(&counts, term, text, spans, i, i+1)
 		}
 	}
 
-	return wordCounts
+	p.observe(start, len(spans), counted)
+	return counts
+}
+
+// count credits a lowercase word, or the word it is a variant of, returning the
+// word credited or "" when neither was counted
+func (v *validator) count(counts *Counts, word string) string {
+	// Validate word using wordbank (already filtered during loading)
+	if v.wordBank.IsValid(word) {
+		counts.credit(word, word)
+		return word
+	}
+	if v.resolver != nil {
+		if term := v.resolver.Resolve(word); term != "" {
+			counts.credit(term, word)
+			return term
+		}
+	}
+	return ""
+}
+
+// processPhrases counts words like ProcessText, matching the longest phrase at
+// each token first. A phrase only spans tokens separated by spaces or hyphens,
+// so it never crosses a sentence or other punctuation.
+func (p *Processor) processPhrases(v *validator, wordRegex *regexp.Regexp, text string, start time.Time) Counts {
+	counts := Counts{Words: make(map[string]int)}
+	counted := 0
+
+	spans := wordRegex.FindAllStringIndex(text, -1)
+	tokens := make([]string, len(spans))
+	for i, span := range spans {
+		tokens[i] = strings.ToLower(text[span[0]:span[1]])
+	}
+
+	// runEnd is the end of the run of joined tokens containing the current one
+	runEnd := 0
+	for i := 0; i < len(tokens); {
+		if runEnd <= i {
+			runEnd = i + 1
+			for runEnd < len(tokens) && joins(text[spans[runEnd-1][1]:spans[runEnd][0]]) {
+				runEnd++
+			}
+		}
+
+		if phrase, n := v.phrases.MatchPhrase(tokens[i:runEnd]); n > 0 {
+			term := v.phraseTerm(phrase)
+			counts.credit(term, phrase)
+			p.contexts.sample(&counts, term, text, spans, i, i+n)
+			counted += n
+			if p.countPhraseWords {
+				for _, word := range tokens[i : i+n] {
+					if v.wordBank.IsValid(word) {
+						counts.Words[word]++
+					}
+				}
+			}
+			i += n
+			continue
+		}
+
+		if term := v.count(&counts, tokens[i]); term != "" {
+			counted++
+			p.contexts.sample(&counts, term, text, spans, i, i+1)
+		}
+		i++
+	}
+
+	p.observe(start, len(tokens), counted)
+	return counts
+}
+
+// phraseTerm returns the word a matched phrase is credited to, which is another
+// word when the phrase is one of its aliases
+func (v *validator) phraseTerm(phrase string) string {
+	if v.resolver != nil && !v.wordBank.IsValid(phrase) {
+		if term := v.resolver.Resolve(phrase); term != "" {
+			return term
+		}
+	}
+	return phrase
+}
+
+// joins reports whether the text between two tokens keeps them in one phrase
+func joins(separator string) bool {
+	return strings.Trim(separator, " \t\n\r-") == ""
+}
+
+// observe records the metrics for one processed article
+func (p *Processor) observe(start time.Time, tokens, counted int) {
+	p.articles.Inc()
+	p.tokens.Add(float64(counted), "counted")
+	p.tokens.Add(float64(tokens-counted), "rejected")
+	p.duration.Observe(time.Since(start).Seconds())
+}
+
+// Categories returns the category of each counted word that has one in the
+// current wordbank, or nil when none do
+func (p *Processor) Categories(wordCounts map[string]int) map[string]string {
+	return p.main.validator.Load().categories(wordCounts)
+}
+
+func (v *validator) categories(wordCounts map[string]int) map[string]string {
+	if v.categorizer == nil {
+		return nil
+	}
+
+	var categories map[string]string
+	for word := range wordCounts {
+		if category := v.categorizer.Category(word); category != "" {
+			if categories == nil {
+				categories = make(map[string]string)
+			}
+			categories[word] = category
+		}
+	}
+	return categories
 }
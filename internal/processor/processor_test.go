@@ -1,8 +1,15 @@
 package processor
 
 import (
+	"io"
+	"log/slog"
 	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/firefly/essay-analyzer/internal/metrics"
 )
 
 // MockWordBank implements WordValidator for testing
@@ -28,41 +35,42 @@ func (m *MockWordBank) IsValid(word string) bool {
 func TestNew(t *testing.T) {
 	mockWordBank := NewMockWordBank([]string{"test", "word"})
 
-	// Test non-verbose processor
-	processor := New(mockWordBank, false)
+	// Test processor without a logger
+	processor := New(mockWordBank, nil)
 
 	if processor == nil {
 		t.Fatal("Expected processor to be created")
 	}
 
-	if processor.wordBank != mockWordBank {
+	if processor.main.validator.Load().wordBank != mockWordBank {
 		t.Error("Expected wordBank to be set correctly")
 	}
 
-	if processor.verbose {
-		t.Error("Expected verbose to be false")
+	if processor.logger == nil {
+		t.Error("Expected a discarding logger when none is given")
 	}
 
-	if processor.wordRegex == nil {
+	if processor.main.wordRegex == nil {
 		t.Error("Expected wordRegex to be initialized")
 	}
 
 	// Test that regex pattern is correct
-	testWords := processor.wordRegex.FindAllString("hello world 123 test-word", -1)
+	testWords := processor.main.wordRegex.FindAllString("hello world 123 test-word", -1)
 	expected := []string{"hello", "world", "test", "word"}
 	if !reflect.DeepEqual(testWords, expected) {
 		t.Errorf("Expected regex to extract %v, got %v", expected, testWords)
 	}
 }
 
-// TestNew_Verbose tests the New constructor with verbose flag
-func TestNew_Verbose(t *testing.T) {
+// TestNew_Logger tests the New constructor with a logger
+func TestNew_Logger(t *testing.T) {
 	mockWordBank := NewMockWordBank([]string{"test"})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	processor := New(mockWordBank, true)
+	processor := New(mockWordBank, logger)
 
-	if !processor.verbose {
-		t.Error("Expected verbose to be true")
+	if processor.logger != logger {
+		t.Error("Expected the given logger to be used")
 	}
 }
 
@@ -70,7 +78,7 @@ func TestNew_Verbose(t *testing.T) {
 func TestProcessText_BasicCounting(t *testing.T) {
 	validWords := []string{"technology", "innovation", "computer", "software"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "Technology and innovation drive computer software development. Technology is key."
 
@@ -92,7 +100,7 @@ func TestProcessText_BasicCounting(t *testing.T) {
 func TestProcessText_CaseInsensitive(t *testing.T) {
 	validWords := []string{"technology", "innovation"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "TECHNOLOGY Technology innovation INNOVATION Innovation"
 
@@ -112,7 +120,7 @@ func TestProcessText_CaseInsensitive(t *testing.T) {
 func TestProcessText_FilterInvalidWords(t *testing.T) {
 	validWords := []string{"technology", "computer"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "Technology and innovation drive computer software development"
 
@@ -134,7 +142,7 @@ func TestProcessText_FilterInvalidWords(t *testing.T) {
 func TestProcessText_EmptyText(t *testing.T) {
 	validWords := []string{"technology"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	result := processor.ProcessText("")
 
@@ -149,7 +157,7 @@ func TestProcessText_EmptyText(t *testing.T) {
 func TestProcessText_NoValidWords(t *testing.T) {
 	validWords := []string{"technology", "computer"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "hello world this is a test"
 
@@ -166,7 +174,7 @@ func TestProcessText_NoValidWords(t *testing.T) {
 func TestProcessText_SpecialCharacters(t *testing.T) {
 	validWords := []string{"technology", "innovation", "computer"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "Technology! Innovation? Computer... technology-innovation, computer's innovation."
 
@@ -188,7 +196,7 @@ func TestProcessText_SpecialCharacters(t *testing.T) {
 func TestProcessText_Numbers(t *testing.T) {
 	validWords := []string{"technology", "version"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "Technology 2.0 version 123 technology version456"
 
@@ -209,7 +217,7 @@ func TestProcessText_Numbers(t *testing.T) {
 func TestProcessText_RepeatedWords(t *testing.T) {
 	validWords := []string{"test"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	text := "test test test test test"
 
@@ -228,7 +236,7 @@ func TestProcessText_RepeatedWords(t *testing.T) {
 func TestProcessText_LargeText(t *testing.T) {
 	validWords := []string{"technology", "innovation", "computer", "software", "development"}
 	mockWordBank := NewMockWordBank(validWords)
-	processor := New(mockWordBank, false)
+	processor := New(mockWordBank, nil)
 
 	// Simulate larger article text
 	text := `
@@ -253,3 +261,301 @@ func TestProcessText_LargeText(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, result)
 	}
 }
+
+func TestProcessText_Instrumented(t *testing.T) {
+	reg := metrics.NewRegistry()
+	processor := New(NewMockWordBank([]string{"technology"}), nil)
+	processor.Instrument(reg)
+
+	processor.ProcessText("Technology beats the other technology")
+
+	if got := processor.articles.Value(); got != 1 {
+		t.Errorf("Expected 1 article, got %v", got)
+	}
+	if got := processor.tokens.Value("counted"); got != 2 {
+		t.Errorf("Expected 2 counted tokens, got %v", got)
+	}
+	if got := processor.tokens.Value("rejected"); got != 3 {
+		t.Errorf("Expected 3 rejected tokens, got %v", got)
+	}
+}
+
+// MockCategorizedWordBank adds categories to MockWordBank
+type MockCategorizedWordBank struct {
+	*MockWordBank
+	categories map[string]string
+}
+
+// Category implements Categorizer interface
+func (m *MockCategorizedWordBank) Category(word string) string {
+	return m.categories[word]
+}
+
+// TestCategories tests that counted words are attributed to their categories
+func TestCategories(t *testing.T) {
+	wordBank := &MockCategorizedWordBank{
+		MockWordBank: NewMockWordBank([]string{"privacy", "tracking", "console", "weather"}),
+		categories:   map[string]string{"privacy": "privacy", "tracking": "privacy", "console": "gaming"},
+	}
+	processor := New(wordBank, nil)
+
+	wordCounts := processor.ProcessText("Privacy and tracking on every console, whatever the weather")
+	expected := map[string]string{"privacy": "privacy", "tracking": "privacy", "console": "gaming"}
+	if got := processor.Categories(wordCounts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected categories %v, got %v", expected, got)
+	}
+
+	if got := processor.Categories(processor.ProcessText("weather")); got != nil {
+		t.Errorf("Expected no categories for uncategorized words, got %v", got)
+	}
+
+	// A wordbank without categories attributes nothing
+	plain := New(NewMockWordBank([]string{"privacy"}), nil)
+	if got := plain.Categories(map[string]int{"privacy": 1}); got != nil {
+		t.Errorf("Expected nil categories without a Categorizer, got %v", got)
+	}
+}
+
+// MockPhraseWordBank adds multi-word terms to MockWordBank
+type MockPhraseWordBank struct {
+	*MockWordBank
+	phrases map[string]bool
+}
+
+// MatchPhrase implements PhraseMatcher interface by trying the longest prefix first
+func (m *MockPhraseWordBank) MatchPhrase(tokens []string) (string, int) {
+	for n := len(tokens); n >= 2; n-- {
+		if phrase := strings.Join(tokens[:n], " "); m.phrases[phrase] {
+			return phrase, n
+		}
+	}
+	return "", 0
+}
+
+// Phrases implements PhraseMatcher interface
+func (m *MockPhraseWordBank) Phrases() bool {
+	return len(m.phrases) > 0
+}
+
+// TestProcessText_Phrases tests greedy longest-match counting of multi-word terms
+func TestProcessText_Phrases(t *testing.T) {
+	wordBank := &MockPhraseWordBank{
+		MockWordBank: NewMockWordBank([]string{"apple", "watch", "reality", "headset"}),
+		phrases:      map[string]bool{"virtual reality": true, "virtual reality headset": true, "apple watch": true},
+	}
+	text := "A virtual reality headset, an Apple Watch and an Apple-Watch. Virtual reality is virtual. Reality is an apple; watch out."
+
+	processor := New(wordBank, nil)
+	expected := map[string]int{
+		"virtual reality headset": 1, // Longest match wins over "virtual reality"
+		"apple watch":             2, // Hyphens join a phrase
+		"virtual reality":         1,
+		"reality":                 1, // The full stop ends "virtual"
+		"apple":                   1, // The semicolon splits "apple; watch"
+		"watch":                   1,
+	}
+	if got := processor.ProcessText(text); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	processor.CountPhraseWords()
+	expected = map[string]int{
+		"virtual reality headset": 1,
+		"apple watch":             2,
+		"virtual reality":         1,
+		"reality":                 3,
+		"headset":                 1,
+		"apple":                   3,
+		"watch":                   3,
+	}
+	if got := processor.ProcessText(text); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v with phrase words, got %v", expected, got)
+	}
+}
+
+// MockResolverWordBank adds aliases to MockPhraseWordBank
+type MockResolverWordBank struct {
+	*MockPhraseWordBank
+	aliases map[string]string
+}
+
+// Resolve implements Resolver interface
+func (m *MockResolverWordBank) Resolve(word string) string {
+	return m.aliases[word]
+}
+
+// TestProcess_Variants tests that aliases are credited to their word and reported as variants
+func TestProcess_Variants(t *testing.T) {
+	wordBank := &MockResolverWordBank{
+		MockPhraseWordBank: &MockPhraseWordBank{
+			MockWordBank: NewMockWordBank([]string{"ereader", "color", "wifi"}),
+			phrases:      map[string]bool{"e reader": true, "wi fi": true},
+		},
+		aliases: map[string]string{"e reader": "ereader", "wi fi": "wifi", "colour": "color"},
+	}
+	text := "An e-reader, an E reader and an ereader. Colour or color? Wi-Fi and WiFi."
+
+	counts := New(wordBank, nil).Process(text)
+	expectedWords := map[string]int{"ereader": 3, "color": 2, "wifi": 2}
+	if !reflect.DeepEqual(counts.Words, expectedWords) {
+		t.Errorf("Expected %v, got %v", expectedWords, counts.Words)
+	}
+	expectedVariants := map[string]map[string]int{
+		"ereader": {"e reader": 2},
+		"color":   {"colour": 1},
+		"wifi":    {"wi fi": 1},
+	}
+	if !reflect.DeepEqual(counts.Variants, expectedVariants) {
+		t.Errorf("Expected variants %v, got %v", expectedVariants, counts.Variants)
+	}
+
+	// Without a Resolver nothing is reported as a variant
+	if plain := New(NewMockWordBank([]string{"color"}), nil).Process(text); plain.Variants != nil {
+		t.Errorf("Expected no variants, got %v", plain.Variants)
+	}
+}
+
+// MockVersionedWordBank adds a version to MockWordBank
+type MockVersionedWordBank struct {
+	*MockWordBank
+	version string
+}
+
+// Version implements Versioned interface
+func (m *MockVersionedWordBank) Version() string {
+	return m.version
+}
+
+// TestSetWordBank tests that a replaced wordbank counts later texts, tagged with its version
+func TestSetWordBank(t *testing.T) {
+	first := &MockVersionedWordBank{NewMockWordBank([]string{"privacy"}), "v1"}
+	second := &MockVersionedWordBank{NewMockWordBank([]string{"privacy", "tracking"}), "v2"}
+	text := "Privacy and tracking"
+
+	processor := New(first, nil)
+	counts := processor.Process(text)
+	if !reflect.DeepEqual(counts.Words, map[string]int{"privacy": 1}) || counts.WordBank != "v1" {
+		t.Errorf("Expected privacy counted with v1, got %v with %q", counts.Words, counts.WordBank)
+	}
+
+	processor.SetWordBank(second)
+	if processor.WordBankVersion() != "v2" {
+		t.Errorf("Expected version v2, got %q", processor.WordBankVersion())
+	}
+	counts = processor.Process(text)
+	if !reflect.DeepEqual(counts.Words, map[string]int{"privacy": 1, "tracking": 1}) || counts.WordBank != "v2" {
+		t.Errorf("Expected privacy and tracking counted with v2, got %v with %q", counts.Words, counts.WordBank)
+	}
+
+	// Swapping while texts are processed is safe
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				processor.Process(text)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		processor.SetWordBank([]WordValidator{first, second}[i%2])
+	}
+	wg.Wait()
+}
+
+// MockIdentifier identifies texts by a marker word
+type MockIdentifier map[string]string
+
+// Identify implements Identifier interface
+func (m MockIdentifier) Identify(text string) string {
+	for marker, language := range m {
+		if strings.Contains(text, marker) {
+			return language
+		}
+	}
+	return ""
+}
+
+// TestDetectLanguages tests that each text is counted with its language's wordbank
+// and tokenizer, and texts in other languages are skipped
+func TestDetectLanguages(t *testing.T) {
+	processor := New(NewMockWordBank([]string{"privacy", "tracking"}), nil)
+	processor.DetectLanguages(MockIdentifier{"the": "en", "la": "es", "der": "de"}, "en", regexp.MustCompile(`[a-zA-Z]+`))
+	processor.AddLanguage("es", NewMockWordBank([]string{"privacidad", "señal"}), regexp.MustCompile(`\p{L}+`))
+
+	counts := processor.Process("the privacy of tracking")
+	if !reflect.DeepEqual(counts.Words, map[string]int{"privacy": 1, "tracking": 1}) || counts.Language != "en" {
+		t.Errorf("Expected English counts, got %v in %q", counts.Words, counts.Language)
+	}
+
+	counts = processor.Process("la privacidad y la Señal")
+	if !reflect.DeepEqual(counts.Words, map[string]int{"privacidad": 1, "señal": 1}) || counts.Language != "es" {
+		t.Errorf("Expected Spanish counts, got %v in %q", counts.Words, counts.Language)
+	}
+
+	counts = processor.Process("der Datenschutz")
+	if counts.Skipped != SkipUnsupported || counts.Language != "de" || counts.Words != nil {
+		t.Errorf("Expected German to be skipped as unsupported, got %+v", counts)
+	}
+
+	counts = processor.Process("privacy")
+	if counts.Skipped != SkipUndetermined || counts.Language != "" {
+		t.Errorf("Expected an unidentified text to be skipped as undetermined, got %+v", counts)
+	}
+}
+
+func TestCollectStatistics(t *testing.T) {
+	processor := New(NewMockWordBank([]string{"robots", "laptops"}), nil)
+	if counts := processor.Process("Robots build laptops."); counts.Statistics != nil {
+		t.Errorf("Expected no statistics by default, got %+v", counts.Statistics)
+	}
+
+	processor.CollectStatistics()
+	counts := processor.Process("Robots build robots. Laptops too.")
+	stats := counts.Statistics
+	if stats == nil {
+		t.Fatal("Expected statistics")
+	}
+	if stats.Words != 5 || stats.Sentences != 2 {
+		t.Errorf("Expected 5 words in 2 sentences, got %d in %d", stats.Words, stats.Sentences)
+	}
+	// The vocabulary covers every token, not only the words counted
+	if stats.Tokens != 5 || stats.Types != 4 || stats.Hapax != 3 || stats.Frequencies["build"] != 1 {
+		t.Errorf("Expected 5 tokens of 4 types with 3 hapax, got %+v", stats)
+	}
+	if counts.Words["build"] != 0 {
+		t.Errorf("Expected build to stay out of the word counts, got %d", counts.Words["build"])
+	}
+}
+
+func TestKeepContexts(t *testing.T) {
+	processor := New(NewMockWordBank([]string{"privacy", "tracking"}), nil)
+	processor.KeepContexts(2, 2, []string{"privacy"})
+
+	counts := processor.Process("Privacy matters. Online tracking erodes privacy for everyone, and privacy is rare.")
+	if len(counts.Contexts) != 1 {
+		t.Fatalf("Expected contexts for privacy alone, got %v", counts.Contexts)
+	}
+	contexts := counts.Contexts["privacy"]
+	if len(contexts) != 2 {
+		t.Fatalf("Expected 2 of the 3 contexts of privacy, got %d", len(contexts))
+	}
+	want := map[string]bool{
+		"|Privacy|matters. Online":             true,
+		"tracking erodes|privacy|for everyone": true,
+		"everyone, and|privacy|is rare":        true,
+	}
+	for _, sample := range contexts {
+		key := sample.Left + "|" + sample.Match + "|" + sample.Right
+		if !want[key] {
+			t.Errorf("Unexpected context %q", key)
+		}
+	}
+
+	processor.KeepContexts(5, 1, nil)
+	counts = processor.Process("Online tracking erodes privacy")
+	if len(counts.Contexts["tracking"]) != 1 || len(counts.Contexts["privacy"]) != 1 {
+		t.Errorf("Expected a context for every counted word, got %v", counts.Contexts)
+	}
+}
@@ -0,0 +1,226 @@
+package simhash
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// ShingleSize is the number of consecutive words hashed together as one feature
+	ShingleSize = 3
+
+	// SuggestedThreshold is a Hamming distance that suits news articles. A handful of
+	// edited words in a news-length article moves about this many bits, while
+	// unrelated articles differ in around half of the 64.
+	SuggestedThreshold = 6
+
+	// MaxThreshold is the largest threshold an Index supports. Its threshold+1
+	// bands keep at least 8 bits each; narrower bands are shared by so many
+	// fingerprints that almost every article becomes a candidate.
+	MaxThreshold = 7
+)
+
+// Fingerprint returns the 64-bit SimHash of text, built from its lowercase
+// word shingles. Texts that differ in a few words get fingerprints a small
+// Hamming distance apart. ok is false when text has no words.
+func Fingerprint(text string) (fingerprint uint64, ok bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0, false
+	}
+
+	// Texts shorter than a shingle are hashed as a single feature
+	size := min(ShingleSize, len(words))
+	var weights [64]int
+	for i := 0; i+size <= len(words); i++ {
+		h := hashShingle(words[i : i+size])
+		for bit := 0; bit < 64; bit++ {
+			if h&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+// hashShingle hashes a run of words, mixing the bits so similar shingles hash far apart
+func hashShingle(words []string) uint64 {
+	h := fnv.New64a()
+	for i, word := range words {
+		if i > 0 {
+			h.Write([]byte{' '})
+		}
+		h.Write([]byte(word))
+	}
+
+	// splitmix64 finalizer; FNV alone leaves the high bits poorly distributed
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Distance returns the number of bits in which two fingerprints differ
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Cluster is an article together with the later articles found to be near duplicates of it
+type Cluster struct {
+	URL        string      `json:"url"`
+	Duplicates []Duplicate `json:"duplicates"`
+}
+
+// Duplicate is an article skipped as a near duplicate and its distance from the cluster's article
+type Duplicate struct {
+	URL      string `json:"url"`
+	Distance int    `json:"distance"`
+}
+
+// Index finds articles whose fingerprints are within a Hamming distance
+// threshold of one added earlier. Fingerprints are split into threshold+1
+// bands; two fingerprints within the threshold must agree exactly on at least
+// one band, so only articles sharing a band are compared. A nil Index finds
+// no duplicates. It is safe for concurrent use.
+type Index struct {
+	mu        sync.Mutex
+	threshold int
+	bands     []band
+	tables    []map[uint64][]int // Band value to entries, one table per band
+	entries   []entry
+}
+
+// band is a run of fingerprint bits
+type band struct {
+	shift uint
+	mask  uint64
+}
+
+// entry is an article kept in the index and the near duplicates found of it
+type entry struct {
+	url         string
+	fingerprint uint64
+	duplicates  []Duplicate
+}
+
+// NewIndex creates an Index for the given threshold, clamped to MaxThreshold.
+// A negative threshold disables detection and returns nil.
+func NewIndex(threshold int) *Index {
+	if threshold < 0 {
+		return nil
+	}
+	threshold = min(threshold, MaxThreshold)
+
+	n := threshold + 1
+	idx := &Index{
+		threshold: threshold,
+		bands:     make([]band, n),
+		tables:    make([]map[uint64][]int, n),
+	}
+	// Spread the 64 bits as evenly as possible, earlier bands taking the remainder
+	shift := uint(0)
+	for i := range idx.bands {
+		width := uint(64 / n)
+		if i < 64%n {
+			width++
+		}
+		idx.bands[i] = band{shift: shift, mask: 1<<width - 1}
+		idx.tables[i] = make(map[uint64][]int)
+		shift += width
+	}
+	return idx
+}
+
+// Threshold returns the largest distance treated as a near duplicate, or -1 when disabled
+func (idx *Index) Threshold() int {
+	if idx == nil {
+		return -1
+	}
+	return idx.threshold
+}
+
+// Add checks url's fingerprint against the articles added so far. If one is
+// within the threshold, url is recorded as its near duplicate and Add returns
+// that article's URL, their distance and true; otherwise url is added to the index.
+func (idx *Index) Add(url string, fingerprint uint64) (original string, distance int, duplicate bool) {
+	if idx == nil {
+		return "", 0, false
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// The closest candidate wins, the earliest on a tie
+	best, bestDistance := -1, 0
+	for i, b := range idx.bands {
+		for _, candidate := range idx.tables[i][b.value(fingerprint)] {
+			d := Distance(fingerprint, idx.entries[candidate].fingerprint)
+			if d > idx.threshold {
+				continue
+			}
+			if best < 0 || d < bestDistance || (d == bestDistance && candidate < best) {
+				best, bestDistance = candidate, d
+			}
+		}
+	}
+
+	if best >= 0 {
+		e := &idx.entries[best]
+		e.duplicates = append(e.duplicates, Duplicate{URL: url, Distance: bestDistance})
+		return e.url, bestDistance, true
+	}
+
+	id := len(idx.entries)
+	idx.entries = append(idx.entries, entry{url: url, fingerprint: fingerprint})
+	for i, b := range idx.bands {
+		value := b.value(fingerprint)
+		idx.tables[i][value] = append(idx.tables[i][value], id)
+	}
+	return "", 0, false
+}
+
+// value extracts the band's bits from a fingerprint
+func (b band) value(fingerprint uint64) uint64 {
+	return fingerprint >> b.shift & b.mask
+}
+
+// Clusters returns every article that has near duplicates, sorted by URL
+func (idx *Index) Clusters() []Cluster {
+	if idx == nil {
+		return nil
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var clusters []Cluster
+	for _, e := range idx.entries {
+		if len(e.duplicates) == 0 {
+			continue
+		}
+		duplicates := make([]Duplicate, len(e.duplicates))
+		copy(duplicates, e.duplicates)
+		sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].URL < duplicates[j].URL })
+		clusters = append(clusters, Cluster{URL: e.url, Duplicates: duplicates})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].URL < clusters[j].URL })
+	return clusters
+}
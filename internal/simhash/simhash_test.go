@@ -0,0 +1,177 @@
+package simhash
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const article = `Apple announced a new laptop on Tuesday with a faster processor, a brighter
+display and a battery the company says lasts a full day of ordinary use. The machine
+ships next month in two sizes and three colors, and prices start slightly higher than
+the model it replaces. Reviewers who tried it at the launch event praised the keyboard
+and the speakers but noted that the port selection has not changed since last year.
+
+The processor is built on a newer manufacturing process, which the company credits for
+most of the battery improvement. In its own benchmarks the chip compiled code about a
+third faster than its predecessor and exported video in roughly half the time, although
+those figures came from the larger model with the extra graphics cores enabled.
+
+The display reaches a higher peak brightness for photos and films and adapts its refresh
+rate to what is on screen, dropping when the picture is still to save power. The webcam
+has also been upgraded, a change many owners of the previous model had asked for after a
+year of video calls from home offices and kitchen tables.
+
+Analysts expect the laptop to sell well through the holiday season, though some warned
+that higher component costs could squeeze margins. Preorders open on Friday in most
+countries, with deliveries to business customers following a few weeks later.`
+
+func TestFingerprint_SimilarTextsAreClose(t *testing.T) {
+	original, ok := Fingerprint(article)
+	if !ok {
+		t.Fatal("Expected a fingerprint for a non-empty article")
+	}
+
+	again, _ := Fingerprint(strings.ToUpper(article))
+	if original != again {
+		t.Errorf("Expected case to be ignored, got distance %d", Distance(original, again))
+	}
+
+	updated, _ := Fingerprint(strings.Replace(article, "Tuesday", "Wednesday", 1))
+	if d := Distance(original, updated); d > SuggestedThreshold {
+		t.Errorf("Expected a corrected article within %d bits, got %d", SuggestedThreshold, d)
+	}
+
+	other, _ := Fingerprint(`The city council voted to extend the bike lane network along the
+river, a project that will close two streets for most of the summer while crews repave
+them and install new signals at the busiest intersections downtown.`)
+	if d := Distance(original, other); d <= SuggestedThreshold {
+		t.Errorf("Expected an unrelated article more than %d bits away, got %d", SuggestedThreshold, d)
+	}
+}
+
+func TestFingerprint_NoWords(t *testing.T) {
+	if _, ok := Fingerprint(" \n\t... "); ok {
+		t.Error("Expected no fingerprint for text without words")
+	}
+	if _, ok := Fingerprint("one two"); !ok {
+		t.Error("Expected a fingerprint for text shorter than a shingle")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(0b1011, 0b0110); d != 3 {
+		t.Errorf("Expected distance 3, got %d", d)
+	}
+}
+
+func TestIndex_FindsNearDuplicates(t *testing.T) {
+	idx := NewIndex(3)
+
+	if _, _, dup := idx.Add("a", 0xFFFF0000FFFF0000); dup {
+		t.Fatal("Expected the first article not to be a duplicate")
+	}
+	// Three bits differ, one in each of three bands
+	if original, distance, dup := idx.Add("b", 0xFFFF0000FFFF0000^(1|1<<20|1<<40)); !dup || original != "a" || distance != 3 {
+		t.Errorf("Expected b to duplicate a at distance 3, got %q, %d, %v", original, distance, dup)
+	}
+	// Four bits differ, one in every band
+	if _, _, dup := idx.Add("c", 0xFFFF0000FFFF0000^(1|1<<20|1<<40|1<<60)); dup {
+		t.Error("Expected c to be beyond the threshold")
+	}
+
+	clusters := idx.Clusters()
+	if len(clusters) != 1 || clusters[0].URL != "a" {
+		t.Fatalf("Expected one cluster for a, got %+v", clusters)
+	}
+	if dups := clusters[0].Duplicates; len(dups) != 1 || dups[0] != (Duplicate{URL: "b", Distance: 3}) {
+		t.Errorf("Expected b at distance 3, got %+v", dups)
+	}
+}
+
+func TestIndex_PrefersClosest(t *testing.T) {
+	idx := NewIndex(3)
+	idx.Add("far", 0b111)
+	idx.Add("near", 0xFF00000000000000)
+
+	if original, _, _ := idx.Add("x", 0xFF00000000000001); original != "near" {
+		t.Errorf("Expected the closest article, got %q", original)
+	}
+}
+
+func TestIndex_ZeroThresholdMatchesExactly(t *testing.T) {
+	idx := NewIndex(0)
+	idx.Add("a", 42)
+
+	if _, _, dup := idx.Add("b", 42); !dup {
+		t.Error("Expected an identical fingerprint to be a duplicate")
+	}
+	if _, _, dup := idx.Add("c", 43); dup {
+		t.Error("Expected a one-bit difference not to be a duplicate")
+	}
+}
+
+func TestIndex_Disabled(t *testing.T) {
+	idx := NewIndex(-1)
+	if idx != nil {
+		t.Fatal("Expected a negative threshold to disable the index")
+	}
+	idx.Add("a", 1)
+	if _, _, dup := idx.Add("b", 1); dup {
+		t.Error("Expected a nil index to find no duplicates")
+	}
+	if idx.Clusters() != nil || idx.Threshold() != -1 {
+		t.Error("Expected a nil index to report nothing")
+	}
+}
+
+func TestIndex_Concurrent(t *testing.T) {
+	idx := NewIndex(SuggestedThreshold)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			idx.Add(fmt.Sprintf("https://example.com/%d", i), 7)
+		}(i)
+	}
+	wg.Wait()
+
+	clusters := idx.Clusters()
+	if len(clusters) != 1 || len(clusters[0].Duplicates) != 19 {
+		t.Errorf("Expected one cluster of 19 duplicates, got %+v", clusters)
+	}
+}
+
+// BenchmarkIndex_MaxThreshold adds random fingerprints to an index of 100,000
+// articles at the suggested and the largest threshold, also reporting how many
+// indexed articles each fingerprint is compared with
+func BenchmarkIndex_MaxThreshold(b *testing.B) {
+	for _, threshold := range []int{SuggestedThreshold, MaxThreshold} {
+		b.Run(fmt.Sprint(threshold), func(b *testing.B) {
+			random := rand.New(rand.NewSource(1))
+			idx := NewIndex(threshold)
+			for i := 0; i < 100000; i++ {
+				idx.Add(fmt.Sprintf("https://example.com/%d", i), random.Uint64())
+			}
+
+			fingerprints := make([]uint64, 1000)
+			candidates := 0
+			for i := range fingerprints {
+				fingerprints[i] = random.Uint64()
+				for j, band := range idx.bands {
+					candidates += len(idx.tables[j][band.value(fingerprints[i])])
+				}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				idx.Add("https://example.com/new", fingerprints[i%len(fingerprints)])
+			}
+			b.ReportMetric(float64(candidates)/float64(len(fingerprints)), "candidates/op")
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package stopwords
+
+// Built-in lists for languages other than English
+const (
+	Dutch      = "dutch"
+	French     = "french"
+	German     = "german"
+	Italian    = "italian"
+	Portuguese = "portuguese"
+	Spanish    = "spanish"
+)
+
+// languages maps ISO 639-1 codes to the built-in list for the language
+var languages = map[string]string{
+	"de": German,
+	"en": English,
+	"es": Spanish,
+	"fr": French,
+	"it": Italian,
+	"nl": Dutch,
+	"pt": Portuguese,
+}
+
+// ForLanguage returns the name of the built-in list for an ISO 639-1 language
+// code, or None when there is none
+func ForLanguage(code string) string {
+	if name, ok := languages[code]; ok {
+		return name
+	}
+	return None
+}
+
+// builtins holds the function word lists other than English
+var builtins = map[string][]string{
+	Dutch: {
+		"aan", "al", "alle", "als", "ben", "bij", "dan", "dat", "de", "deze",
+		"die", "dit", "doen", "door", "dus", "een", "en", "er", "geen", "had",
+		"heb", "hebben", "heeft", "het", "hier", "hij", "hoe", "hun", "ik", "in",
+		"is", "je", "kan", "kunnen", "maar", "me", "meer", "met", "mij", "mijn",
+		"na", "naar", "niet", "nog", "nu", "of", "om", "omdat", "ons", "onze",
+		"ook", "op", "over", "te", "toen", "tot", "u", "uit", "van", "veel",
+		"voor", "want", "was", "waren", "wat", "we", "wel", "werd", "wie", "wij",
+		"wordt", "worden", "zal", "ze", "zich", "zij", "zijn", "zo", "zou", "zonder",
+	},
+	French: {
+		"à", "afin", "ai", "au", "aux", "avait", "avec", "avons", "ce", "ces",
+		"cette", "chez", "comme", "dans", "de", "des", "donc", "du", "elle", "elles",
+		"en", "est", "et", "étaient", "était", "être", "eu", "il", "ils", "je",
+		"la", "le", "les", "leur", "leurs", "lui", "mais", "me", "même", "mes",
+		"moi", "mon", "ne", "nos", "notre", "nous", "on", "ont", "ou", "où",
+		"par", "pas", "plus", "pour", "qu", "que", "qui", "sa", "sans", "se",
+		"ses", "son", "sont", "sur", "ta", "te", "tes", "toi", "ton", "tous",
+		"tout", "très", "tu", "un", "une", "vos", "votre", "vous", "été", "aussi",
+	},
+	German: {
+		"aber", "alle", "als", "also", "am", "an", "auch", "auf", "aus", "bei",
+		"bin", "bis", "da", "damit", "dann", "das", "dass", "dem", "den", "der",
+		"des", "die", "dies", "diese", "doch", "du", "durch", "ein", "eine", "einem",
+		"einen", "einer", "eines", "er", "es", "für", "hat", "hatte", "haben", "ich",
+		"ihr", "ihre", "im", "in", "ist", "ja", "kann", "kein", "mich", "mir",
+		"mit", "nach", "nicht", "noch", "nur", "ob", "oder", "ohne", "sich", "sie",
+		"sind", "so", "über", "um", "und", "uns", "unter", "vom", "von", "vor",
+		"war", "waren", "was", "wenn", "wie", "wir", "wird", "wurde", "zu", "zum", "zur",
+	},
+	Italian: {
+		"a", "ai", "al", "alla", "alle", "anche", "che", "chi", "ci", "come",
+		"con", "da", "dal", "dalla", "degli", "dei", "del", "della", "delle", "di",
+		"e", "è", "essere", "gli", "ha", "hanno", "i", "il", "in", "io",
+		"la", "le", "lei", "li", "lo", "loro", "lui", "ma", "mi", "mio",
+		"ne", "negli", "nel", "nella", "noi", "non", "nostro", "o", "per", "perché",
+		"più", "quale", "quando", "quello", "questa", "questo", "se", "sei", "si", "sia",
+		"siamo", "sono", "su", "sua", "sul", "sulla", "suo", "ti", "tra", "tu",
+		"tutti", "tutto", "un", "una", "uno", "voi", "era", "stato", "fra", "cui",
+	},
+	Portuguese: {
+		"a", "à", "ao", "aos", "as", "até", "com", "como", "da", "das",
+		"de", "dela", "dele", "do", "dos", "e", "é", "ela", "elas", "ele",
+		"eles", "em", "entre", "era", "essa", "esse", "esta", "está", "este", "eu",
+		"foi", "há", "isso", "isto", "já", "lhe", "mais", "mas", "me", "meu",
+		"minha", "muito", "na", "nas", "não", "nem", "no", "nos", "nós", "num",
+		"numa", "o", "os", "ou", "para", "pela", "pelo", "por", "qual", "quando",
+		"que", "quem", "se", "sem", "ser", "seu", "sua", "são", "também", "te",
+		"tem", "ter", "um", "uma", "você", "vocês", "foram", "seus", "suas", "só",
+	},
+	Spanish: {
+		"a", "al", "algo", "como", "con", "cuando", "de", "del", "desde", "donde",
+		"e", "el", "él", "ella", "ellas", "ellos", "en", "entre", "era", "es",
+		"esa", "ese", "eso", "esta", "está", "este", "esto", "fue", "ha", "han",
+		"hay", "la", "las", "le", "les", "lo", "los", "más", "me", "mi",
+		"muy", "nada", "ni", "no", "nos", "nosotros", "o", "otra", "otro", "para",
+		"pero", "por", "porque", "que", "qué", "quien", "se", "sea", "ser", "si",
+		"sí", "sin", "sobre", "son", "su", "sus", "también", "te", "tiene", "todo",
+		"tu", "un", "una", "uno", "unos", "usted", "y", "ya", "yo", "fueron",
+	},
+}
@@ -0,0 +1,126 @@
+package stopwords
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Built-in list names
+const (
+	English = "english" // Common English function words
+	None    = "none"    // No built-in list
+)
+
+// Builtins lists the names accepted by Builtin
+var Builtins = []string{English, Dutch, French, German, Italian, Portuguese, Spanish, None}
+
+// english holds common English function words: articles, pronouns, auxiliaries,
+// prepositions, conjunctions and frequent adverbs
+var english = []string{
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an",
+	"and", "any", "are", "aren", "as", "at", "be", "because", "been", "before",
+	"being", "below", "between", "both", "but", "by", "can", "could", "couldn", "did",
+	"didn", "do", "does", "doesn", "doing", "don", "down", "during", "each", "even",
+	"ever", "every", "few", "for", "from", "further", "had", "hadn", "has", "hasn",
+	"have", "haven", "having", "he", "her", "here", "hers", "herself", "him", "himself",
+	"his", "how", "however", "i", "if", "in", "into", "is", "isn", "it",
+	"its", "itself", "just", "let", "ll", "may", "me", "might", "more", "most",
+	"much", "must", "mustn", "my", "myself", "no", "nor", "not", "now", "of",
+	"off", "on", "once", "one", "only", "or", "other", "our", "ours", "ourselves",
+	"out", "over", "own", "re", "said", "same", "says", "shall", "shan", "she",
+	"should", "shouldn", "so", "some", "such", "than", "that", "the", "their", "theirs",
+	"them", "themselves", "then", "there", "these", "they", "this", "those", "though", "through",
+	"to", "too", "under", "until", "up", "us", "ve", "very", "was", "wasn",
+	"we", "were", "weren", "what", "when", "where", "whether", "which", "while", "who",
+	"whom", "whose", "why", "will", "with", "won", "would", "wouldn", "yet", "you",
+	"your", "yours", "yourself", "yourselves",
+}
+
+// Set is a set of stopwords. A nil Set contains nothing.
+type Set struct {
+	words map[string]bool
+}
+
+// New creates a Set of words, lowercased
+func New(words ...string) *Set {
+	s := &Set{words: make(map[string]bool, len(words))}
+	s.Add(words...)
+	return s
+}
+
+// Builtin returns the built-in list called name, or an empty Set for None
+func Builtin(name string) (*Set, error) {
+	switch name {
+	case English:
+		return New(english...), nil
+	case None, "":
+		return New(), nil
+	}
+	if words, ok := builtins[name]; ok {
+		return New(words...), nil
+	}
+	return nil, fmt.Errorf("unknown stopword list %q (want %s or %s)", name, strings.Join(Builtins[:len(Builtins)-1], ", "), None)
+}
+
+// Load adds the words in a file, one per line, to the Set. Blank lines and lines
+// starting with # are ignored.
+func (s *Set) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("opening stopwords file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		s.Add(word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading stopwords file: %w", err)
+	}
+	return nil
+}
+
+// Add adds words to the Set, lowercased
+func (s *Set) Add(words ...string) {
+	for _, word := range words {
+		s.words[strings.ToLower(word)] = true
+	}
+}
+
+// Contains reports whether word is a stopword, ignoring case
+func (s *Set) Contains(word string) bool {
+	if s == nil {
+		return false
+	}
+	return s.words[strings.ToLower(word)]
+}
+
+// Size returns the number of stopwords
+func (s *Set) Size() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.words)
+}
+
+// Words returns the stopwords in sorted order
+func (s *Set) Words() []string {
+	if s == nil {
+		return nil
+	}
+	words := make([]string, 0, len(s.words))
+	for word := range s.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
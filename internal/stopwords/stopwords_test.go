@@ -0,0 +1,76 @@
+package stopwords
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	english, err := Builtin(English)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, word := range []string{"the", "And", "THAT", "with"} {
+		if !english.Contains(word) {
+			t.Errorf("Expected %q to be an English stopword", word)
+		}
+	}
+	if english.Contains("technology") {
+		t.Error("Expected technology not to be a stopword")
+	}
+
+	none, err := Builtin(None)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if none.Size() != 0 {
+		t.Errorf("Expected no stopwords, got %d", none.Size())
+	}
+
+	if _, err := Builtin("klingon"); err == nil {
+		t.Error("Expected error for unknown list, got nil")
+	}
+}
+
+func TestSet_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stopwords.txt")
+	if err := os.WriteFile(path, []byte("# site boilerplate\nEngadget\n\n  subscribe \n"), 0644); err != nil {
+		t.Fatalf("Failed to write stopwords file: %v", err)
+	}
+
+	set := New("the")
+	if err := set.Load(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := []string{"engadget", "subscribe", "the"}; !reflect.DeepEqual(set.Words(), want) {
+		t.Errorf("Expected %v, got %v", want, set.Words())
+	}
+
+	if err := set.Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
+
+func TestSet_Nil(t *testing.T) {
+	var set *Set
+	if set.Contains("the") || set.Size() != 0 || set.Words() != nil {
+		t.Error("Expected a nil Set to be empty")
+	}
+}
+
+func TestForLanguage(t *testing.T) {
+	for code, word := range map[string]string{"en": "the", "de": "und", "es": "porque", "fr": "être", "it": "della", "nl": "het", "pt": "não"} {
+		list, err := Builtin(ForLanguage(code))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", code, err)
+		}
+		if !list.Contains(word) {
+			t.Errorf("Expected %q to be a stopword for %s", word, code)
+		}
+	}
+	if got := ForLanguage("ru"); got != None {
+		t.Errorf("Expected no list for ru, got %q", got)
+	}
+}
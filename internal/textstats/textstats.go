@@ -0,0 +1,315 @@
+package textstats
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Text holds the counts readability is computed from. They cover every word of a
+// text, not only those counted, and add up across texts.
+type Text struct {
+	Sentences int `json:"sentences"`
+	Words     int `json:"words"`
+	Letters   int `json:"letters"`
+	Syllables int `json:"syllables"`
+}
+
+// add adds other's counts n times
+func (t *Text) add(other Text, n int) {
+	t.Sentences += n * other.Sentences
+	t.Words += n * other.Words
+	t.Letters += n * other.Letters
+	t.Syllables += n * other.Syllables
+}
+
+// AverageWordLength returns the mean letters per word, or 0 without words
+func (t Text) AverageWordLength() float64 {
+	return ratio(t.Letters, t.Words)
+}
+
+// AverageSentenceLength returns the mean words per sentence, or 0 without sentences
+func (t Text) AverageSentenceLength() float64 {
+	return ratio(t.Words, t.Sentences)
+}
+
+// FleschKincaidGrade returns the US school grade the text reads at, or 0 without
+// words. Syllables are estimated with English spelling rules, so the grade is only
+// meaningful for English.
+func (t Text) FleschKincaidGrade() float64 {
+	if t.Words == 0 || t.Sentences == 0 {
+		return 0
+	}
+	return 0.39*t.AverageSentenceLength() + 11.8*ratio(t.Syllables, t.Words) - 15.59
+}
+
+// Measure counts the sentences, words, letters and syllables of text. A word is a
+// run of letters, which may contain apostrophes, and a sentence ends at each run
+// of '.', '!' or '?' after a word, or at the end of the text.
+func Measure(text string) Text {
+	var t Text
+	var word strings.Builder
+	inSentence := false
+
+	endWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := strings.Trim(word.String(), "'’")
+		word.Reset()
+		if w == "" {
+			return
+		}
+		t.Words++
+		t.Syllables += Syllables(w)
+		for _, r := range w {
+			if unicode.IsLetter(r) {
+				t.Letters++
+			}
+		}
+		inSentence = true
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			word.WriteRune(unicode.ToLower(r))
+		case (r == '\'' || r == '’') && word.Len() > 0:
+			word.WriteRune(r)
+		default:
+			endWord()
+			if (r == '.' || r == '!' || r == '?') && inSentence {
+				t.Sentences++
+				inSentence = false
+			}
+		}
+	}
+	endWord()
+	if inSentence {
+		t.Sentences++
+	}
+	return t
+}
+
+// Syllables estimates the syllables in a lowercase English word as its groups of
+// vowels, not counting a silent final 'e', and at least one
+func Syllables(word string) int {
+	n := 0
+	vowel := false
+	for _, r := range word {
+		isVowel := strings.ContainsRune("aeiouyàáâäèéêëìíîïòóôöùúûü", r)
+		if isVowel && !vowel {
+			n++
+		}
+		vowel = isVowel
+	}
+	if n > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		n--
+	}
+	return max(n, 1)
+}
+
+// Article holds the statistics of one article
+type Article struct {
+	Text
+
+	// Vocabulary of every token in the article, whether counted or not
+	Tokens int `json:"tokens"`
+	Types  int `json:"types"` // Distinct tokens
+	Hapax  int `json:"hapax"` // Tokens occurring once
+
+	// Frequencies of each token, added to the corpus vocabulary
+	Frequencies map[string]int `json:"-"`
+}
+
+// NewArticle returns the statistics of an article's text and the frequency of each
+// token in it, as the tokenizer splits and lowercases them
+func NewArticle(text string, frequencies map[string]int) Article {
+	a := Article{Text: Measure(text), Frequencies: frequencies}
+	a.Tokens, a.Types, a.Hapax = vocabulary(frequencies)
+	return a
+}
+
+// TypeTokenRatio returns the distinct tokens per token, or 0 without tokens
+func (a Article) TypeTokenRatio() float64 {
+	return ratio(a.Types, a.Tokens)
+}
+
+// vocabulary returns the tokens, types and hapax legomena of token frequencies
+func vocabulary(frequencies map[string]int) (tokens, types, hapax int) {
+	for _, count := range frequencies {
+		if count <= 0 {
+			continue
+		}
+		tokens += count
+		types++
+		if count == 1 {
+			hapax++
+		}
+	}
+	return tokens, types, hapax
+}
+
+// Totals accumulates the statistics of many articles, and can be merged with the
+// totals of other shards
+type Totals struct {
+	Articles int  `json:"articles"`
+	Text     Text `json:"text"`
+
+	// Frequencies of each token across the articles, for the corpus vocabulary
+	Frequencies map[string]int `json:"frequencies,omitempty"`
+
+	// Sums of the per-article measures, for their means
+	TypeTokenRatio        float64 `json:"type_token_ratio"`
+	Hapax                 int     `json:"hapax"`
+	AverageWordLength     float64 `json:"average_word_length"`
+	AverageSentenceLength float64 `json:"average_sentence_length"`
+	FleschKincaidGrade    float64 `json:"flesch_kincaid_grade"`
+}
+
+// Add adds an article's statistics, or removes them when sign is -1
+func (t *Totals) Add(a Article, sign int) {
+	t.Articles += sign
+	t.Text.add(a.Text, sign)
+	t.addFrequencies(a.Frequencies, sign)
+	s := float64(sign)
+	t.TypeTokenRatio += s * a.TypeTokenRatio()
+	t.Hapax += sign * a.Hapax
+	t.AverageWordLength += s * a.AverageWordLength()
+	t.AverageSentenceLength += s * a.AverageSentenceLength()
+	t.FleschKincaidGrade += s * a.FleschKincaidGrade()
+}
+
+// Merge adds other's totals
+func (t *Totals) Merge(other *Totals) {
+	t.Articles += other.Articles
+	t.Text.add(other.Text, 1)
+	t.addFrequencies(other.Frequencies, 1)
+	t.TypeTokenRatio += other.TypeTokenRatio
+	t.Hapax += other.Hapax
+	t.AverageWordLength += other.AverageWordLength
+	t.AverageSentenceLength += other.AverageSentenceLength
+	t.FleschKincaidGrade += other.FleschKincaidGrade
+}
+
+// addFrequencies adds token frequencies n times, dropping tokens that fall to zero
+func (t *Totals) addFrequencies(frequencies map[string]int, n int) {
+	if len(frequencies) > 0 && t.Frequencies == nil {
+		t.Frequencies = make(map[string]int, len(frequencies))
+	}
+	for token, count := range frequencies {
+		if t.Frequencies[token] += n * count; t.Frequencies[token] <= 0 {
+			delete(t.Frequencies, token)
+		}
+	}
+}
+
+// Statistics reports the vocabulary and readability of a corpus, and the mean of
+// each measure over its articles
+type Statistics struct {
+	Corpus       Corpus `json:"corpus"`
+	ArticleMeans Means  `json:"article_means"`
+}
+
+// Corpus holds the statistics of all articles taken as one text
+type Corpus struct {
+	Tokens                int      `json:"tokens"`
+	Types                 int      `json:"types"`
+	TypeTokenRatio        float64  `json:"type_token_ratio"`
+	HapaxLegomena         int      `json:"hapax_legomena"`
+	Sentences             int      `json:"sentences"`
+	Words                 int      `json:"words"`
+	AverageWordLength     float64  `json:"average_word_length"`
+	AverageSentenceLength float64  `json:"average_sentence_length"`
+	FleschKincaidGrade    float64  `json:"flesch_kincaid_grade"`
+	Zipf                  *ZipfFit `json:"zipf,omitempty"`
+}
+
+// Means holds the mean of each per-article measure
+type Means struct {
+	Articles              int     `json:"articles"`
+	TypeTokenRatio        float64 `json:"type_token_ratio"`
+	HapaxLegomena         float64 `json:"hapax_legomena"`
+	AverageWordLength     float64 `json:"average_word_length"`
+	AverageSentenceLength float64 `json:"average_sentence_length"`
+	FleschKincaidGrade    float64 `json:"flesch_kincaid_grade"`
+}
+
+// Summarize returns the statistics of the articles added
+func (t *Totals) Summarize() *Statistics {
+	s := &Statistics{
+		Corpus: Corpus{
+			Sentences:             t.Text.Sentences,
+			Words:                 t.Text.Words,
+			AverageWordLength:     t.Text.AverageWordLength(),
+			AverageSentenceLength: t.Text.AverageSentenceLength(),
+			FleschKincaidGrade:    t.Text.FleschKincaidGrade(),
+			Zipf:                  FitZipf(t.Frequencies),
+		},
+		ArticleMeans: Means{Articles: t.Articles},
+	}
+	s.Corpus.Tokens, s.Corpus.Types, s.Corpus.HapaxLegomena = vocabulary(t.Frequencies)
+	s.Corpus.TypeTokenRatio = ratio(s.Corpus.Types, s.Corpus.Tokens)
+
+	if t.Articles > 0 {
+		n := float64(t.Articles)
+		s.ArticleMeans.TypeTokenRatio = t.TypeTokenRatio / n
+		s.ArticleMeans.HapaxLegomena = float64(t.Hapax) / n
+		s.ArticleMeans.AverageWordLength = t.AverageWordLength / n
+		s.ArticleMeans.AverageSentenceLength = t.AverageSentenceLength / n
+		s.ArticleMeans.FleschKincaidGrade = t.FleschKincaidGrade / n
+	}
+	return s
+}
+
+// ZipfFit is the least-squares line through log frequency against log rank. Text
+// following Zipf's law has a slope near -1, and the closer R² is to 1 the better
+// the line fits.
+type ZipfFit struct {
+	Slope    float64 `json:"slope"`
+	RSquared float64 `json:"r_squared"`
+	Ranks    int     `json:"ranks"` // Distinct words fitted
+}
+
+// FitZipf fits Zipf's law to word counts, or returns nil for fewer than two words
+func FitZipf(wordCounts map[string]int) *ZipfFit {
+	counts := make([]int, 0, len(wordCounts))
+	for _, count := range wordCounts {
+		if count > 0 {
+			counts = append(counts, count)
+		}
+	}
+	if len(counts) < 2 {
+		return nil
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+
+	n := float64(len(counts))
+	var sumX, sumY, sumXX, sumXY, sumYY float64
+	for i, count := range counts {
+		x, y := math.Log(float64(i+1)), math.Log(float64(count))
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+		sumYY += y * y
+	}
+	sxx := sumXX - sumX*sumX/n
+	sxy := sumXY - sumX*sumY/n
+	syy := sumYY - sumY*sumY/n
+
+	fit := &ZipfFit{Slope: sxy / sxx, RSquared: 1, Ranks: len(counts)}
+	if syy > 0 {
+		fit.RSquared = sxy * sxy / (sxx * syy)
+	}
+	return fit
+}
+
+// ratio returns a/b, or 0 when b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
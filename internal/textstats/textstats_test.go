@@ -0,0 +1,96 @@
+package textstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	text := Measure("The cat sat. It didn't like the rain!  Then it slept")
+	want := Text{Sentences: 3, Words: 11, Letters: 38, Syllables: 11}
+	if text != want {
+		t.Errorf("Expected %+v, got %+v", want, text)
+	}
+	if got := text.AverageSentenceLength(); math.Abs(got-11.0/3) > 1e-9 {
+		t.Errorf("Expected %.3f words per sentence, got %.3f", 11.0/3, got)
+	}
+
+	if empty := Measure("... !?"); empty != (Text{}) || empty.FleschKincaidGrade() != 0 {
+		t.Errorf("Expected nothing counted in punctuation, got %+v", empty)
+	}
+}
+
+func TestSyllables(t *testing.T) {
+	for word, want := range map[string]int{
+		"cat":        1,
+		"rain":       1,
+		"make":       1,
+		"table":      2,
+		"technology": 4,
+		"rhythm":     1,
+		"privacy":    3,
+	} {
+		if got := Syllables(word); got != want {
+			t.Errorf("Syllables(%q): expected %d, got %d", word, want, got)
+		}
+	}
+}
+
+func TestFleschKincaidGrade(t *testing.T) {
+	text := Text{Sentences: 2, Words: 20, Syllables: 30}
+	want := 0.39*10 + 11.8*1.5 - 15.59
+	if got := text.FleschKincaidGrade(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected grade %.2f, got %.2f", want, got)
+	}
+}
+
+func TestFitZipf(t *testing.T) {
+	// Counts exactly proportional to 1/rank fit a slope of -1
+	counts := map[string]int{"a": 120, "b": 60, "c": 40, "d": 30, "e": 24}
+	fit := FitZipf(counts)
+	if fit == nil || math.Abs(fit.Slope+1) > 1e-9 || math.Abs(fit.RSquared-1) > 1e-9 || fit.Ranks != 5 {
+		t.Errorf("Expected slope -1 with R² 1 over 5 ranks, got %+v", fit)
+	}
+
+	if fit := FitZipf(map[string]int{"a": 3}); fit != nil {
+		t.Errorf("Expected no fit for one word, got %+v", fit)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	first := NewArticle("Robots build robots. Robots fix laptops.", map[string]int{"robots": 3, "build": 1, "fix": 1, "laptops": 1})
+	second := NewArticle("Laptops are light.", map[string]int{"laptops": 1, "are": 1, "light": 1})
+	if first.Tokens != 6 || first.Types != 4 || first.Hapax != 3 || math.Abs(first.TypeTokenRatio()-4.0/6) > 1e-9 {
+		t.Errorf("Expected 6 tokens, 4 types and 3 hapax, got %+v", first)
+	}
+
+	var totals Totals
+	totals.Add(first, 1)
+	totals.Add(second, 1)
+	stats := totals.Summarize()
+
+	if stats.Corpus.Tokens != 9 || stats.Corpus.Types != 6 || stats.Corpus.HapaxLegomena != 4 {
+		t.Errorf("Expected 9 tokens, 6 types and 4 hapax, got %+v", stats.Corpus)
+	}
+	if stats.Corpus.Sentences != 3 || stats.Corpus.Words != 9 {
+		t.Errorf("Expected 3 sentences of 9 words, got %d and %d", stats.Corpus.Sentences, stats.Corpus.Words)
+	}
+	if stats.ArticleMeans.Articles != 2 || math.Abs(stats.ArticleMeans.TypeTokenRatio-(4.0/6+1)/2) > 1e-9 {
+		t.Errorf("Expected a mean type/token ratio of %.3f over 2 articles, got %+v", (4.0/6+1)/2, stats.ArticleMeans)
+	}
+	if stats.Corpus.Zipf == nil || stats.Corpus.Zipf.Ranks != 6 {
+		t.Errorf("Expected a Zipf fit over 6 ranks, got %+v", stats.Corpus.Zipf)
+	}
+
+	// Removing an article and merging it back gives the same totals
+	var other Totals
+	other.Add(second, 1)
+	totals.Add(second, -1)
+	if _, ok := totals.Frequencies["light"]; ok {
+		t.Error("Expected tokens of a removed article to leave the frequencies")
+	}
+	totals.Merge(&other)
+	if again := totals.Summarize(); again.Corpus.Tokens != 9 || *again.Corpus.Zipf != *stats.Corpus.Zipf || again.ArticleMeans != stats.ArticleMeans {
+		t.Errorf("Expected merged totals to match, got %+v", again)
+	}
+}
@@ -0,0 +1,339 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// flushThreshold is how many ended spans are buffered before a batch is written
+const flushThreshold = 512
+
+// statusError marks a failed span, as in OTLP
+const statusError = 2
+
+// spanKindInternal marks spans for in-process work, as in OTLP
+const spanKindInternal = 1
+
+// Attribute is a key/value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Float64 returns a floating-point attribute
+func Float64(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer records spans and exports them as OTLP/JSON, one export request per
+// line, so a file can be loaded into a trace viewer without a collector.
+// A nil Tracer records nothing.
+type Tracer struct {
+	service string
+
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closer  io.Closer
+	pending []spanJSON
+	err     error // First write error
+}
+
+// NewTracer creates a Tracer that writes spans to w under the given service name
+func NewTracer(w io.Writer, service string) *Tracer {
+	return &Tracer{service: service, w: bufio.NewWriter(w)}
+}
+
+// Create creates a Tracer that writes spans to a new file at path
+func Create(path, service string) (*Tracer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace file: %w", err)
+	}
+	t := NewTracer(file, service)
+	t.closer = file
+	return t, nil
+}
+
+// Start begins a span named name. It is a child of the span in ctx, if any,
+// and otherwise the root of a new trace. The returned context carries the span.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, start: time.Now(), attrs: attrs}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Start begins a child of the span in ctx. Without a span in ctx tracing is
+// off for this work, and the returned span is nil and records nothing.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, attrs...)
+}
+
+// Close writes any buffered spans and closes the file opened by Create
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.flushLocked()
+	if err := t.w.Flush(); err != nil && t.err == nil {
+		t.err = fmt.Errorf("writing traces: %w", err)
+	}
+	if t.closer != nil {
+		if err := t.closer.Close(); err != nil && t.err == nil {
+			t.err = fmt.Errorf("closing trace file: %w", err)
+		}
+	}
+	return t.err
+}
+
+// export buffers an ended span, writing a batch once enough have accumulated
+func (t *Tracer) export(span spanJSON) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, span)
+	if len(t.pending) >= flushThreshold {
+		t.flushLocked()
+	}
+}
+
+// flushLocked writes the pending spans as one export request line
+func (t *Tracer) flushLocked() {
+	if len(t.pending) == 0 || t.err != nil {
+		t.pending = t.pending[:0]
+		return
+	}
+
+	request := exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{toKeyValue(String("service.name", t.service))}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: t.service},
+			Spans: t.pending,
+		}},
+	}}}
+
+	line, err := json.Marshal(request)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = t.w.Write(line)
+	}
+	if err != nil {
+		t.err = fmt.Errorf("writing traces: %w", err)
+	}
+	t.pending = nil
+}
+
+// Span is one timed operation within a trace. A nil Span records nothing, so
+// callers need not check whether tracing is enabled.
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	mu      sync.Mutex
+	attrs   []Attribute
+	status  int
+	message string
+	ended   bool
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// RecordError marks the span as failed with err's message; a nil err is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.message = statusError, err.Error()
+}
+
+// End finishes the span and hands it to the tracer. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := s.toJSON(time.Now())
+	s.mu.Unlock()
+
+	s.tracer.export(data)
+}
+
+// TraceID returns the span's trace ID in hex, or "" for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// toJSON converts the span to its OTLP/JSON form
+func (s *Span) toJSON(end time.Time) spanJSON {
+	span := spanJSON{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            status{Code: s.status, Message: s.message},
+	}
+	if s.parentID != ([8]byte{}) {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for _, attr := range s.attrs {
+		span.Attributes = append(span.Attributes, toKeyValue(attr))
+	}
+	return span
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying span; a nil span leaves ctx unchanged
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// OTLP/JSON encoding of an ExportTraceServiceRequest
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanJSON `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanJSON struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+// anyValue holds exactly one of its fields; 64-bit integers are strings in OTLP/JSON
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// toKeyValue converts an attribute to its OTLP/JSON form
+func toKeyValue(attr Attribute) keyValue {
+	kv := keyValue{Key: attr.Key}
+	switch v := attr.Value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case bool:
+		kv.Value.BoolValue = &v
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// decodeSpans parses every export request line written by a tracer
+func decodeSpans(t *testing.T, data string) []spanJSON {
+	t.Helper()
+
+	var spans []spanJSON
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var request exportRequest
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			t.Fatalf("Expected valid JSON lines, got %v: %s", err, line)
+		}
+		for _, rs := range request.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func TestTracer_ParentAndChild(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf, "test")
+
+	ctx, root := tracer.Start(context.Background(), "url", String("url.full", "https://example.com/a"))
+	_, child := Start(ctx, "http.attempt", Int("http.attempt", 1))
+	child.SetAttributes(Int("http.status_code", 503))
+	child.RecordError(errors.New("HTTP 503"))
+	child.End()
+	root.End()
+	root.End() // Ending twice must not export twice
+
+	if err := tracer.Close(); err != nil {
+		t.Fatalf("Expected no error closing, got %v", err)
+	}
+
+	spans := decodeSpans(t, buf.String())
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	gotChild, gotRoot := spans[0], spans[1]
+	if gotRoot.Name != "url" || gotChild.Name != "http.attempt" {
+		t.Errorf("Expected spans in end order, got %q then %q", gotChild.Name, gotRoot.Name)
+	}
+	if gotChild.TraceID != gotRoot.TraceID || len(gotRoot.TraceID) != 32 {
+		t.Errorf("Expected child to share root trace ID, got %q and %q", gotChild.TraceID, gotRoot.TraceID)
+	}
+	if gotChild.ParentSpanID != gotRoot.SpanID {
+		t.Errorf("Expected child parent %q, got %q", gotRoot.SpanID, gotChild.ParentSpanID)
+	}
+	if gotRoot.ParentSpanID != "" {
+		t.Errorf("Expected root to have no parent, got %q", gotRoot.ParentSpanID)
+	}
+	if gotChild.Status.Code != statusError || gotChild.Status.Message != "HTTP 503" {
+		t.Errorf("Expected error status, got %+v", gotChild.Status)
+	}
+	if len(gotChild.Attributes) != 2 || *gotChild.Attributes[1].Value.IntValue != "503" {
+		t.Errorf("Expected integer attributes encoded as strings, got %+v", gotChild.Attributes)
+	}
+}
+
+func TestStart_WithoutParentIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "parse")
+	if span != nil {
+		t.Error("Expected nil span without a parent in context")
+	}
+	if SpanFromContext(ctx) != nil {
+		t.Error("Expected context without a span")
+	}
+
+	// A nil span must be safe to use
+	span.SetAttributes(Bool("ok", true))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	var tracer *Tracer
+	if _, span := tracer.Start(context.Background(), "url"); span != nil {
+		t.Error("Expected nil tracer to return nil span")
+	}
+	if err := tracer.Close(); err != nil {
+		t.Errorf("Expected nil tracer to close cleanly, got %v", err)
+	}
+}
+
+func TestTracer_BatchesLines(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf, "test")
+
+	for i := 0; i < flushThreshold+1; i++ {
+		_, span := tracer.Start(context.Background(), "url")
+		span.End()
+	}
+	tracer.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 export lines, got %d", len(lines))
+	}
+	if spans := decodeSpans(t, buf.String()); len(spans) != flushThreshold+1 {
+		t.Errorf("Expected %d spans, got %d", flushThreshold+1, len(spans))
+	}
+}
@@ -0,0 +1,138 @@
+package urlnorm
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultStripParams lists the tracking parameters removed by default; a
+// trailing * matches any parameter with that prefix
+var DefaultStripParams = []string{"utm_*", "guccounter"}
+
+// defaultPorts maps schemes to the port implied when none is given
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalizer rewrites URLs into a canonical form so variants of the same page compare equal
+type Normalizer struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// New creates a Normalizer that strips the given query parameters. Names are
+// matched case-insensitively; a trailing * matches by prefix.
+func New(stripParams []string) *Normalizer {
+	n := &Normalizer{exact: make(map[string]bool)}
+	for _, param := range stripParams {
+		param = strings.ToLower(strings.TrimSpace(param))
+		switch {
+		case param == "":
+		case strings.HasSuffix(param, "*"):
+			n.prefixes = append(n.prefixes, strings.TrimSuffix(param, "*"))
+		default:
+			n.exact[param] = true
+		}
+	}
+	return n
+}
+
+// Normalize returns the canonical form of raw: lowercase scheme and host, no
+// default port, no fragment, no trailing slash, stripped parameters removed and
+// the remaining query parameters sorted by name
+func (n *Normalizer) Normalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("parsing URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("URL %q is not absolute", raw)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" && port == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+		if u.Path == "" {
+			u.Path, u.RawPath = "/", ""
+		}
+	}
+
+	query := u.Query()
+	for name := range query {
+		if n.strip(name) {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode() // Sorted by name
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// strip reports whether a query parameter should be removed
+func (n *Normalizer) strip(name string) bool {
+	name = strings.ToLower(name)
+	if n.exact[name] {
+		return true
+	}
+	for _, prefix := range n.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the deduplication key of a normalized URL, which ignores the
+// scheme so http and https variants of a page count as one
+func Key(normalized string) string {
+	if i := strings.Index(normalized, "://"); i >= 0 {
+		return normalized[i+1:]
+	}
+	return normalized
+}
+
+// Set records the keys seen so far; it is safe for concurrent use
+type Set struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewSet creates an empty Set
+func NewSet() *Set {
+	return &Set{seen: make(map[string]struct{})}
+}
+
+// Add records key and reports whether it was new
+func (s *Set) Add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = struct{}{}
+	return true
+}
+
+// Len returns the number of distinct keys seen
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
@@ -0,0 +1,95 @@
+package urlnorm
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	n := New(DefaultStripParams)
+
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"https://www.engadget.com/article/", "https://www.engadget.com/article"},
+		{"HTTPS://WWW.Engadget.COM/Article", "https://www.engadget.com/Article"},
+		{"https://www.engadget.com:443/a", "https://www.engadget.com/a"},
+		{"http://www.engadget.com:80/a", "http://www.engadget.com/a"},
+		{"http://www.engadget.com:8080/a", "http://www.engadget.com:8080/a"},
+		{"https://www.engadget.com/a#comments", "https://www.engadget.com/a"},
+		{"https://www.engadget.com/a?utm_source=x&utm_medium=y", "https://www.engadget.com/a"},
+		{"https://www.engadget.com/a?guccounter=1&page=2", "https://www.engadget.com/a?page=2"},
+		{"https://www.engadget.com/a?b=2&a=1", "https://www.engadget.com/a?a=1&b=2"},
+		{"https://www.engadget.com", "https://www.engadget.com/"},
+		{"https://www.engadget.com/?", "https://www.engadget.com/"},
+	}
+
+	for _, tt := range tests {
+		got, err := n.Normalize(tt.raw)
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Normalize(%q): expected %q, got %q", tt.raw, tt.expected, got)
+		}
+	}
+}
+
+func TestNormalizer_CustomParams(t *testing.T) {
+	n := New([]string{"ref", "fb*"})
+
+	got, err := n.Normalize("https://example.com/a?ref=home&fbclid=1&utm_source=x")
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if expected := "https://example.com/a?utm_source=x"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestNormalizer_RejectsRelative(t *testing.T) {
+	n := New(nil)
+	for _, raw := range []string{"/article", "not a url", "http://[::1"} {
+		if _, err := n.Normalize(raw); err == nil {
+			t.Errorf("Expected error for %q", raw)
+		}
+	}
+}
+
+func TestKey_IgnoresScheme(t *testing.T) {
+	if Key("http://example.com/a") != Key("https://example.com/a") {
+		t.Error("Expected http and https variants to share a key")
+	}
+	if Key("https://example.com/a") == Key("https://example.com/b") {
+		t.Error("Expected different pages to have different keys")
+	}
+}
+
+func TestSet_Add(t *testing.T) {
+	set := NewSet()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if set.Add("//example.com/a") {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if added != 1 {
+		t.Errorf("Expected exactly one Add to succeed, got %d", added)
+	}
+	if set.Len() != 1 {
+		t.Errorf("Expected 1 key, got %d", set.Len())
+	}
+}
@@ -0,0 +1,213 @@
+package wordbank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// A compiled wordbank holds a sorted lexicon laid out to be memory-mapped and
+// searched in place, so loading it takes no time however many words it has.
+//
+// It starts with compiledMagic, a uint32 version, uint32 flags and the SHA-256
+// of the wordbank it was compiled from, followed by the sections, each a uint64
+// length and its bytes. Integers are little-endian.
+var compiledMagic = []byte("EAWB")
+
+const (
+	compiledVersion = 2 // Version 1 also stored weights
+
+	compiledPhrases = 1 << 0 // Flag: some term or alias has several words
+
+	compiledHeaderSize = 4 + 4 + 4 + 32
+)
+
+// Sections of a compiled wordbank, in order
+const (
+	sectionTermData = iota
+	sectionTermOffsets
+	sectionCategoryOf
+	sectionCategoryData
+	sectionCategoryOffsets
+	sectionAliasData
+	sectionAliasOffsets
+	sectionAliasTerms
+	sectionCount
+)
+
+// WriteCompiled writes the wordbank in the compiled format, which New reads
+// without parsing or filtering it again. Its SHA-256 stays that of the wordbank
+// as first read, so manifests identify the same words either way.
+func (wb *WordBank) WriteCompiled(w io.Writer) error {
+	if wb.all {
+		return fmt.Errorf("compiling word bank: no word list to compile")
+	}
+	lex, ok := wb.lexicon.(*sortedLexicon)
+	if !ok {
+		var err error
+		if lex, err = toSorted(wb.lexicon); err != nil {
+			return fmt.Errorf("compiling word bank: %w", err)
+		}
+	}
+
+	digest, err := hex.DecodeString(wb.sha256)
+	if err != nil || len(digest) != 32 {
+		digest = make([]byte, 32)
+	}
+
+	categories, err := packStrings(lex.categories)
+	if err != nil {
+		return fmt.Errorf("compiling word bank: %w", err)
+	}
+
+	var flags uint32
+	if lex.phrases {
+		flags |= compiledPhrases
+	}
+
+	out := bufio.NewWriter(w)
+	header := make([]byte, 0, compiledHeaderSize)
+	header = append(header, compiledMagic...)
+	header = binary.LittleEndian.AppendUint32(header, compiledVersion)
+	header = binary.LittleEndian.AppendUint32(header, flags)
+	header = append(header, digest...)
+	out.Write(header)
+
+	sections := [sectionCount][]byte{
+		sectionTermData:        lex.terms.data,
+		sectionTermOffsets:     lex.terms.offsets,
+		sectionCategoryOf:      lex.categoryOf,
+		sectionCategoryData:    categories.data,
+		sectionCategoryOffsets: categories.offsets,
+		sectionAliasData:       lex.aliases.data,
+		sectionAliasOffsets:    lex.aliases.offsets,
+		sectionAliasTerms:      lex.aliasTerms,
+	}
+	for _, section := range sections {
+		out.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(section))))
+		out.Write(section)
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("writing compiled word bank: %w", err)
+	}
+	return nil
+}
+
+// toSorted converts a lexicon to a sorted one
+func toSorted(lex lexicon) (*sortedLexicon, error) {
+	if m, ok := lex.(*mapLexicon); ok {
+		return newSortedLexicon(m.words, m.aliases)
+	}
+	return nil, fmt.Errorf("unsupported lexicon %T", lex)
+}
+
+// isCompiled reports whether data starts like a compiled wordbank
+func isCompiled(data []byte) bool {
+	return bytes.HasPrefix(data, compiledMagic)
+}
+
+// isCompiledFile reports whether the file at path is a compiled wordbank
+func isCompiledFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("opening word bank file: %w", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(compiledMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("reading word bank file: %w", err)
+	}
+	return isCompiled(magic), nil
+}
+
+// openCompiled memory-maps the compiled wordbank at path where the platform
+// supports it, returning its lexicon and the SHA-256 it was compiled from
+func openCompiled(path string) (*sortedLexicon, string, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	lex, digest, err := parseCompiled(data)
+	if err != nil {
+		unmap()
+		return nil, "", err
+	}
+	lex.unmap = unmap
+	return lex, digest, nil
+}
+
+// parseCompiled reads a compiled wordbank in place; the lexicon refers to data
+// rather than copying it
+func parseCompiled(data []byte) (*sortedLexicon, string, error) {
+	if len(data) < compiledHeaderSize || !isCompiled(data) {
+		return nil, "", fmt.Errorf("reading compiled word bank: not a compiled word bank")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != compiledVersion {
+		return nil, "", fmt.Errorf("reading compiled word bank: unsupported version %d (expected %d)", version, compiledVersion)
+	}
+	flags := binary.LittleEndian.Uint32(data[8:])
+	digest := hex.EncodeToString(data[12:compiledHeaderSize])
+
+	var sections [sectionCount][]byte
+	rest := data[compiledHeaderSize:]
+	for i := range sections {
+		if len(rest) < 8 {
+			return nil, "", fmt.Errorf("reading compiled word bank: truncated")
+		}
+		size := binary.LittleEndian.Uint64(rest)
+		rest = rest[8:]
+		if size > uint64(len(rest)) {
+			return nil, "", fmt.Errorf("reading compiled word bank: truncated")
+		}
+		sections[i], rest = rest[:size:size], rest[size:]
+	}
+
+	lex := &sortedLexicon{
+		terms:      sortedStrings{data: sections[sectionTermData], offsets: sections[sectionTermOffsets]},
+		categoryOf: sections[sectionCategoryOf],
+		aliases:    sortedStrings{data: sections[sectionAliasData], offsets: sections[sectionAliasOffsets]},
+		aliasTerms: sections[sectionAliasTerms],
+		phrases:    flags&compiledPhrases != 0,
+	}
+	categories := sortedStrings{data: sections[sectionCategoryData], offsets: sections[sectionCategoryOffsets]}
+	if err := lex.check(categories); err != nil {
+		return nil, "", fmt.Errorf("reading compiled word bank: %w", err)
+	}
+	for i := 0; i < categories.len(); i++ {
+		lex.categories = append(lex.categories, string(categories.at(i)))
+	}
+	return lex, digest, nil
+}
+
+// check verifies that the sections of a compiled wordbank fit together. It only
+// looks at their sizes, so opening a large wordbank does not read all of it.
+func (l *sortedLexicon) check(categories sortedStrings) error {
+	for _, s := range []sortedStrings{l.terms, l.aliases, categories} {
+		if err := s.checkSize(); err != nil {
+			return err
+		}
+	}
+
+	n := l.terms.len()
+	if len(l.categoryOf) != 0 && len(l.categoryOf) != 2*n {
+		return fmt.Errorf("categories for %d of %d words", len(l.categoryOf)/2, n)
+	}
+	if categories.len() == 0 {
+		return fmt.Errorf("missing categories")
+	}
+	if len(l.aliasTerms) != 4*l.aliases.len() {
+		return fmt.Errorf("words for %d of %d aliases", len(l.aliasTerms)/4, l.aliases.len())
+	}
+	return nil
+}
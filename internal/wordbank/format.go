@@ -0,0 +1,244 @@
+package wordbank
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Wordbank formats
+const (
+	FormatAuto = "auto" // Detect from the extension, then the content
+	FormatText = "text" // One word per line, or "word = alias, alias"
+	FormatCSV  = "csv"  // Word, weight, category and aliases columns separated by commas
+	FormatTSV  = "tsv"  // Word, weight, category and aliases columns separated by tabs
+	FormatJSON = "json" // Array of words or {"word", "weight", "category", "aliases"} objects
+
+	// FormatCompiled is the output of WriteCompiled, recognized by its content
+	FormatCompiled = "compiled"
+)
+
+// aliasSeparator separates the aliases in a CSV or TSV column
+const aliasSeparator = "|"
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// sniffBytes is how much content is examined to detect a format
+const sniffBytes = 4096
+
+// ValidateFormat checks a wordbank format name
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatAuto, FormatText, FormatCSV, FormatTSV, FormatJSON, FormatCompiled:
+		return nil
+	}
+	return fmt.Errorf("unknown wordbank format %q (want auto, text, csv, tsv, json or compiled)", format)
+}
+
+// decompress returns the content of reader, gunzipping it if it starts with the gzip magic
+func decompress(reader *bufio.Reader) (*bufio.Reader, error) {
+	magic, _ := reader.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return reader, nil
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing word bank file: %w", err)
+	}
+	return bufio.NewReader(gz), nil
+}
+
+// detectFormat picks a format from the source's extension, ignoring .gz, or
+// failing that from the first line of content
+func detectFormat(source string, content *bufio.Reader) (string, error) {
+	name := source
+	if u, err := url.Parse(source); err == nil && IsRemote(source) {
+		name = u.Path
+	}
+	ext := strings.ToLower(path.Ext(strings.TrimSuffix(strings.ToLower(name), ".gz")))
+	switch ext {
+	case ".txt":
+		return FormatText, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".tsv", ".tab":
+		return FormatTSV, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+
+	head, err := content.Peek(sniffBytes)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", err
+	}
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+	if len(head) > 0 && head[0] == '[' {
+		return FormatJSON, nil
+	}
+
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	switch {
+	case bytes.ContainsRune(line, '\t'):
+		return FormatTSV, nil
+	case bytes.ContainsRune(line, ','):
+		return FormatCSV, nil
+	}
+	return FormatText, nil
+}
+
+// readText reads one word per line. A line "word = alias, alias" also gives the
+// word's aliases.
+func readText(reader io.Reader, add func(word string, entry Entry)) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" {
+			continue
+		}
+		var entry Entry
+		if term, aliases, ok := strings.Cut(word, "="); ok {
+			word, entry.Aliases = strings.TrimSpace(term), splitAliases(aliases, ",")
+		}
+		add(word, entry)
+	}
+	return scanner.Err()
+}
+
+// splitAliases splits a list of aliases, dropping empty ones
+func splitAliases(list, sep string) []string {
+	var aliases []string
+	for _, alias := range strings.Split(list, sep) {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// readDelimited reads rows of word, weight, category and aliases separated by comma,
+// with the aliases separated by aliasSeparator. A first row naming a "word" column
+// is a header, and its names select the columns instead.
+func readDelimited(reader io.Reader, comma rune, add func(word string, entry Entry)) error {
+	records := csv.NewReader(reader)
+	records.Comma = comma
+	records.Comment = '#'
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+	records.TrimLeadingSpace = true
+
+	wordCol, weightCol, categoryCol, aliasesCol := 0, 1, 2, 3
+	for first := true; ; first = false {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if first && isHeader(record) {
+			wordCol, weightCol, categoryCol, aliasesCol = -1, -1, -1, -1
+			for i := range record {
+				switch strings.ToLower(field(record, i)) {
+				case "word":
+					wordCol = i
+				case "weight":
+					weightCol = i
+				case "category":
+					categoryCol = i
+				case "aliases":
+					aliasesCol = i
+				}
+			}
+			continue
+		}
+
+		line, _ := records.FieldPos(0)
+		word := field(record, wordCol)
+		if word == "" {
+			continue
+		}
+		if err := checkWeight(field(record, weightCol)); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		add(word, Entry{
+			Category: field(record, categoryCol),
+			Aliases:  splitAliases(field(record, aliasesCol), aliasSeparator),
+		})
+	}
+}
+
+// isHeader reports whether a first row names its columns
+func isHeader(record []string) bool {
+	for _, name := range record {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), "word") {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns column i of record trimmed, or "" when the row is shorter
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(record[i], "\ufeff"))
+}
+
+// checkWeight checks a weight column, which may be empty. Weights are not used,
+// but one that is not a number usually means the columns are out of line.
+func checkWeight(s string) error {
+	if s == "" {
+		return nil
+	}
+	if weight, err := strconv.ParseFloat(s, 64); err != nil || weight < 0 {
+		return fmt.Errorf("invalid weight %q: must be a non-negative number", s)
+	}
+	return nil
+}
+
+// jsonEntry is a word given as an object in a JSON wordbank
+type jsonEntry struct {
+	Word     string   `json:"word"`
+	Weight   *float64 `json:"weight"` // Checked but not used
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases"`
+}
+
+// readJSON reads an array whose elements are words or jsonEntry objects
+func readJSON(reader io.Reader, add func(word string, entry Entry)) error {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(reader).Decode(&elements); err != nil {
+		return fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	for i, element := range elements {
+		var word string
+		if err := json.Unmarshal(element, &word); err == nil {
+			add(word, Entry{})
+			continue
+		}
+
+		var entry jsonEntry
+		if err := json.Unmarshal(element, &entry); err != nil {
+			return fmt.Errorf("element %d: want a word or an object with \"word\"", i)
+		}
+		if entry.Weight != nil && *entry.Weight < 0 {
+			return fmt.Errorf("element %d: invalid weight %v: must be non-negative", i, *entry.Weight)
+		}
+		add(entry.Word, Entry{Category: strings.TrimSpace(entry.Category), Aliases: entry.Aliases})
+	}
+	return nil
+}
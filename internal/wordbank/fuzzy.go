@@ -0,0 +1,183 @@
+package wordbank
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/stopwords"
+)
+
+// MinFuzzyLength is the fewest letters a word needs to be matched fuzzily, since
+// short words are a single edit from many others
+const MinFuzzyLength = 5
+
+// fuzzyCacheSize is how many searched words a fuzzy matcher remembers. Beyond it
+// the least recently used are forgotten, so a long run's typos and rare words
+// don't grow the cache without limit.
+const fuzzyCacheSize = 1 << 16
+
+// fuzzyMatcher finds the closest single word in the wordbank by edit distance
+type fuzzyMatcher struct {
+	root      *bkNode
+	distance  int
+	filter    *config.WordFilterConfig
+	stopwords *stopwords.Set
+
+	// Words repeat across articles, so recently seen words are not searched again
+	mu        sync.Mutex
+	cache     map[string]*list.Element // word -> element in recent
+	recent    *list.List               // *fuzzyCacheEntry, most recently used first
+	cacheSize int
+}
+
+// fuzzyCacheEntry is the term found for a word, "" for none
+type fuzzyCacheEntry struct {
+	word, term string
+}
+
+// bkNode is a node of a BK-tree, whose children are keyed by their distance to it
+type bkNode struct {
+	word     string
+	children map[int]*bkNode
+}
+
+// newFuzzyMatcher indexes the single words of a wordbank
+func newFuzzyMatcher(lex lexicon, distance int, filter *config.WordFilterConfig, stop *stopwords.Set) *fuzzyMatcher {
+	if distance > config.MaxFuzzyDistance {
+		distance = config.MaxFuzzyDistance
+	}
+	m := &fuzzyMatcher{
+		distance:  distance,
+		filter:    filter,
+		stopwords: stop,
+		cache:     make(map[string]*list.Element),
+		recent:    list.New(),
+		cacheSize: fuzzyCacheSize,
+	}
+	lex.each(func(word string) {
+		if !strings.Contains(word, " ") {
+			m.insert(word)
+		}
+	})
+	return m
+}
+
+func (m *fuzzyMatcher) insert(word string) {
+	if m.root == nil {
+		m.root = &bkNode{word: word}
+		return
+	}
+	node := m.root
+	for {
+		d := levenshtein(word, node.word)
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{word: word}
+			return
+		}
+		node = child
+	}
+}
+
+// match returns the single closest word within the distance, or "" when there is
+// none, the closest are tied or the word is too short, filtered out or a stopword
+func (m *fuzzyMatcher) match(word string) string {
+	if m == nil || m.root == nil || utf8.RuneCountInString(word) < MinFuzzyLength {
+		return ""
+	}
+	if term, ok := m.cached(word); ok {
+		return term
+	}
+
+	term := ""
+	if m.filter.Pattern.MatchString(word) && !m.stopwords.Contains(word) {
+		term = m.search(word)
+	}
+	m.remember(word, term)
+	return term
+}
+
+// cached returns the term remembered for word, marking it recently used
+func (m *fuzzyMatcher) cached(word string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.cache[word]
+	if !ok {
+		return "", false
+	}
+	m.recent.MoveToFront(elem)
+	return elem.Value.(*fuzzyCacheEntry).term, true
+}
+
+// remember caches the term for word, forgetting the least recently used word
+// once the cache is full
+func (m *fuzzyMatcher) remember(word, term string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.cache[word]; ok {
+		// Another goroutine searched for the same word meanwhile
+		return
+	}
+	m.cache[word] = m.recent.PushFront(&fuzzyCacheEntry{word: word, term: term})
+	if m.recent.Len() > m.cacheSize {
+		oldest := m.recent.Back()
+		m.recent.Remove(oldest)
+		delete(m.cache, oldest.Value.(*fuzzyCacheEntry).word)
+	}
+}
+
+// search walks the BK-tree, only visiting children that the triangle inequality
+// allows to be within the distance
+func (m *fuzzyMatcher) search(word string) string {
+	best, bestDist, tied := "", m.distance+1, false
+	stack := []*bkNode{m.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := levenshtein(word, node.word)
+		switch {
+		case d < bestDist:
+			best, bestDist, tied = node.word, d, false
+		case d == bestDist:
+			tied = true
+		}
+		for childDist, child := range node.children {
+			if childDist >= d-m.distance && childDist <= d+m.distance {
+				stack = append(stack, child)
+			}
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions that turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,123 @@
+package wordbank
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Wordbank backends, which trade load time and memory for lookup speed
+const (
+	BackendMap    = "map"    // Hash map of the words; fastest lookups
+	BackendSorted = "sorted" // Sorted array searched by bisection; a fraction of the memory
+)
+
+// ValidateBackend checks a wordbank backend name
+func ValidateBackend(backend string) error {
+	switch backend {
+	case "", BackendMap, BackendSorted:
+		return nil
+	}
+	return fmt.Errorf("unknown wordbank backend %q (want map or sorted)", backend)
+}
+
+// lexicon stores the words, phrases and aliases of a wordbank
+type lexicon interface {
+	// lookup returns the entry of a lowercase word or phrase, without its aliases
+	lookup(term string) (Entry, bool)
+	// alias returns the term a lowercase alias is credited to
+	alias(variant string) (string, bool)
+	// matchPhrase returns the longest phrase or phrase alias that the lowercase
+	// tokens start with and how many tokens it spans, or 0 when they start with none
+	matchPhrase(tokens []string) (string, int)
+	// hasPhrases reports whether any term or alias has several words
+	hasPhrases() bool
+	// size returns the number of terms, not counting aliases
+	size() int
+	// each calls fn with every term
+	each(fn func(term string))
+	// close releases any memory-mapped file
+	close() error
+}
+
+// aliasesOf maps each alias in words to its term. A variant that is a word in its
+// own right is counted as itself, so it is left out.
+func aliasesOf(words map[string]Entry) map[string]string {
+	aliases := make(map[string]string)
+	for term, entry := range words {
+		for _, alias := range entry.Aliases {
+			if _, ok := words[alias]; !ok {
+				aliases[alias] = term
+			}
+		}
+	}
+	return aliases
+}
+
+// mapLexicon keeps the wordbank in hash maps, with phrases in a trie
+type mapLexicon struct {
+	words   map[string]Entry  // Words and phrases, phrases with their words joined by single spaces
+	aliases map[string]string // Term each alias is credited to
+	phrases *phraseTrie       // Multi-word words and aliases; nil when there are none
+}
+
+// newMapLexicon takes ownership of words, dropping the aliases from its entries
+func newMapLexicon(words map[string]Entry, aliases map[string]string) *mapLexicon {
+	lex := &mapLexicon{words: words, aliases: aliases}
+	for term, entry := range words {
+		if entry.Aliases != nil {
+			entry.Aliases = nil
+			words[term] = entry
+		}
+		lex.addPhrase(term)
+	}
+	for alias := range aliases {
+		lex.addPhrase(alias)
+	}
+	return lex
+}
+
+// addPhrase indexes a word or alias in the phrase trie when it has several words
+func (l *mapLexicon) addPhrase(key string) {
+	if !strings.Contains(key, " ") {
+		return
+	}
+	if l.phrases == nil {
+		l.phrases = &phraseTrie{}
+	}
+	l.phrases.insert(strings.Split(key, " "))
+}
+
+func (l *mapLexicon) lookup(term string) (Entry, bool) {
+	entry, ok := l.words[term]
+	return entry, ok
+}
+
+func (l *mapLexicon) alias(variant string) (string, bool) {
+	term, ok := l.aliases[variant]
+	return term, ok
+}
+
+func (l *mapLexicon) matchPhrase(tokens []string) (string, int) {
+	if l.phrases == nil {
+		return "", 0
+	}
+	return l.phrases.longest(tokens)
+}
+
+func (l *mapLexicon) hasPhrases() bool {
+	return l.phrases != nil
+}
+
+func (l *mapLexicon) size() int {
+	return len(l.words)
+}
+
+func (l *mapLexicon) each(fn func(term string)) {
+	for term := range l.words {
+		fn(term)
+	}
+}
+
+func (l *mapLexicon) close() error {
+	return nil
+}
@@ -0,0 +1,254 @@
+package wordbank
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const backendTestWords = `word,weight,category,aliases
+Technology,2,tech,tech
+science,,,
+Virtual Reality,1.5,tech,vr headset
+ereader,,gadgets,e-reader
+innovation,0,,
+`
+
+// loadBackends loads content with each backend and as a compiled wordbank
+func loadBackends(t *testing.T, content string) map[string]*WordBank {
+	t.Helper()
+	path := writeTestFile(t, "words.csv", []byte(content))
+
+	banks := make(map[string]*WordBank)
+	for _, backend := range []string{BackendMap, BackendSorted} {
+		wb, err := NewWithOptions(context.Background(), path, Options{Backend: backend})
+		if err != nil {
+			t.Fatalf("Loading with backend %s: %v", backend, err)
+		}
+		banks[backend] = wb
+	}
+
+	compiled := filepath.Join(t.TempDir(), "words.wbin")
+	var buf bytes.Buffer
+	if err := banks[BackendMap].WriteCompiled(&buf); err != nil {
+		t.Fatalf("WriteCompiled failed: %v", err)
+	}
+	if err := os.WriteFile(compiled, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write compiled wordbank: %v", err)
+	}
+	wb, err := New(compiled)
+	if err != nil {
+		t.Fatalf("Loading compiled wordbank: %v", err)
+	}
+	t.Cleanup(func() { wb.Close() })
+	banks[FormatCompiled] = wb
+	return banks
+}
+
+// TestBackends tests that every backend, and a compiled wordbank, answer alike
+func TestBackends(t *testing.T) {
+	banks := loadBackends(t, backendTestWords)
+	want := banks[BackendMap]
+
+	for name, wb := range banks {
+		t.Run(name, func(t *testing.T) {
+			if wb.Size() != 5 {
+				t.Errorf("Expected 5 entries, got %d", wb.Size())
+			}
+			if wb.SHA256() != want.SHA256() {
+				t.Errorf("Expected the SHA-256 of the source %s, got %s", want.SHA256(), wb.SHA256())
+			}
+
+			lookups := map[string]Entry{
+				"technology":      {Category: "tech"},
+				"science":         {},
+				"virtual reality": {Category: "tech"},
+				"innovation":      {},
+			}
+			for word, entry := range lookups {
+				got, ok := wb.Lookup(word)
+				if !ok || got.Category != entry.Category || got.Aliases != nil {
+					t.Errorf("Lookup(%q): expected %+v, got %+v/%v", word, entry, got, ok)
+				}
+			}
+			for _, word := range []string{"tech", "virtual", "scienc", "sciences", ""} {
+				if wb.IsValid(word) {
+					t.Errorf("Expected %q to be invalid", word)
+				}
+			}
+			if got := wb.Category("ereader"); got != "gadgets" {
+				t.Errorf("Expected category gadgets, got %q", got)
+			}
+
+			for alias, term := range map[string]string{"tech": "technology", "vr headset": "virtual reality", "e reader": "ereader", "vr": ""} {
+				if got := wb.Resolve(alias); got != term {
+					t.Errorf("Resolve(%q): expected %q, got %q", alias, term, got)
+				}
+			}
+
+			if !wb.Phrases() {
+				t.Error("Expected phrases")
+			}
+			phrases := []struct {
+				tokens []string
+				phrase string
+				n      int
+			}{
+				{[]string{"virtual", "reality", "now"}, "virtual reality", 2},
+				{[]string{"vr", "headset"}, "vr headset", 2},
+				{[]string{"e", "reader"}, "e reader", 2},
+				{[]string{"virtual", "machine"}, "", 0},
+				{[]string{"science"}, "", 0},
+			}
+			for _, tc := range phrases {
+				if phrase, n := wb.MatchPhrase(tc.tokens); phrase != tc.phrase || n != tc.n {
+					t.Errorf("MatchPhrase(%v): expected %q/%d, got %q/%d", tc.tokens, tc.phrase, tc.n, phrase, n)
+				}
+			}
+		})
+	}
+}
+
+func TestBackends_Empty(t *testing.T) {
+	for name, wb := range loadBackends(t, "") {
+		if wb.Size() != 0 || wb.IsValid("science") || wb.Phrases() {
+			t.Errorf("%s: expected an empty wordbank", name)
+		}
+	}
+}
+
+func TestNewWithOptions_InvalidBackend(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("science\n"))
+	if _, err := NewWithOptions(context.Background(), path, Options{Backend: "trie"}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+func TestNew_CorruptCompiled(t *testing.T) {
+	var buf bytes.Buffer
+	wb, err := New(writeTestFile(t, "words.txt", []byte("science\ntechnology\n")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := wb.WriteCompiled(&buf); err != nil {
+		t.Fatalf("WriteCompiled failed: %v", err)
+	}
+	data := buf.Bytes()
+
+	if _, err := New(writeTestFile(t, "truncated.wbin", data[:len(data)-3])); err == nil {
+		t.Error("Expected an error for a truncated compiled wordbank")
+	}
+
+	// Naming the format rejects a file that is not compiled
+	path := writeTestFile(t, "words.txt", []byte("science\n"))
+	if _, err := NewWithOptions(context.Background(), path, Options{Format: FormatCompiled}); err == nil {
+		t.Error("Expected an error for a text wordbank read as compiled")
+	}
+
+	if err := All(Options{}).WriteCompiled(&buf); err == nil {
+		t.Error("Expected an error compiling a wordbank without a word list")
+	}
+}
+
+// benchmarkWords generates n distinct words of letters
+func benchmarkWords(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		word := []byte("word")
+		for v := i; ; v /= 26 {
+			word = append(word, byte('a'+v%26))
+			if v < 26 {
+				break
+			}
+		}
+		words[i] = string(word)
+	}
+	return words
+}
+
+// benchmarkSource writes words to a wordbank file, compiled for FormatCompiled,
+// and returns its path with the options to load it with backend
+func benchmarkSource(b *testing.B, backend string, words []string) (string, Options) {
+	b.Helper()
+	dir := b.TempDir()
+	path := filepath.Join(dir, "words.txt")
+	var content bytes.Buffer
+	for _, word := range words {
+		content.WriteString(word + "\n")
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+		b.Fatal(err)
+	}
+	if backend != FormatCompiled {
+		return path, Options{Backend: backend}
+	}
+
+	wb, err := New(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var compiled bytes.Buffer
+	if err := wb.WriteCompiled(&compiled); err != nil {
+		b.Fatal(err)
+	}
+	path = filepath.Join(dir, "words.wbin")
+	if err := os.WriteFile(path, compiled.Bytes(), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path, Options{}
+}
+
+// BenchmarkLookup compares lookups of words in and out of a 200,000 word wordbank
+// across backends, also reporting the heap each keeps per word. A compiled
+// wordbank is memory-mapped, so its words take page cache rather than heap.
+func BenchmarkLookup(b *testing.B) {
+	words := benchmarkWords(200000)
+	for _, backend := range []string{BackendMap, BackendSorted, FormatCompiled} {
+		b.Run(backend, func(b *testing.B) {
+			path, opts := benchmarkSource(b, backend, words)
+
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			wb, err := NewWithOptions(context.Background(), path, opts)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer wb.Close()
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			heap := float64(int64(after.HeapAlloc)-int64(before.HeapAlloc)) / float64(len(words))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				word := words[i%len(words)]
+				if i%2 == 1 {
+					word = word[1:] // Not in the wordbank
+				}
+				wb.IsValid(word)
+			}
+			b.ReportMetric(heap, "heap-B/word")
+		})
+	}
+}
+
+// BenchmarkLoad compares loading a 200,000 word wordbank across backends
+func BenchmarkLoad(b *testing.B) {
+	words := benchmarkWords(200000)
+	for _, backend := range []string{BackendMap, BackendSorted, FormatCompiled} {
+		b.Run(backend, func(b *testing.B) {
+			path, opts := benchmarkSource(b, backend, words)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				wb, err := NewWithOptions(context.Background(), path, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				wb.Close()
+			}
+		})
+	}
+}
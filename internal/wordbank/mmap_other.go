@@ -0,0 +1,17 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package wordbank
+
+import (
+	"fmt"
+	"os"
+)
+
+// mapFile reads the file at path into memory where memory-mapping is unsupported
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading word bank file: %w", err)
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,84 @@
+package wordbank
+
+import (
+	"strings"
+	"unicode"
+)
+
+// phraseTrie indexes multi-word entries by their words, so the longest phrase
+// starting at a token can be found in one walk
+type phraseTrie struct {
+	children map[string]*phraseTrie
+	phrase   string // Set when the words leading here form a phrase
+}
+
+// insert adds a phrase given as its words
+func (t *phraseTrie) insert(words []string) {
+	node := t
+	for _, word := range words {
+		child, ok := node.children[word]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*phraseTrie)
+			}
+			child = &phraseTrie{}
+			node.children[word] = child
+		}
+		node = child
+	}
+	node.phrase = strings.Join(words, " ")
+}
+
+// longest returns the longest phrase that tokens start with and how many tokens it spans
+func (t *phraseTrie) longest(tokens []string) (string, int) {
+	var phrase string
+	var n int
+
+	node := t
+	for i, token := range tokens {
+		if node = node.children[token]; node == nil {
+			break
+		}
+		if node.phrase != "" {
+			phrase, n = node.phrase, i+1
+		}
+	}
+	return phrase, n
+}
+
+// phraseWords splits an entry into its words when it is a phrase, requiring every
+// word to match the pattern so the phrase can be found in tokenized text. Hyphens
+// separate words as they do in text, so "e-reader" is the phrase "e reader".
+func phraseWords(entry string, match func(word string) bool) ([]string, bool) {
+	words := strings.FieldsFunc(entry, isWordSeparator)
+	if len(words) < 2 {
+		return nil, false
+	}
+	for _, word := range words {
+		if !match(word) {
+			return nil, false
+		}
+	}
+	return words, true
+}
+
+// aliasKey lowercases an alias and joins its words with single spaces. Hyphens
+// separate words as they do in text, so "Wi-Fi" is the phrase "wi fi". Every
+// word must match the pattern.
+func aliasKey(alias string, match func(word string) bool) (string, bool) {
+	words := strings.FieldsFunc(strings.ToLower(alias), isWordSeparator)
+	if len(words) == 0 {
+		return "", false
+	}
+	for _, word := range words {
+		if !match(word) {
+			return "", false
+		}
+	}
+	return strings.Join(words, " "), true
+}
+
+// isWordSeparator reports whether r separates the words of a phrase or alias
+func isWordSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == '-'
+}
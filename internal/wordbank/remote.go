@@ -0,0 +1,98 @@
+package wordbank
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/logging"
+)
+
+// fetchRemote returns the path of a local copy of the wordbank at url: the cached
+// copy while it is younger than opts.CacheTTL, and otherwise a fresh download,
+// falling back to a stale cached copy if downloading fails. Call remove once the
+// copy has been read; it deletes a download that was not cached.
+func fetchRemote(ctx context.Context, url string, opts Options) (path string, remove func(), err error) {
+	logger := logging.OrDiscard(opts.Logger)
+	keep := func() {}
+
+	dir := opts.CacheDir
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			logger.Warn("failed to create wordbank cache, not caching", "path", dir, "error", err)
+			dir = ""
+		}
+	}
+	if dir == "" {
+		downloaded, err := download(ctx, url, os.TempDir(), opts)
+		if err != nil {
+			return "", nil, err
+		}
+		return downloaded, func() { os.Remove(downloaded) }, nil
+	}
+
+	cached := cachePath(dir, url)
+	info, statErr := os.Stat(cached)
+	if statErr == nil && time.Since(info.ModTime()) < opts.CacheTTL {
+		logger.Debug("using cached wordbank", "url", url, "path", cached)
+		return cached, keep, nil
+	}
+
+	downloaded, err := download(ctx, url, dir, opts)
+	if err != nil {
+		if statErr == nil {
+			logger.Warn("failed to fetch wordbank, using cached copy", "url", url, "path", cached, "error", err)
+			return cached, keep, nil
+		}
+		return "", nil, err
+	}
+
+	// Renaming replaces the cached copy at once, so a reader never sees a partial one
+	if err := os.Rename(downloaded, cached); err != nil {
+		logger.Warn("failed to cache wordbank", "url", url, "error", err)
+		return downloaded, func() { os.Remove(downloaded) }, nil
+	}
+	return cached, keep, nil
+}
+
+// download streams url into a new file in dir through the fetcher in opts, limited
+// to opts.MaxBytes rather than the fetcher's page limit, and returns its path
+func download(ctx context.Context, url, dir string, opts Options) (string, error) {
+	fetch := opts.Fetcher
+	if fetch == nil {
+		fetch = fetcher.New(0, opts.Logger)
+	}
+
+	page, err := fetch.FetchURLLimit(ctx, url, opts.MaxBytes)
+	if err != nil {
+		return "", fmt.Errorf("fetching word bank: %w", err)
+	}
+	defer page.Body.Close()
+
+	file, err := os.CreateTemp(dir, ".wordbank-*")
+	if err != nil {
+		return "", fmt.Errorf("creating word bank download: %w", err)
+	}
+	if _, err := io.Copy(file, page.Body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("fetching word bank: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("writing word bank download: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// cachePath is where the wordbank at url is cached within dir
+func cachePath(dir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".wordbank")
+}
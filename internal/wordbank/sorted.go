@@ -0,0 +1,221 @@
+package wordbank
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// sortedStrings is an immutable sorted list of strings packed into two byte
+// slices, so it can be read in place from a memory-mapped file
+type sortedStrings struct {
+	data    []byte // The strings concatenated in order
+	offsets []byte // len+1 little-endian uint32 offsets into data
+}
+
+// packStrings packs strings in order; only sorted ones can be searched
+func packStrings(sorted []string) (sortedStrings, error) {
+	var s sortedStrings
+	s.offsets = make([]byte, 4*(len(sorted)+1))
+	total := 0
+	for _, str := range sorted {
+		total += len(str)
+	}
+	if total > math.MaxUint32 {
+		return s, fmt.Errorf("word bank too large: %d bytes of words", total)
+	}
+
+	s.data = make([]byte, 0, total)
+	for i, str := range sorted {
+		s.data = append(s.data, str...)
+		binary.LittleEndian.PutUint32(s.offsets[4*(i+1):], uint32(len(s.data)))
+	}
+	return s, nil
+}
+
+func (s sortedStrings) len() int {
+	if len(s.offsets) < 4 {
+		return 0
+	}
+	return len(s.offsets)/4 - 1
+}
+
+// at returns string i without copying it
+func (s sortedStrings) at(i int) []byte {
+	start := binary.LittleEndian.Uint32(s.offsets[4*i:])
+	end := binary.LittleEndian.Uint32(s.offsets[4*(i+1):])
+	return s.data[start:end]
+}
+
+// search returns the index of the first string not less than key
+func (s sortedStrings) search(key []byte) int {
+	return sort.Search(s.len(), func(i int) bool {
+		return bytes.Compare(s.at(i), key) >= 0
+	})
+}
+
+// find returns the index of key
+func (s sortedStrings) find(key []byte) (int, bool) {
+	i := s.search(key)
+	return i, i < s.len() && bytes.Equal(s.at(i), key)
+}
+
+// hasPrefix reports whether any string starts with prefix
+func (s sortedStrings) hasPrefix(prefix []byte) bool {
+	i := s.search(prefix)
+	return i < s.len() && bytes.HasPrefix(s.at(i), prefix)
+}
+
+// checkSize checks that the offsets span data, so a truncated compiled wordbank
+// fails to load
+func (s sortedStrings) checkSize() error {
+	if len(s.offsets) < 4 || len(s.offsets)%4 != 0 {
+		return fmt.Errorf("invalid offsets")
+	}
+	if end := binary.LittleEndian.Uint32(s.offsets[len(s.offsets)-4:]); int(end) != len(s.data) {
+		return fmt.Errorf("offsets end at %d, not %d", end, len(s.data))
+	}
+	return nil
+}
+
+// sortedLexicon keeps the wordbank in sorted arrays searched by bisection. It holds
+// no pointers per word, so it takes a fraction of the memory of a map and can be
+// read in place from a compiled wordbank.
+type sortedLexicon struct {
+	terms      sortedStrings
+	categoryOf []byte   // Little-endian uint16 per term indexing categories; empty when none has one
+	categories []string // Category names, with categories[0] = "" for none
+	aliases    sortedStrings
+	aliasTerms []byte // Little-endian uint32 term index per alias
+	phrases    bool
+
+	unmap func() error // Releases the memory-mapped file it is read from, if any
+}
+
+// newSortedLexicon builds a sorted lexicon from the words read and their aliases
+func newSortedLexicon(words map[string]Entry, aliases map[string]string) (*sortedLexicon, error) {
+	terms := make([]string, 0, len(words))
+	for term := range words {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	lex := &sortedLexicon{categories: []string{""}}
+	var err error
+	if lex.terms, err = packStrings(terms); err != nil {
+		return nil, err
+	}
+
+	categoryIndex := map[string]uint16{"": 0}
+	categoryOf := make([]byte, 2*len(terms))
+	var categorized bool
+	for i, term := range terms {
+		entry := words[term]
+		index, ok := categoryIndex[entry.Category]
+		if !ok {
+			if len(lex.categories) > math.MaxUint16 {
+				return nil, fmt.Errorf("word bank has more than %d categories", math.MaxUint16)
+			}
+			index = uint16(len(lex.categories))
+			categoryIndex[entry.Category] = index
+			lex.categories = append(lex.categories, entry.Category)
+			categorized = true
+		}
+		binary.LittleEndian.PutUint16(categoryOf[2*i:], index)
+
+		lex.phrases = lex.phrases || strings.Contains(term, " ")
+	}
+	if categorized {
+		lex.categoryOf = categoryOf
+	}
+
+	variants := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		variants = append(variants, alias)
+		lex.phrases = lex.phrases || strings.Contains(alias, " ")
+	}
+	sort.Strings(variants)
+	if lex.aliases, err = packStrings(variants); err != nil {
+		return nil, err
+	}
+	lex.aliasTerms = make([]byte, 4*len(variants))
+	for i, alias := range variants {
+		index := sort.SearchStrings(terms, aliases[alias])
+		binary.LittleEndian.PutUint32(lex.aliasTerms[4*i:], uint32(index))
+	}
+
+	return lex, nil
+}
+
+func (l *sortedLexicon) lookup(term string) (Entry, bool) {
+	i, ok := l.terms.find([]byte(term))
+	if !ok {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	if len(l.categoryOf) > 0 {
+		entry.Category = l.categories[binary.LittleEndian.Uint16(l.categoryOf[2*i:])]
+	}
+	return entry, true
+}
+
+func (l *sortedLexicon) alias(variant string) (string, bool) {
+	i, ok := l.aliases.find([]byte(variant))
+	if !ok {
+		return "", false
+	}
+	term := binary.LittleEndian.Uint32(l.aliasTerms[4*i:])
+	return string(l.terms.at(int(term))), true
+}
+
+// matchPhrase extends the phrase a token at a time while some term or alias
+// starts with it, keeping the longest that is one
+func (l *sortedLexicon) matchPhrase(tokens []string) (string, int) {
+	if !l.phrases || len(tokens) < 2 {
+		return "", 0
+	}
+
+	var buf [64]byte
+	key := append(buf[:0], tokens[0]...)
+	best := 0
+	var phrase string
+	for n := 2; n <= len(tokens); n++ {
+		key = append(append(key, ' '), tokens[n-1]...)
+		if !l.terms.hasPrefix(key) && !l.aliases.hasPrefix(key) {
+			break
+		}
+		_, isTerm := l.terms.find(key)
+		_, isAlias := l.aliases.find(key)
+		if isTerm || isAlias {
+			best, phrase = n, string(key)
+		}
+	}
+	return phrase, best
+}
+
+func (l *sortedLexicon) hasPhrases() bool {
+	return l.phrases
+}
+
+func (l *sortedLexicon) size() int {
+	return l.terms.len()
+}
+
+func (l *sortedLexicon) each(fn func(term string)) {
+	for i := 0; i < l.terms.len(); i++ {
+		fn(string(l.terms.at(i)))
+	}
+}
+
+func (l *sortedLexicon) close() error {
+	if l.unmap == nil {
+		return nil
+	}
+	unmap := l.unmap
+	l.unmap = nil
+	return unmap()
+}
@@ -2,54 +2,240 @@ package wordbank
 
 import (
 	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/stopwords"
 )
 
+// Entry is what the wordbank records about a word
+type Entry struct {
+	Category string   // "" unless the wordbank gives one
+	Aliases  []string // Variants credited to the word as read, lowercased with their words joined by single spaces
+}
+
+// Options controls how a wordbank is read
+type Options struct {
+	Format   string           // FormatText, FormatCSV, FormatTSV, FormatJSON or FormatCompiled ("" or FormatAuto = detect)
+	Backend  string           // BackendMap or BackendSorted ("" = BackendMap); compiled wordbanks are always sorted
+	Fetcher  *fetcher.Fetcher // Fetches http(s) wordbanks (nil = a default fetcher)
+	MaxBytes int64            // Largest http(s) wordbank downloaded, in place of the fetcher's page limit (0 = unlimited)
+	CacheDir string           // Where fetched wordbanks are cached ("" = no cache)
+	CacheTTL time.Duration    // How long a cached wordbank is used without fetching it again
+	Logger   *slog.Logger     // nil discards all log output
+
+	Filter    *config.WordFilterConfig // Words must pass this to be valid (nil = the default filter)
+	Stopwords *stopwords.Set           // Words that are never valid (nil = none)
+
+	// FuzzyDistance credits a word outside the wordbank to the single closest word
+	// within this many edits (0 = off)
+	FuzzyDistance int
+}
+
 // WordBank holds valid words for filtering
 type WordBank struct {
-	words map[string]bool
+	lexicon lexicon       // Words, phrases and aliases; nil for All
+	fuzzy   *fuzzyMatcher // nil unless Options.FuzzyDistance is set
+	source  string
+	sha256  string
+	left    leftOut // Entries left out when loading
+
+	// Set by All, which accepts any word passing the filter that is not a stopword
+	all       bool
+	filter    *config.WordFilterConfig
+	stopwords *stopwords.Set
+}
+
+// All creates a WordBank without a word list, in which every word that passes
+// opts.Filter and is not one of opts.Stopwords is valid
+func All(opts Options) *WordBank {
+	return &WordBank{
+		all:       true,
+		filter:    filterOf(opts),
+		stopwords: opts.Stopwords,
+	}
 }
 
-// New creates a new WordBank from a file
-func New(filename string) (*WordBank, error) {
-	file, err := os.Open(filename)
+// New creates a new WordBank from a file or http(s) URL with the default options
+func New(source string) (*WordBank, error) {
+	return NewWithOptions(context.Background(), source, Options{})
+}
+
+// NewWithOptions creates a new WordBank from a file or http(s) URL, detecting its
+// format from the extension or content unless opts names one. A compiled wordbank
+// file is memory-mapped rather than read; call Close to release it.
+func NewWithOptions(ctx context.Context, source string, opts Options) (*WordBank, error) {
+	if err := ValidateFormat(opts.Format); err != nil {
+		return nil, err
+	}
+	if err := ValidateBackend(opts.Backend); err != nil {
+		return nil, err
+	}
+	if opts.MaxBytes < 0 {
+		return nil, fmt.Errorf("word bank size limit must be non-negative (0 = unlimited)")
+	}
+	detect := opts.Format == "" || opts.Format == FormatAuto
+
+	// A remote wordbank is downloaded to a file, then read like any other
+	path := source
+	if IsRemote(source) {
+		downloaded, remove, err := fetchRemote(ctx, source, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer remove()
+		path = downloaded
+	}
+
+	compiled := opts.Format == FormatCompiled
+	if detect {
+		var err error
+		if compiled, err = isCompiledFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if compiled {
+		lex, digest, err := openCompiled(path)
+		if err != nil {
+			return nil, err
+		}
+		return newWordBank(lex, source, digest, opts), nil
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("opening word bank file: %w", err)
 	}
 	defer file.Close()
 
-	words := make(map[string]bool)
-	scanner := bufio.NewScanner(file)
+	// Hash everything read, including anything after the entries, to identify the wordbank in manifests
+	hash := sha256.New()
+	tee := io.TeeReader(file, hash)
+	words, left, err := decode(tee, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return nil, fmt.Errorf("reading word bank file: %w", err)
+	}
+
+	var lex lexicon
+	aliases := aliasesOf(words)
+	if opts.Backend == BackendSorted {
+		if lex, err = newSortedLexicon(words, aliases); err != nil {
+			return nil, fmt.Errorf("reading word bank file: %w", err)
+		}
+	} else {
+		lex = newMapLexicon(words, aliases)
+	}
+	wb := newWordBank(lex, source, hex.EncodeToString(hash.Sum(nil)), opts)
+	wb.left = left
+	return wb, nil
+}
+
+// newWordBank wraps a lexicon, indexing it for fuzzy matching if opts asks
+func newWordBank(lex lexicon, source, sha256 string, opts Options) *WordBank {
+	wb := &WordBank{lexicon: lex, source: source, sha256: sha256}
+	if opts.FuzzyDistance > 0 {
+		wb.fuzzy = newFuzzyMatcher(lex, opts.FuzzyDistance, filterOf(opts), opts.Stopwords)
+	}
+	return wb
+}
+
+// IsRemote reports whether source is an http(s) URL rather than a file
+func IsRemote(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
 
-	// Get word filtering configuration
-	filterConfig := config.GetWordFilterConfig()
+// leftOut counts the wordbank entries that were not loaded
+type leftOut struct {
+	stopwords int // Single words that are stopwords
+	filtered  int // Entries whose words don't pass the word filter
+}
 
-	for scanner.Scan() {
-		word := strings.TrimSpace(scanner.Text())
-		if word == "" {
-			continue
+// decode reads the wordbank entries in reader, decompressing gzip and detecting the
+// format. It also returns how many entries were left out.
+func decode(reader io.Reader, source string, opts Options) (map[string]Entry, leftOut, error) {
+	buffered := bufio.NewReader(reader)
+	content, err := decompress(buffered)
+	if err != nil {
+		return nil, leftOut{}, err
+	}
+
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		if format, err = detectFormat(source, content); err != nil {
+			return nil, leftOut{}, fmt.Errorf("reading word bank file: %w", err)
 		}
+	}
 
+	filter := filterOf(opts)
+	words := make(map[string]Entry)
+	var left leftOut
+	add := func(word string, entry Entry) {
 		// Convert to lowercase for case-insensitive matching
-		word = strings.ToLower(word)
+		word = strings.ToLower(strings.TrimSpace(word))
+
+		// Aliases may be single words or phrases, whose words only need to match the pattern
+		var aliases []string
+		for _, alias := range entry.Aliases {
+			if key, ok := aliasKey(alias, filter.Pattern.MatchString); ok {
+				aliases = append(aliases, key)
+			}
+		}
+		entry.Aliases = aliases
+
+		// A phrase's words only need to match the pattern; short words and stopwords
+		// are part of many phrases
+		if parts, ok := phraseWords(word, filter.Pattern.MatchString); ok {
+			words[strings.Join(parts, " ")] = entry
+			return
+		}
 
 		// Only include words that match our validation criteria
-		if filterConfig.Pattern.MatchString(word) {
-			words[word] = true
+		if !filter.Match(word) {
+			left.filtered++
+			return
+		}
+		if opts.Stopwords.Contains(word) {
+			left.stopwords++
+			return
 		}
+		words[word] = entry
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading word bank file: %w", err)
+	switch format {
+	case FormatCSV:
+		err = readDelimited(content, ',', add)
+	case FormatTSV:
+		err = readDelimited(content, '\t', add)
+	case FormatJSON:
+		err = readJSON(content, add)
+	default:
+		err = readText(content, add)
+	}
+	if err != nil {
+		return nil, leftOut{}, fmt.Errorf("reading word bank file: %w", err)
 	}
 
-	return &WordBank{
-		words: words,
-	}, nil
+	return words, left, nil
+}
+
+// filterOf returns the word filter in opts, or the default one
+func filterOf(opts Options) *config.WordFilterConfig {
+	if opts.Filter == nil {
+		return config.GetWordFilterConfig()
+	}
+	return opts.Filter
 }
 
 // IsValid checks if a word is valid according to our criteria
@@ -61,11 +247,107 @@ func (wb *WordBank) IsValid(word string) bool {
 	// Convert to lowercase for case-insensitive matching
 	word = strings.ToLower(word)
 
+	if wb.all {
+		return wb.filter.Match(word) && !wb.stopwords.Contains(word)
+	}
+
 	// Check if word exists in our word bank (already filtered during loading)
-	return wb.words[word]
+	_, ok := wb.lexicon.lookup(word)
+	return ok
+}
+
+// Lookup returns the entry for a word and whether it is in the wordbank
+func (wb *WordBank) Lookup(word string) (Entry, bool) {
+	if wb.all {
+		return Entry{}, wb.IsValid(word)
+	}
+	return wb.lexicon.lookup(strings.ToLower(word))
+}
+
+// MatchPhrase returns the longest phrase that the lowercase tokens start with and
+// how many tokens it spans, or 0 when they start with none
+func (wb *WordBank) MatchPhrase(tokens []string) (string, int) {
+	if wb.all {
+		return "", 0
+	}
+	return wb.lexicon.matchPhrase(tokens)
+}
+
+// Phrases reports whether the wordbank has any multi-word entries
+func (wb *WordBank) Phrases() bool {
+	return !wb.all && wb.lexicon.hasPhrases()
+}
+
+// Resolve returns the term credited for a lowercase word or phrase that is not
+// itself in the wordbank: the term it is an alias of, or else the single closest
+// word within the fuzzy distance. It returns "" when there is none.
+func (wb *WordBank) Resolve(word string) string {
+	if wb.all {
+		return ""
+	}
+	if term, ok := wb.lexicon.alias(word); ok {
+		return term
+	}
+	return wb.fuzzy.match(word)
+}
+
+// IsAll reports whether the wordbank was created by All and has no word list
+func (wb *WordBank) IsAll() bool {
+	return wb.all
 }
 
-// Size returns the number of words in the word bank
+// Category returns the category of a word, or "" when it has none or is not in the wordbank
+func (wb *WordBank) Category(word string) string {
+	if wb.all {
+		return ""
+	}
+	entry, _ := wb.lexicon.lookup(strings.ToLower(word))
+	return entry.Category
+}
+
+// Size returns the number of words and phrases in the word bank, which is 0 for All
 func (wb *WordBank) Size() int {
-	return len(wb.words)
+	if wb.all {
+		return 0
+	}
+	return wb.lexicon.size()
+}
+
+// StopwordsRemoved returns how many entries were left out when loading because
+// they are stopwords
+func (wb *WordBank) StopwordsRemoved() int {
+	return wb.left.stopwords
+}
+
+// FilteredOut returns how many entries were left out when loading because they,
+// or a word of a phrase, don't pass the word filter
+func (wb *WordBank) FilteredOut() int {
+	return wb.left.filtered
+}
+
+// Close releases a memory-mapped compiled wordbank. The wordbank must not be used afterwards.
+func (wb *WordBank) Close() error {
+	if wb.all {
+		return nil
+	}
+	return wb.lexicon.close()
+}
+
+// Source returns the file or URL the wordbank was read from, or "" for All
+func (wb *WordBank) Source() string {
+	return wb.source
+}
+
+// SHA256 returns the hex SHA-256 of the wordbank as read, before any decompression
+func (wb *WordBank) SHA256() string {
+	return wb.sha256
+}
+
+// Version identifies the words of the wordbank by the start of its SHA-256, so
+// counts made before and after it is edited can be told apart. It is "" for All.
+func (wb *WordBank) Version() string {
+	if len(wb.sha256) < 12 {
+		return wb.sha256
+	}
+	return wb.sha256[:12]
 }
@@ -1,10 +1,25 @@
 package wordbank
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/config"
+	"github.com/firefly/essay-analyzer/internal/fetcher"
+	"github.com/firefly/essay-analyzer/internal/stopwords"
 )
 
 // TestNew_MissingFile tests that New returns an error when file is missing
@@ -62,7 +77,7 @@ func TestNew_FilteringCorrectly(t *testing.T) {
 		"x",                    // too short (1 char)
 		"ab",                   // too short (2 chars)
 		"123",                  // not alphabetic
-		"test-word",            // contains hyphen
+		"test-word",            // contains hyphen, so it is the phrase "test word"
 		"word_with_underscore", // contains underscore
 	}
 
@@ -74,7 +89,8 @@ func TestNew_FilteringCorrectly(t *testing.T) {
 
 	// Verify size - should only include valid words
 	// From our test file: 22 valid words (10 + 2 + 10, with UPPERCASE/MixedCase converted)
-	expectedSize := 22
+	// and the phrase "test word"
+	expectedSize := 23
 	if wordBank.Size() != expectedSize {
 		t.Errorf("Expected wordbank size to be %d, got %d", expectedSize, wordBank.Size())
 	}
@@ -177,8 +193,8 @@ func TestSize(t *testing.T) {
 		t.Error("Expected wordbank size to be greater than 0")
 	}
 
-	// Should be exactly 22 valid words from our test file
-	expectedSize := 22
+	// Should be exactly 22 valid words and 1 phrase from our test file
+	expectedSize := 23
 	if size != expectedSize {
 		t.Errorf("Expected wordbank size to be %d, got %d", expectedSize, size)
 	}
@@ -241,3 +257,428 @@ func TestNew_OnlyWhitespace(t *testing.T) {
 		t.Errorf("Expected wordbank with only whitespace to have size 0, got %d", wordBank.Size())
 	}
 }
+
+// writeTestFile writes content to name in a temporary directory and returns its path
+func writeTestFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+// gzipped compresses s
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(s))
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestNew_Formats tests that every format is detected and read, by extension or content
+func TestNew_Formats(t *testing.T) {
+	testCases := []struct {
+		name    string
+		file    string
+		content []byte
+	}{
+		{"text", "words.txt", []byte("Robot\nlaptop\nai\n")},
+		{"gzip text", "words.txt.gz", gzipped(t, "robot\nlaptop\n")},
+		{"gzip without extension", "words", gzipped(t, "robot\nlaptop\n")},
+		{"csv header", "words.csv", []byte("category,word,weight\nhardware,robot,2.5\n,laptop,\n")},
+		{"csv sniffed", "words", []byte("robot,2.5,hardware\nlaptop\n")},
+		{"tsv", "words.tsv", []byte("robot\t2.5\thardware\n# comment\nlaptop\n")},
+		{"json", "words.json", []byte(`["laptop", {"word": "Robot", "weight": 2.5, "category": "hardware"}]`)},
+		{"json sniffed gzip", "words.gz", gzipped(t, `[{"word": "robot", "weight": 2.5, "category": "hardware"}, "laptop"]`)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wordBank, err := New(writeTestFile(t, tc.file, tc.content))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if wordBank.Size() != 2 {
+				t.Errorf("Expected 2 words, got %d", wordBank.Size())
+			}
+			if !wordBank.IsValid("robot") || !wordBank.IsValid("laptop") {
+				t.Errorf("Expected robot and laptop to be valid")
+			}
+
+			entry, _ := wordBank.Lookup("laptop")
+			if entry.Category != "" {
+				t.Errorf("Expected laptop to have no category, got %+v", entry)
+			}
+			if tc.name != "text" && tc.name != "gzip text" && tc.name != "gzip without extension" {
+				entry, _ := wordBank.Lookup("ROBOT")
+				if entry.Category != "hardware" {
+					t.Errorf("Expected robot to have category hardware, got %+v", entry)
+				}
+				if wordBank.Category("Robot") != "hardware" || wordBank.Category("laptop") != "" {
+					t.Errorf("Expected categories hardware and none, got %q and %q", wordBank.Category("Robot"), wordBank.Category("laptop"))
+				}
+			}
+		})
+	}
+}
+
+// TestNewWithOptions_Format tests that an explicit format overrides detection
+func TestNewWithOptions_Format(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("robot,2,hardware\n"))
+
+	wordBank, err := NewWithOptions(context.Background(), path, Options{Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry, ok := wordBank.Lookup("robot"); !ok || entry.Category != "hardware" {
+		t.Errorf("Expected robot in category hardware, got %+v (found %v)", entry, ok)
+	}
+
+	if _, err := NewWithOptions(context.Background(), path, Options{Format: "yaml"}); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
+
+// TestNew_InvalidWeight tests that a bad weight reports its line
+func TestNew_InvalidWeight(t *testing.T) {
+	_, err := New(writeTestFile(t, "words.csv", []byte("word,weight\nrobot,1\nlaptop,heavy\n")))
+	if err == nil {
+		t.Fatal("Expected error for invalid weight, got nil")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error to name line 3, got: %v", err)
+	}
+}
+
+// TestNew_SHA256 tests that the digest covers the file as stored
+func TestNew_SHA256(t *testing.T) {
+	content := gzipped(t, "robot\n")
+	wordBank, err := New(writeTestFile(t, "words.gz", content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	if wordBank.SHA256() != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected SHA-256 of the compressed file, got %s", wordBank.SHA256())
+	}
+	if wordBank.Version() != wordBank.SHA256()[:12] {
+		t.Errorf("Expected the version to start the SHA-256, got %s", wordBank.Version())
+	}
+	if version := All(Options{}).Version(); version != "" {
+		t.Errorf("Expected no version without a word list, got %q", version)
+	}
+}
+
+// TestNewWithOptions_Remote tests fetching a wordbank over HTTP through the cache
+func TestNewWithOptions_Remote(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("word,category\nrobot,hardware\n"))
+	}))
+	defer server.Close()
+
+	opts := Options{CacheDir: t.TempDir(), CacheTTL: time.Hour}
+	url := server.URL + "/words.csv"
+
+	wordBank, err := NewWithOptions(context.Background(), url, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if entry, _ := wordBank.Lookup("robot"); entry.Category != "hardware" {
+		t.Errorf("Expected robot in category hardware, got %+v", entry)
+	}
+
+	// A fresh cache is used without fetching again
+	if _, err := NewWithOptions(context.Background(), url, opts); err != nil {
+		t.Fatalf("Expected no error from cache, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", requests.Load())
+	}
+
+	// An expired cache is still used when the wordbank cannot be fetched
+	opts.CacheTTL = 0
+	wordBank, err = NewWithOptions(context.Background(), url, opts)
+	if err != nil {
+		t.Fatalf("Expected stale cache to be used, got %v", err)
+	}
+	if !wordBank.IsValid("robot") {
+		t.Error("Expected robot to be valid from the stale cache")
+	}
+
+	// Without a cache the failure is returned
+	if _, err := NewWithOptions(context.Background(), url, Options{}); err == nil {
+		t.Error("Expected error fetching a missing wordbank, got nil")
+	}
+}
+
+// TestNewWithOptions_RemoteLarge tests that a remote wordbank is not held to the
+// fetcher's page size limit, only to its own
+func TestNewWithOptions_RemoteLarge(t *testing.T) {
+	var body strings.Builder
+	for i := 0; body.Len() < 4096; i++ {
+		body.WriteString("robot" + strings.Repeat("s", i%50) + "\n")
+	}
+	body.WriteString("laptop\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.String()))
+	}))
+	defer server.Close()
+
+	fetchOpts := fetcher.DefaultOptions()
+	fetchOpts.MaxBodyBytes = 1024
+	cacheDir := t.TempDir()
+	opts := Options{Fetcher: fetcher.NewWithOptions(0, fetchOpts, nil), CacheDir: cacheDir, CacheTTL: time.Hour}
+	url := server.URL + "/words.txt"
+
+	wordBank, err := NewWithOptions(context.Background(), url, opts)
+	if err != nil {
+		t.Fatalf("Expected a wordbank larger than the page limit to load, got %v", err)
+	}
+	if !wordBank.IsValid("laptop") {
+		t.Error("Expected laptop, the last word, to be valid")
+	}
+	sum := sha256.Sum256([]byte(body.String()))
+	if wordBank.SHA256() != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the SHA-256 of the whole download, got %s", wordBank.SHA256())
+	}
+
+	// Only the cached copy is left behind, with no partial downloads
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(cachePath(cacheDir, url)) {
+		t.Errorf("Expected only the cached wordbank, got %v", entries)
+	}
+
+	// The wordbank's own limit still applies
+	opts.CacheDir = ""
+	opts.MaxBytes = 1024
+	if _, err := NewWithOptions(context.Background(), url, opts); !errors.Is(err, fetcher.ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge past the wordbank limit, got %v", err)
+	}
+}
+
+// TestNewWithOptions_FilterAndStopwords tests that loading applies the given filter and stopwords
+func TestNewWithOptions_FilterAndStopwords(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("the\nand\nrobot\nai\nr2d2\n"))
+	opts := Options{
+		Filter:    &config.WordFilterConfig{Pattern: regexp.MustCompile(`^[a-z0-9]+$`), MinLength: 2},
+		Stopwords: stopwords.New("the", "and"),
+	}
+
+	wordBank, err := NewWithOptions(context.Background(), path, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for word, want := range map[string]bool{"the": false, "and": false, "robot": true, "ai": true, "r2d2": true} {
+		if got := wordBank.IsValid(word); got != want {
+			t.Errorf("IsValid(%s): expected %v, got %v", word, want, got)
+		}
+	}
+	if removed := wordBank.StopwordsRemoved(); removed != 2 {
+		t.Errorf("Expected 2 entries removed as stopwords, got %d", removed)
+	}
+}
+
+// TestAll tests a wordbank without a word list
+func TestAll(t *testing.T) {
+	wordBank := All(Options{Stopwords: stopwords.New("the", "and")})
+
+	testCases := []struct {
+		word     string
+		expected bool
+	}{
+		{"anything", true},
+		{"Robots", true},
+		{"the", false},  // stopword
+		{"AND", false},  // stopword, case-insensitive
+		{"ai", false},   // shorter than the default minimum
+		{"r2d2", false}, // not alphabetic
+		{"", false},
+	}
+	for _, tc := range testCases {
+		if got := wordBank.IsValid(tc.word); got != tc.expected {
+			t.Errorf("IsValid(%s): expected %v, got %v", tc.word, tc.expected, got)
+		}
+	}
+
+	if !wordBank.IsAll() || wordBank.Size() != 0 || wordBank.Source() != "" {
+		t.Errorf("Expected an empty All wordbank, got size %d from %q", wordBank.Size(), wordBank.Source())
+	}
+	if entry, ok := wordBank.Lookup("anything"); !ok || entry.Category != "" {
+		t.Errorf("Expected anything with no category, got %+v (found %v)", entry, ok)
+	}
+}
+
+// TestNew_Phrases tests that multi-word entries are loaded and matched longest first
+func TestNew_Phrases(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("Virtual  Reality\nvirtual reality headset\nXbox One\nrobot\nwi-fi router\nE-Reader\nR2 D2\nR2-D2\n"))
+
+	wordBank, err := New(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !wordBank.Phrases() {
+		t.Fatal("Expected the wordbank to have phrases")
+	}
+	// Hyphens split words as the tokenizer does, but "R2 D2" and "R2-D2" have
+	// words it never produces
+	if wordBank.Size() != 6 {
+		t.Errorf("Expected 5 phrases and 1 word, got %d entries", wordBank.Size())
+	}
+	if filtered := wordBank.FilteredOut(); filtered != 2 {
+		t.Errorf("Expected 2 entries filtered out, got %d", filtered)
+	}
+
+	testCases := []struct {
+		tokens []string
+		phrase string
+		n      int
+	}{
+		{[]string{"virtual", "reality", "headset", "review"}, "virtual reality headset", 3},
+		{[]string{"virtual", "reality", "glasses"}, "virtual reality", 2},
+		{[]string{"virtual", "machine"}, "", 0},
+		{[]string{"xbox", "one"}, "xbox one", 2}, // Short words and stopwords are fine within a phrase
+		{[]string{"wi", "fi", "router"}, "wi fi router", 3},
+		{[]string{"e", "reader"}, "e reader", 2},
+		{[]string{"robot"}, "", 0},
+	}
+	for _, tc := range testCases {
+		phrase, n := wordBank.MatchPhrase(tc.tokens)
+		if phrase != tc.phrase || n != tc.n {
+			t.Errorf("MatchPhrase(%v): expected %q/%d, got %q/%d", tc.tokens, tc.phrase, tc.n, phrase, n)
+		}
+	}
+
+	plain, err := New(writeTestFile(t, "plain.txt", []byte("robot\nlaptop\n")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if plain.Phrases() {
+		t.Error("Expected no phrases in a single-word wordbank")
+	}
+}
+
+func TestNew_Aliases(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"words.txt", "ereader = e-reader, E Reader\nwifi = Wi-Fi\ncolor = colour\nrobot\n"},
+		{"words.csv", "word,aliases\nereader,e-reader|e reader\nwifi,wi-fi\ncolor,colour\nrobot,\n"},
+		{"words.json", `[{"word": "ereader", "aliases": ["e-reader", "E Reader"]}, {"word": "wifi", "aliases": ["Wi-Fi"]}, {"word": "color", "aliases": ["colour"]}, "robot"]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wordBank, err := New(writeTestFile(t, tc.name, []byte(tc.content)))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if wordBank.Size() != 4 {
+				t.Errorf("Expected aliases not to count as words, got %d entries", wordBank.Size())
+			}
+			if wordBank.IsValid("colour") {
+				t.Error("Expected an alias not to be valid itself")
+			}
+
+			for alias, term := range map[string]string{"colour": "color", "e reader": "ereader", "wi fi": "wifi", "robots": ""} {
+				if got := wordBank.Resolve(alias); got != term {
+					t.Errorf("Resolve(%q): expected %q, got %q", alias, term, got)
+				}
+			}
+
+			// Hyphenated aliases are matched as phrases, like the tokens they are read as
+			if phrase, n := wordBank.MatchPhrase([]string{"wi", "fi", "router"}); phrase != "wi fi" || n != 2 {
+				t.Errorf("Expected the alias phrase \"wi fi\" to match, got %q/%d", phrase, n)
+			}
+		})
+	}
+}
+
+func TestNew_AliasOfWord(t *testing.T) {
+	// An alias that is also a word keeps being counted as itself
+	wordBank, err := New(writeTestFile(t, "words.txt", []byte("color = colour\ncolour\n")))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !wordBank.IsValid("colour") || wordBank.Resolve("colour") != "" {
+		t.Error("Expected colour to be a word rather than an alias")
+	}
+}
+
+func TestNewWithOptions_Fuzzy(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("technology\nscience\ncomputer\nbread\nbeard\n"))
+
+	wordBank, err := NewWithOptions(context.Background(), path, Options{FuzzyDistance: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	testCases := []struct {
+		word string
+		term string
+	}{
+		{"tecnology", "technology"}, // One deletion
+		{"commuter", "computer"},    // One substitution
+		{"sceince", "science"},      // A transposition is two edits
+		{"beads", ""},               // Two edits from both bread and beard
+		{"technologies", ""},        // Three edits
+		{"zzzzzzzz", ""},            // Far from everything
+		{"brea", ""},                // Too short to match fuzzily
+	}
+	for _, tc := range testCases {
+		if got := wordBank.Resolve(tc.word); got != tc.term {
+			t.Errorf("Resolve(%q): expected %q, got %q", tc.word, tc.term, got)
+		}
+	}
+
+	exact, err := New(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := exact.Resolve("tecnology"); got != "" {
+		t.Errorf("Expected no fuzzy matching by default, got %q", got)
+	}
+}
+
+// TestFuzzyMatcher_CacheBounded tests that the fuzzy cache forgets the least recently used words
+func TestFuzzyMatcher_CacheBounded(t *testing.T) {
+	path := writeTestFile(t, "words.txt", []byte("technology\nscience\ncomputer\n"))
+	wordBank, err := NewWithOptions(context.Background(), path, Options{FuzzyDistance: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	m := wordBank.fuzzy
+	m.cacheSize = 2
+
+	m.match("tecnology")
+	m.match("commuter")
+	m.match("tecnology") // Now more recent than "commuter"
+	if got := m.match("sceince"); got != "science" {
+		t.Errorf("Expected science, got %q", got)
+	}
+
+	if len(m.cache) != 2 || m.recent.Len() != 2 {
+		t.Fatalf("Expected 2 cached words, got %d", len(m.cache))
+	}
+	for word, want := range map[string]bool{"tecnology": true, "sceince": true, "commuter": false} {
+		if _, ok := m.cache[word]; ok != want {
+			t.Errorf("Expected %q cached to be %v", word, want)
+		}
+	}
+}
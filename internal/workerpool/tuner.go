@@ -0,0 +1,172 @@
+package workerpool
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/firefly/essay-analyzer/internal/logging"
+)
+
+const (
+	// DefaultSampleInterval is how often the Tuner samples queues and workers
+	DefaultSampleInterval = 100 * time.Millisecond
+
+	// DefaultAdjustInterval is how often the Tuner may move workers between stages
+	DefaultAdjustInterval = 2 * time.Second
+
+	// backedUp is the average input occupancy above which a stage is falling behind
+	backedUp = 0.5
+
+	// saturated is the average busy fraction above which a stage has no spare workers
+	saturated = 0.8
+
+	// idle is the average busy fraction below which a stage can give up workers
+	idle = 0.5
+
+	// blocked is the average output occupancy above which a stage is held up downstream
+	blocked = 0.9
+)
+
+// Resizable is a pool the Tuner can resize
+type Resizable interface {
+	Size() int
+	Active() int
+	Resize(n int)
+}
+
+// Stage is one pipeline stage under the Tuner's control
+type Stage struct {
+	Name   string
+	Pool   Resizable
+	Input  func() (length, capacity int) // The channel the stage reads from
+	Output func() (length, capacity int) // The channel the stage writes to, or nil
+}
+
+// Tuner moves workers between stages towards the bottleneck, keeping the total fixed.
+// A stage is the bottleneck when its input backs up while its workers are all busy
+// and its output still has room; workers are taken from the least busy stage.
+type Tuner struct {
+	stages         []Stage
+	SampleInterval time.Duration
+	AdjustInterval time.Duration
+	logger         *slog.Logger
+
+	samples []stageSample // Running sums since the last adjustment, one per stage
+	count   int
+}
+
+// stageSample accumulates a stage's occupancy and busy fractions between adjustments
+type stageSample struct {
+	input  float64
+	output float64
+	busy   float64
+}
+
+// NewTuner creates a Tuner for stages listed in pipeline order. A nil logger discards all log output.
+func NewTuner(logger *slog.Logger, stages ...Stage) *Tuner {
+	return &Tuner{
+		stages:         stages,
+		SampleInterval: DefaultSampleInterval,
+		AdjustInterval: DefaultAdjustInterval,
+		logger:         logging.OrDiscard(logger),
+		samples:        make([]stageSample, len(stages)),
+	}
+}
+
+// Run samples and adjusts the stages until ctx is canceled
+func (t *Tuner) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.SampleInterval)
+	defer ticker.Stop()
+
+	lastAdjust := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.Sample()
+			if now.Sub(lastAdjust) >= t.AdjustInterval {
+				t.Adjust()
+				lastAdjust = now
+			}
+		}
+	}
+}
+
+// Sample records the current occupancy and busy fraction of every stage
+func (t *Tuner) Sample() {
+	for i, stage := range t.stages {
+		t.samples[i].input += fraction(stage.Input)
+		t.samples[i].output += fraction(stage.Output)
+		if size := stage.Pool.Size(); size > 0 {
+			t.samples[i].busy += float64(stage.Pool.Active()) / float64(size)
+		}
+	}
+	t.count++
+}
+
+// Adjust moves workers from the least busy stage to the bottleneck, if there is one,
+// and starts a new sampling period
+func (t *Tuner) Adjust() {
+	if t.count == 0 {
+		return
+	}
+	defer func() {
+		t.samples = make([]stageSample, len(t.stages))
+		t.count = 0
+	}()
+
+	n := float64(t.count)
+	target, donor := -1, -1
+	var targetPressure float64
+	for i, s := range t.samples {
+		input, output, busy := s.input/n, s.output/n, s.busy/n
+		if input >= backedUp && busy >= saturated && output < blocked {
+			if pressure := input * busy; target < 0 || pressure > targetPressure {
+				target, targetPressure = i, pressure
+			}
+		}
+	}
+	if target < 0 {
+		return
+	}
+
+	var donorBusy float64
+	for i, s := range t.samples {
+		busy := s.busy / n
+		if i == target || busy >= idle || t.stages[i].Pool.Size() <= 1 {
+			continue
+		}
+		if donor < 0 || busy < donorBusy {
+			donor, donorBusy = i, busy
+		}
+	}
+	if donor < 0 {
+		return
+	}
+
+	// Move a quarter of the donor's workers, at least one, keeping one behind
+	from, to := t.stages[donor], t.stages[target]
+	move := max(1, from.Pool.Size()/4)
+	move = min(move, from.Pool.Size()-1)
+
+	from.Pool.Resize(from.Pool.Size() - move)
+	to.Pool.Resize(to.Pool.Size() + move)
+
+	t.logger.Info("moved workers to bottleneck stage",
+		"from", from.Name, "to", to.Name, "workers", move,
+		"from_size", from.Pool.Size(), "to_size", to.Pool.Size())
+}
+
+// fraction returns how full a channel is, or 0 for none
+func fraction(occupancy func() (length, capacity int)) float64 {
+	if occupancy == nil {
+		return 0
+	}
+	length, capacity := occupancy()
+	if capacity == 0 {
+		return 0
+	}
+	return float64(length) / float64(capacity)
+}
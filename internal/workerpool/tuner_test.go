@@ -0,0 +1,70 @@
+package workerpool
+
+import "testing"
+
+// fakePool is a Resizable with fixed activity
+type fakePool struct {
+	size   int
+	active int
+}
+
+func (p *fakePool) Size() int    { return p.size }
+func (p *fakePool) Active() int  { return min(p.active, p.size) }
+func (p *fakePool) Resize(n int) { p.size = max(n, 1) }
+
+// queue returns a fixed channel occupancy
+func queue(length, capacity int) func() (int, int) {
+	return func() (int, int) { return length, capacity }
+}
+
+func TestTuner_MovesWorkersToBottleneck(t *testing.T) {
+	fetchers := &fakePool{size: 4, active: 4}
+	parsers := &fakePool{size: 8, active: 1}
+
+	tuner := NewTuner(nil,
+		Stage{Name: "fetch", Pool: fetchers, Input: queue(100, 100), Output: queue(0, 50)},
+		Stage{Name: "parse", Pool: parsers, Input: queue(0, 50), Output: queue(0, 50)},
+	)
+	tuner.Sample()
+	tuner.Adjust()
+
+	if fetchers.size != 6 || parsers.size != 6 {
+		t.Errorf("Expected 2 workers moved to fetch (6/6), got %d/%d", fetchers.size, parsers.size)
+	}
+}
+
+func TestTuner_IgnoresStageBlockedDownstream(t *testing.T) {
+	fetchers := &fakePool{size: 4, active: 4}
+	parsers := &fakePool{size: 4, active: 4}
+	processors := &fakePool{size: 4, active: 0}
+
+	// Fetchers look busy only because the parsers' input is full
+	tuner := NewTuner(nil,
+		Stage{Name: "fetch", Pool: fetchers, Input: queue(100, 100), Output: queue(50, 50)},
+		Stage{Name: "parse", Pool: parsers, Input: queue(50, 50), Output: queue(0, 50)},
+		Stage{Name: "process", Pool: processors, Input: queue(0, 50), Output: nil},
+	)
+	tuner.Sample()
+	tuner.Adjust()
+
+	if fetchers.size != 4 || parsers.size != 5 || processors.size != 3 {
+		t.Errorf("Expected one worker moved from process to parse (4/5/3), got %d/%d/%d",
+			fetchers.size, parsers.size, processors.size)
+	}
+}
+
+func TestTuner_NoMoveWithoutDonor(t *testing.T) {
+	fetchers := &fakePool{size: 4, active: 4}
+	parsers := &fakePool{size: 1, active: 0}
+
+	tuner := NewTuner(nil,
+		Stage{Name: "fetch", Pool: fetchers, Input: queue(100, 100)},
+		Stage{Name: "parse", Pool: parsers, Input: queue(0, 50)},
+	)
+	tuner.Sample()
+	tuner.Adjust()
+
+	if fetchers.size != 4 || parsers.size != 1 {
+		t.Errorf("Expected no change when the only idle stage has one worker, got %d/%d", fetchers.size, parsers.size)
+	}
+}
@@ -0,0 +1,113 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool runs a resizable set of goroutines that each take items from one input
+// channel. Workers exit when the input is closed or the context is canceled;
+// a worker removed by Resize finishes its current item first.
+type Pool[T any] struct {
+	in         <-chan T
+	newHandler func(id int) func(ctx context.Context, item T)
+
+	mu     sync.Mutex
+	ctx    context.Context
+	quit   []chan struct{} // One per running worker, newest last
+	nextID int
+	closed bool // Input closed or context canceled; the pool no longer grows
+
+	active atomic.Int64 // Workers currently handling an item
+	wg     sync.WaitGroup
+}
+
+// New creates a Pool reading from in. newHandler is called once per worker
+// with a unique ID and returns the function that handles each item.
+func New[T any](in <-chan T, newHandler func(id int) func(ctx context.Context, item T)) *Pool[T] {
+	return &Pool[T]{in: in, newHandler: newHandler}
+}
+
+// Start launches n workers (at least one) that run until ctx is canceled or the input is closed
+func (p *Pool[T]) Start(ctx context.Context, n int) {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+
+	p.Resize(n)
+}
+
+// Resize grows or shrinks the pool to n workers (at least one). It does nothing
+// once the pool has started shutting down.
+func (p *Pool[T]) Resize(n int) {
+	n = max(n, 1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.ctx == nil {
+		return
+	}
+
+	for len(p.quit) < n {
+		quit := make(chan struct{})
+		p.quit = append(p.quit, quit)
+		p.wg.Add(1)
+		go p.run(p.ctx, p.newHandler(p.nextID), quit)
+		p.nextID++
+	}
+	for len(p.quit) > n {
+		last := len(p.quit) - 1
+		close(p.quit[last])
+		p.quit = p.quit[:last]
+	}
+}
+
+// Size returns the number of workers the pool is running
+func (p *Pool[T]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.quit)
+}
+
+// Active returns the number of workers currently handling an item
+func (p *Pool[T]) Active() int {
+	return int(p.active.Load())
+}
+
+// Wait blocks until every worker has exited
+func (p *Pool[T]) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool[T]) run(ctx context.Context, handle func(ctx context.Context, item T), quit <-chan struct{}) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case item, ok := <-p.in:
+			if !ok {
+				p.shutdown()
+				return
+			}
+			p.active.Add(1)
+			handle(ctx, item)
+			p.active.Add(-1)
+		case <-ctx.Done():
+			p.shutdown()
+			return
+		}
+	}
+}
+
+// shutdown stops the pool from growing once its workers start exiting for good.
+// It runs before the exiting worker is released from the wait group, so a worker
+// is never added after the count has reached zero.
+func (p *Pool[T]) shutdown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+}
@@ -0,0 +1,116 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_ProcessesAllItems(t *testing.T) {
+	in := make(chan int)
+	var sum atomic.Int64
+	ids := sync.Map{}
+
+	pool := New(in, func(id int) func(ctx context.Context, item int) {
+		return func(ctx context.Context, item int) {
+			ids.Store(id, true)
+			sum.Add(int64(item))
+		}
+	})
+	pool.Start(context.Background(), 4)
+
+	for i := 1; i <= 100; i++ {
+		in <- i
+	}
+	close(in)
+	pool.Wait()
+
+	if got := sum.Load(); got != 5050 {
+		t.Errorf("Expected sum 5050, got %d", got)
+	}
+	ids.Range(func(key, _ any) bool {
+		if id := key.(int); id < 0 || id >= 4 {
+			t.Errorf("Expected worker IDs 0-3, got %d", id)
+		}
+		return true
+	})
+}
+
+func TestPool_Resize(t *testing.T) {
+	in := make(chan int)
+	release := make(chan struct{})
+	var running atomic.Int64
+
+	pool := New(in, func(int) func(ctx context.Context, item int) {
+		return func(ctx context.Context, item int) {
+			running.Add(1)
+			<-release
+			running.Add(-1)
+		}
+	})
+	pool.Start(context.Background(), 2)
+
+	pool.Resize(5)
+	if got := pool.Size(); got != 5 {
+		t.Fatalf("Expected 5 workers, got %d", got)
+	}
+
+	// Every worker can hold an item at once
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool { return pool.Active() == 5 })
+
+	pool.Resize(0)
+	if got := pool.Size(); got != 1 {
+		t.Errorf("Expected resize to keep at least 1 worker, got %d", got)
+	}
+
+	// Removed workers finish their current item before exiting
+	close(release)
+	waitFor(t, func() bool { return running.Load() == 0 })
+
+	close(in)
+	pool.Wait()
+
+	pool.Resize(3)
+	if got := pool.Size(); got != 1 {
+		t.Errorf("Expected a closed pool not to grow, got %d workers", got)
+	}
+}
+
+func TestPool_StopsOnCancel(t *testing.T) {
+	in := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pool := New(in, func(int) func(ctx context.Context, item int) {
+		return func(ctx context.Context, item int) {}
+	})
+	pool.Start(ctx, 3)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected workers to exit on cancel")
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}